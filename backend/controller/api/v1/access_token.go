@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 创建个人访问令牌
+// @Description 创建用于脚本和CI的个人访问令牌，令牌只在本次响应中返回，请立即保存。可用的权限范围：articles:write、photos:write、comments:write
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.CreateAccessTokenService true "名称、权限范围和有效天数"
+// @Success 200 {object} internal.Response{data=service.CreatedAccessToken}
+// @Failure 20240 {object} internal.Response{data=string}
+// @Failure 20241 {object} internal.Response{data=string}
+// @Router /user/accessTokens [post]
+func CreateAccessToken(c *gin.Context) {
+	var service service.CreateAccessTokenService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	token, err := service.Create()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, token)
+}
+
+// @Summary 获取个人访问令牌列表
+// @Description 获取当前用户未吊销的个人访问令牌，不包含令牌本身
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.PersonalAccessToken}
+// @Router /user/accessTokens [get]
+func ListAccessTokens(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.ListAccessTokensService{UserID: uid}
+	tokens, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, tokens)
+}
+
+// @Summary 吊销个人访问令牌
+// @Description 吊销个人访问令牌，吊销后立即失效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "令牌ID"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20239 {object} internal.Response{data=string}
+// @Router /user/accessTokens/{id} [delete]
+func RevokeAccessToken(c *gin.Context) {
+	var service service.RevokeAccessTokenService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Revoke(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 申请注销账号
+// @Description 验证密码后申请注销账号，开启两步验证时还需要验证码或恢复码。mode为anonymize时保留内容并匿名化作者，为delete时删除文章和照片。冷静期结束后账号被永久删除，冷静期内可以撤销
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.ScheduleAccountDeletionService true "密码、验证码和处理内容的方式"
+// @Success 200 {object} internal.Response{data=service.AccountDeletionStatus}
+// @Failure 20204 {object} internal.Response{data=string}
+// @Failure 20227 {object} internal.Response{data=string}
+// @Failure 20247 {object} internal.Response{data=string}
+// @Router /user/accountDeletion [post]
+func ScheduleAccountDeletion(c *gin.Context) {
+	var service service.ScheduleAccountDeletionService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	status, err := service.Schedule()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, status)
+}
+
+// @Summary 获取账号注销状态
+// @Description 获取当前账号是否已申请注销以及计划删除的时间
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=service.AccountDeletionStatus}
+// @Router /user/accountDeletion [get]
+func GetAccountDeletion(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.GetAccountDeletionService{UserID: uid}
+	status, err := service.Get()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, status)
+}
+
+// @Summary 撤销注销账号
+// @Description 在冷静期内撤销注销申请
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20248 {object} internal.Response{data=string}
+// @Router /user/accountDeletion [delete]
+func CancelAccountDeletion(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.CancelAccountDeletionService{UserID: uid}
+	if err := service.Cancel(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,333 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/middleware"
+	"blog-server/internal/rbac"
+	"blog-server/internal/utils"
+	"blog-server/service"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserController 用户管理控制器
+type AdminUserController struct{}
+
+// adminOperator 获取执行管理操作的管理员，用于记录审计日志
+func adminOperator(c *gin.Context) (service.AdminOperator, error) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return service.AdminOperator{}, code.ErrUserNotFound
+	}
+	return service.AdminOperator{
+		ID:        uid,
+		UserName:  c.GetString("username"),
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}, nil
+}
+
+// @Summary 查询用户
+// @Description 按关键词、角色、状态等条件查询用户，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param keyword query string false "用户名、昵称或邮箱关键词"
+// @Param role query string false "角色"
+// @Param status query string false "状态" Enums(active, inactive, suspended)
+// @Param locked query bool false "只返回被锁定的用户"
+// @Param deleted query bool false "只返回已删除的用户"
+// @Param page query int false "页码，默认为1"
+// @Param size query int false "每页数量，默认为20，最大为100"
+// @Success 200 {object} internal.Response{data=[]service.AdminUser}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users [get]
+func AdminListUsers(c *gin.Context) {
+	var service service.AdminListUsersService
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	users, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"users": users,
+		"total": total,
+	})
+}
+
+// @Summary 获取用户详情
+// @Description 获取用户的状态、锁定和登录信息，包括已删除的用户，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} internal.Response{data=service.AdminUser}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users/{id} [get]
+func AdminGetUser(c *gin.Context) {
+	var service service.AdminGetUserService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	user, err := service.Get()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, user)
+}
+
+// @Summary 获取用户登录记录
+// @Description 获取用户的登录记录，包括失败的登录，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param page query int false "页码，默认为1"
+// @Param size query int false "每页数量，默认为20，最大为100"
+// @Success 200 {object} internal.Response{data=[]service.AdminLoginRecord}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/logins [get]
+func AdminListUserLogins(c *gin.Context) {
+	var service service.AdminListLoginsService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	logins, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"logins": logins,
+		"total":  total,
+	})
+}
+
+// @Summary 获取用户审计日志
+// @Description 获取管理员对该用户执行过的操作，包括失败的操作，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param page query int false "页码，默认为1"
+// @Param size query int false "每页数量，默认为20，最大为100"
+// @Success 200 {object} internal.Response{data=[]models.OperationLog}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/audit-logs [get]
+func AdminListUserAuditLogs(c *gin.Context) {
+	var service service.AdminListAuditLogsService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	logs, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"logs":  logs,
+		"total": total,
+	})
+}
+
+// @Summary 封禁用户
+// @Description 封禁用户并吊销用户已签发的令牌，不能封禁自己，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminUserActionService false "封禁原因"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20908 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/suspend [put]
+func AdminSuspendUser(c *gin.Context) {
+	adminUserAction(c, (*service.AdminUserActionService).Suspend)
+}
+
+// @Summary 解除封禁
+// @Description 解除用户的封禁，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminUserActionService false "操作原因"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/reactivate [put]
+func AdminReactivateUser(c *gin.Context) {
+	adminUserAction(c, (*service.AdminUserActionService).Reactivate)
+}
+
+// @Summary 解除锁定
+// @Description 解除用户因密码错误次数过多导致的锁定，并清零失败次数，需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminUserActionService false "操作原因"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/unlock [put]
+func AdminUnlockUser(c *gin.Context) {
+	adminUserAction(c, (*service.AdminUserActionService).Unlock)
+}
+
+// @Summary 要求重置密码
+// @Description 吊销用户已签发的令牌并发送重置密码邮件，用户重置密码前所有登录方式都返回20242。需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminUserActionService false "操作原因"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20223 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20908 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/force-password-reset [post]
+func AdminForcePasswordReset(c *gin.Context) {
+	adminUserAction(c, (*service.AdminUserActionService).ForcePasswordReset)
+}
+
+// @Summary 删除用户
+// @Description 软删除用户，记录删除人和删除原因，并吊销用户已签发的令牌，删除原因必填。需要users:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminUserActionService true "删除原因"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20908 {object} internal.Response{data=string}
+// @Router /admin/users/{id} [delete]
+func AdminDeleteUser(c *gin.Context) {
+	adminUserAction(c, (*service.AdminUserActionService).Delete)
+}
+
+// adminUserAction 绑定参数并执行管理员操作用户的接口
+func adminUserAction(c *gin.Context, action func(*service.AdminUserActionService) error) {
+	var service service.AdminUserActionService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	// 请求体可以为空
+	if err := c.ShouldBindJSON(&service); err != nil && !errors.Is(err, io.EOF) {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	operator, err := adminOperator(c)
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	service.Operator = operator
+
+	if err := action(&service); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 修改用户角色
+// @Description 修改用户的角色，用户已签发的令牌会被吊销。不能修改自己的角色，需要users:manage和roles:manage权限
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param data body service.AdminChangeRoleService true "新角色"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20902 {object} internal.Response{data=string}
+// @Failure 20907 {object} internal.Response{data=string}
+// @Failure 20908 {object} internal.Response{data=string}
+// @Router /admin/users/{id}/role [put]
+func AdminChangeUserRole(c *gin.Context) {
+	var service service.AdminChangeRoleService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	operator, err := adminOperator(c)
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	service.Operator = operator
+
+	if err := service.ChangeRole(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// InitRouter 初始化用户管理路由，所有接口都需要users:manage权限
+func (controller *AdminUserController) InitRouter(router *gin.RouterGroup) error {
+	logger.Logger.Info("init admin user controller")
+
+	adminUserGroup := router.Group("/admin/users")
+	adminUserGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequirePermission(rbac.PermUsersManage))
+	adminUserGroup.GET("", AdminListUsers)
+	adminUserGroup.GET("/:id", AdminGetUser)
+	adminUserGroup.GET("/:id/logins", AdminListUserLogins)
+	adminUserGroup.GET("/:id/audit-logs", AdminListUserAuditLogs)
+	adminUserGroup.PUT("/:id/suspend", AdminSuspendUser)
+	adminUserGroup.PUT("/:id/reactivate", AdminReactivateUser)
+	adminUserGroup.PUT("/:id/unlock", AdminUnlockUser)
+	adminUserGroup.PUT("/:id/role", middleware.RequirePermission(rbac.PermRolesManage), AdminChangeUserRole)
+	adminUserGroup.POST("/:id/force-password-reset", AdminForcePasswordReset)
+	adminUserGroup.DELETE("/:id", AdminDeleteUser)
+
+	return nil
+}
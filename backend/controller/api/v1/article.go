@@ -2,8 +2,10 @@ package v1
 
 import (
 	"blog-server/internal"
+	"blog-server/internal/accesstoken"
 	"blog-server/internal/code"
 	"blog-server/internal/middleware"
+	"blog-server/internal/rbac"
 	"blog-server/service"
 
 	"github.com/gin-gonic/gin"
@@ -80,6 +82,7 @@ func (a *ArticleController) UpdateArticle(c *gin.Context) {
 	}
 	// 将uuid.UUID转换为字符串
 	updateService.UserID = userID.(string)
+	updateService.Role = c.GetString("role")
 
 	article, err := updateService.Update(c)
 	if err != nil {
@@ -121,6 +124,7 @@ func (a *ArticleController) DeleteArticle(c *gin.Context) {
 		internal.APIResponse(c, code.ErrParam, nil)
 		return
 	}
+	deleteService.Role = c.GetString("role")
 
 	if err := deleteService.Delete(c); err != nil {
 		internal.APIResponse(c, err, nil)
@@ -271,6 +275,7 @@ func (a *ArticleController) UpdateArticleStatus(c *gin.Context) {
 	}
 	// 将uuid.UUID转换为字符串
 	updateStatusService.UserID = userID.(string)
+	updateStatusService.Role = c.GetString("role")
 
 	if err := updateStatusService.UpdateStatus(c); err != nil {
 		internal.APIResponse(c, err, nil)
@@ -282,7 +287,7 @@ func (a *ArticleController) UpdateArticleStatus(c *gin.Context) {
 
 // GetArticlesByRole 根据用户角色获取文章
 // @Summary 根据用户角色获取文章
-// @Description 管理员返回所有用户的全部文章，非管理员返回用户自己创建的文章。支持多种排序方式。
+// @Description 拥有articles:moderate权限的用户返回所有用户的全部文章，其他用户返回自己创建的文章。支持多种排序方式。
 // @Tags article
 // @Accept json
 // @Produce json
@@ -326,14 +331,8 @@ func (a *ArticleController) GetArticlesByRole(c *gin.Context) {
 		return
 	}
 
-	userRole, exists := c.Get("role")
-	if !exists {
-		// 如果JWT中没有角色信息，默认为普通用户
-		userRole = "user"
-	}
-
 	// 调用服务获取文章
-	articles, total, err := getArticlesByRoleService.GetArticlesByRole(userID.(string), userRole.(string))
+	articles, total, err := getArticlesByRoleService.GetArticlesByRole(userID.(string), c.GetString("role"))
 	if err != nil {
 		internal.APIResponse(c, err, nil)
 		return
@@ -355,8 +354,8 @@ func (a *ArticleController) InitRouter(Router *gin.RouterGroup) error {
 	articleRouter.GET(":id", a.GetArticle)                    // 获取文章详情
 	// --------------------需要认证-------------------------
 	authGroup := articleRouter.Group("")
-	authGroup.Use(middleware.JWTAuthMiddleware())
-	authGroup.POST("", a.CreateArticle)                    // 创建文章
+	authGroup.Use(middleware.JWTAuthMiddleware(accesstoken.ScopeArticlesWrite))
+	authGroup.POST("", middleware.RequirePermission(rbac.PermArticlesWrite), a.CreateArticle) // 创建文章
 	authGroup.PUT(":id", a.UpdateArticle)                 // 更新文章
 	authGroup.DELETE(":id", a.DeleteArticle)              // 删除文章
 	authGroup.POST(":id/like", a.LikeArticle)             // 点赞文章
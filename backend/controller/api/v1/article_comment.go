@@ -2,17 +2,27 @@ package v1
 
 import (
 	"blog-server/internal"
+	"blog-server/internal/accesstoken"
 	IError "blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/middleware"
+	"blog-server/internal/pubsub"
+	"blog-server/internal/rbac"
+	"blog-server/internal/utils"
+	"blog-server/internal/ws"
 	"blog-server/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 评论导入文件的大小上限
+const commentImportMaxSize = 20 << 20
+
 type ArticleCommentController struct {
 }
 
 // @Summary 添加评论
-// @Description 添加评论
+// @Description 添加评论，登录用户的评论之后可以编辑和删除
 // @Tags article_comment
 // @Accept json
 // @Produce json
@@ -23,6 +33,10 @@ type ArticleCommentController struct {
 func AddComment(c *gin.Context) {
 	var service service.AddCommentService
 	if err := c.ShouldBind(&service); err == nil {
+		// 登录用户的评论记录评论者，用于之后编辑和删除
+		if uid, err := utils.GetUserIDFromContext(c); err == nil {
+			service.UserID = uid
+		}
 		if err := service.AddComment(); err != nil {
 			internal.APIResponse(c, IError.ErrArticleCommentCreateFailed, err.Error())
 		} else {
@@ -38,6 +52,7 @@ func AddComment(c *gin.Context) {
 // @Tags article_comment
 // @Accept json
 // @Produce json
+// @Param article_id query string false "文章ID"
 // @Param article_title query string false "文章标题"
 // @Param is_notify query bool false "是否通知"
 // @Param is_read query bool false "是否已读"
@@ -61,10 +76,238 @@ func ListComment(c *gin.Context) {
 	}
 }
 
+// @Summary 审核评论
+// @Description 审核通过评论，并实时推送给正在阅读该文章的用户。拥有comments:moderate权限的用户或文章作者可以审核
+// @Tags article_comment
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "评论ID"
+// @Success 200 {object} internal.Response{data=models.ArticleComment}
+// @Failure 20403 {object} internal.Response{data=string}
+// @Failure 20404 {object} internal.Response{data=string}
+// @Router /article_comment/{id}/approve [put]
+func ApproveComment(c *gin.Context) {
+	var approveService service.ApproveCommentService
+	if err := c.ShouldBindUri(&approveService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, IError.ErrUserNotFound, nil)
+		return
+	}
+	approveService.UserID = uid
+	approveService.Role = c.GetString("role")
+
+	comment, err := approveService.Approve()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, IError.OK, comment)
+}
+
+// @Summary 编辑评论
+// @Description 评论者在发布后的可编辑时间内编辑自己的评论，编辑前的内容保存为历史版本
+// @Tags article_comment
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "评论ID"
+// @Param comment body service.EditCommentService true "评论内容"
+// @Success 200 {object} internal.Response{data=models.ArticleComment}
+// @Failure 20404 {object} internal.Response{data=string}
+// @Failure 20407 {object} internal.Response{data=string}
+// @Router /article_comment/{id} [put]
+func EditComment(c *gin.Context) {
+	var editService service.EditCommentService
+	if err := c.ShouldBindUri(&editService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBind(&editService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, IError.ErrUserNotFound, nil)
+		return
+	}
+	editService.UserID = uid
+
+	comment, err := editService.Edit()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, IError.OK, comment)
+}
+
+// @Summary 删除评论
+// @Description 评论者可以删除自己的评论，拥有comments:moderate权限的用户和文章作者可以删除文章下的任意评论。有回复的评论保留为已删除的占位
+// @Tags article_comment
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "评论ID"
+// @Success 200 {object} internal.Response
+// @Failure 20403 {object} internal.Response{data=string}
+// @Failure 20404 {object} internal.Response{data=string}
+// @Router /article_comment/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	var deleteService service.DeleteCommentService
+	if err := c.ShouldBindUri(&deleteService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, IError.ErrUserNotFound, nil)
+		return
+	}
+	deleteService.UserID = uid
+	deleteService.Role = c.GetString("role")
+
+	if err := deleteService.Delete(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, IError.OK, nil)
+}
+
+// @Summary 评论历史版本
+// @Description 获取评论的编辑历史，评论者本人、拥有comments:moderate权限的用户和文章作者可以查看
+// @Tags article_comment
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "评论ID"
+// @Success 200 {object} internal.Response{data=[]models.CommentRevision}
+// @Failure 20404 {object} internal.Response{data=string}
+// @Router /article_comment/{id}/revisions [get]
+func ListCommentRevisions(c *gin.Context) {
+	var revisionsService service.ListCommentRevisionsService
+	if err := c.ShouldBindUri(&revisionsService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, IError.ErrUserNotFound, nil)
+		return
+	}
+	revisionsService.UserID = uid
+	revisionsService.Role = c.GetString("role")
+
+	revisions, err := revisionsService.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, IError.OK, revisions)
+}
+
+// @Summary 导入评论
+// @Description 导入Disqus XML或Twikoo/Valine JSON导出的评论，需要comments:import权限。
+// @Description 评论串依次按链接中的文章ID、链接slug和标题匹配文章，导入的评论保留原时间、昵称和回复关系，并标记为审核通过。
+// @Description dry_run为true时只返回匹配报告，不写入数据库
+// @Tags article_comment
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "导出文件"
+// @Param source formData string true "评论来源" Enums(disqus, twikoo, valine)
+// @Param dry_run formData bool false "试运行"
+// @Success 200 {object} internal.Response{data=service.CommentImportReport}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20410 {object} internal.Response{data=string}
+// @Failure 20411 {object} internal.Response{data=string}
+// @Router /article_comment/import [post]
+func ImportComments(c *gin.Context) {
+	var importService service.ImportCommentsService
+	if err := c.ShouldBind(&importService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Logger.Warnf("close import file failed: %v", err)
+		}
+	}()
+	if header.Size > commentImportMaxSize {
+		internal.APIResponse(c, IError.ErrBind, "文件不能超过20MB")
+		return
+	}
+
+	report, err := importService.Import(file)
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, IError.OK, report)
+}
+
+// @Summary 实时评论
+// @Description 通过WebSocket实时推送文章评论的审核通过(comment.approved)、编辑(comment.updated)和删除(comment.deleted)事件。
+// @Description 浏览器无法为WebSocket设置请求头，可以通过查询参数access_token传递令牌。
+// @Description 服务端每54秒发送一次ping帧，客户端也可以发送{"type":"ping"}消息，服务端回复{"type":"pong"}；客户端消息超过频率限制时连接会被关闭。
+// @Tags article_comment
+// @Security ApiKeyAuth
+// @Param article_id path string true "文章ID"
+// @Param access_token query string false "访问令牌"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 20505 {object} internal.Response{data=string}
+// @Failure 20406 {object} internal.Response{data=string}
+// @Router /article_comment/ws/{article_id} [get]
+func WatchComments(c *gin.Context) {
+	var watchService service.WatchArticleCommentsService
+	if err := c.ShouldBindUri(&watchService); err != nil {
+		internal.APIResponse(c, IError.ErrBind, nil)
+		return
+	}
+
+	subscriber, err := watchService.Watch()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	defer pubsub.Unsubscribe(subscriber)
+
+	conn, err := ws.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade失败时已经向客户端写入了错误响应
+		logger.Logger.Debugf("upgrade websocket failed: %v", err)
+		return
+	}
+	ws.NewClient(conn, subscriber.C).Run()
+}
+
 func (controller *ArticleCommentController) InitRouter(router *gin.RouterGroup) error {
 	articleCommentGroup := router.Group("/article_comment")
-	articleCommentGroup.POST("/add", AddComment)
+	articleCommentGroup.POST("/add", middleware.OptionalJWTAuthMiddleware(), AddComment)
 	articleCommentGroup.GET("/list", ListComment)
+	articleCommentGroup.GET("/ws/:article_id", middleware.JWTAuthQueryMiddleware(), WatchComments)
+
+	authGroup := articleCommentGroup.Group("")
+	authGroup.Use(middleware.JWTAuthMiddleware(accesstoken.ScopeCommentsWrite))
+	authGroup.POST("/import", middleware.RequirePermission(rbac.PermCommentsImport), ImportComments)
+	authGroup.PUT("/:id/approve", ApproveComment)
+	authGroup.PUT("/:id", EditComment)
+	authGroup.DELETE("/:id", DeleteComment)
+	authGroup.GET("/:id/revisions", ListCommentRevisions)
 
 	return nil
 }
@@ -2,9 +2,11 @@ package v1
 
 import (
 	"blog-server/internal"
+	"blog-server/internal/accesstoken"
 	IError "blog-server/internal/code"
 	logger "blog-server/internal/logger"
 	"blog-server/internal/middleware"
+	"blog-server/internal/rbac"
 	"blog-server/internal/models"
 	"blog-server/service"
 
@@ -240,6 +242,7 @@ func UpdateDailyPhotograph(c *gin.Context) {
 		internal.APIResponse(c, IError.ErrParam, nil)
 		return
 	}
+	service.Role = c.GetString("role")
 
 	if err := c.ShouldBind(&service); err != nil {
 		internal.APIResponse(c, IError.ErrParam, err.Error())
@@ -280,6 +283,7 @@ func DeleteDailyPhotograph(c *gin.Context) {
 		internal.APIResponse(c, IError.ErrParam, nil)
 		return
 	}	
+	service.Role = c.GetString("role")
 
 	// 绑定查询参数
 	if err := c.ShouldBindUri(&service); err != nil {
@@ -332,8 +336,8 @@ func (controller *DailyPhotographController) InitRouter(engine *gin.RouterGroup)
 	dailyPhotographGroup.POST("/like/:photo_id", LikeDailyPhotograph)
 	// --------------------需要认证-------------------------
 	authDailyPhotographGroup := dailyPhotographGroup.Group("")
-	authDailyPhotographGroup.Use(middleware.JWTAuthMiddleware())
-	authDailyPhotographGroup.POST("/create", CreateDailyPhotograph)
+	authDailyPhotographGroup.Use(middleware.JWTAuthMiddleware(accesstoken.ScopePhotosWrite))
+	authDailyPhotographGroup.POST("/create", middleware.RequirePermission(rbac.PermPhotosWrite), CreateDailyPhotograph)
 	authDailyPhotographGroup.PUT("/update", UpdateDailyPhotograph)
 	authDailyPhotographGroup.DELETE("/delete/:photo_id", DeleteDailyPhotograph)
 
@@ -12,7 +12,6 @@ import (
 
 	"blog-server/internal"
 	"blog-server/internal/models"
-	"blog-server/internal/rsa"
 	"blog-server/internal/utils"
 	IError "blog-server/internal/code"
 
@@ -57,7 +56,7 @@ func setupTestData(t *testing.T) *TestData {
 		"nbf":      time.Now().Unix(),
 		"type":     "access",
 	}
-	token, err := utils.GenerateJWT(claims, rsa.PrivateKey)
+	token, err := utils.GenerateJWT(claims)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -85,7 +84,7 @@ func setupTestData(t *testing.T) *TestData {
 		"nbf":      time.Now().Unix(),
 		"type":     "access",
 	}
-	otherToken, err := utils.GenerateJWT(otherClaims, rsa.PrivateKey)
+	otherToken, err := utils.GenerateJWT(otherClaims)
 	if err != nil {
 		t.Fatalf("Failed to generate other token: %v", err)
 	}
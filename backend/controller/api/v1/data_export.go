@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/utils"
+	"blog-server/service"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 申请导出个人数据
+// @Description 在后台打包个人资料、文章、照片、评论、点赞、登录会话和操作日志，完成后发送邮件通知。同一时间只能有一个进行中的导出，24小时内只能申请一次
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=models.DataExport}
+// @Failure 20243 {object} internal.Response{data=string}
+// @Failure 20244 {object} internal.Response{data=string}
+// @Router /user/dataExports [post]
+func RequestDataExport(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.RequestDataExportService{UserID: uid}
+	export, err := service.Request()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, export)
+}
+
+// @Summary 获取个人数据导出列表
+// @Description 获取当前用户的导出记录，按申请时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.DataExport}
+// @Router /user/dataExports [get]
+func ListDataExports(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.ListDataExportsService{UserID: uid}
+	exports, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, exports)
+}
+
+// @Summary 下载个人数据
+// @Description 下载已完成的个人数据导出，返回zip文件
+// @Tags user
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param id path string true "导出ID"
+// @Success 200 {file} file
+// @Failure 20245 {object} internal.Response{data=string}
+// @Failure 20246 {object} internal.Response{data=string}
+// @Router /user/dataExports/{id}/download [get]
+func DownloadDataExport(c *gin.Context) {
+	var service service.DownloadDataExportService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	file, size, fileName, err := service.Download()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Logger.Errorf("close data export file failed: %v", err)
+		}
+	}()
+	c.DataFromReader(http.StatusOK, size, "application/zip", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, fileName),
+	})
+}
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 申请修改邮箱
+// @Description 验证密码后向新邮箱发送验证码，开启两步验证时还需要验证码或恢复码。验证码15分钟内有效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.RequestEmailChangeService true "新邮箱、密码和验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20204 {object} internal.Response{data=string}
+// @Failure 20213 {object} internal.Response{data=string}
+// @Failure 20220 {object} internal.Response{data=string}
+// @Failure 20251 {object} internal.Response{data=string}
+// @Router /user/emailChange [post]
+func RequestEmailChange(c *gin.Context) {
+	var service service.RequestEmailChangeService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Request(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, nil)
+}
+
+// @Summary 确认修改邮箱
+// @Description 使用新邮箱收到的验证码确认修改，修改成功后会通知旧邮箱。验证码输错5次后需要重新申请
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.ConfirmEmailChangeService true "新邮箱收到的验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20217 {object} internal.Response{data=string}
+// @Failure 20220 {object} internal.Response{data=string}
+// @Failure 20252 {object} internal.Response{data=string}
+// @Router /user/emailChange/confirm [post]
+func ConfirmEmailChange(c *gin.Context) {
+	var service service.ConfirmEmailChangeService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+	service.IP = c.ClientIP()
+	service.UserAgent = c.GetHeader("User-Agent")
+
+	email, err := service.Confirm()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, email)
+}
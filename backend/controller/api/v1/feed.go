@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/middleware"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedController 关注动态控制器
+type FeedController struct{}
+
+// @Summary 获取关注动态
+// @Description 获取关注的作者发布的文章和公开的照片，按发布时间倒序。使用上一页返回的next_cursor获取下一页，next_cursor为空表示没有更多动态
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param cursor query string false "分页游标"
+// @Param limit query int false "每页数量，最大为50" default(20)
+// @Success 200 {object} internal.Response{data=object{items=[]service.FeedItem,next_cursor=string}}
+// @Failure 20257 {object} internal.Response{data=string}
+// @Router /feed [get]
+func GetFeed(c *gin.Context) {
+	var service service.FeedService
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	items, next, err := service.Feed()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"items":       items,
+		"next_cursor": next,
+	})
+}
+
+// InitRouter 初始化关注动态路由
+func (controller *FeedController) InitRouter(router *gin.RouterGroup) error {
+	logger.Logger.Info("init feed controller")
+
+	feedGroup := router.Group("/feed")
+	feedGroup.Use(middleware.JWTAuthMiddleware())
+	feedGroup.GET("", GetFeed)
+
+	return nil
+}
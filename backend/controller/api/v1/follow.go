@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindFollowUserService 绑定路径中的用户名和当前登录用户
+func bindFollowUserService(c *gin.Context) (*service.FollowUserService, bool) {
+	var service service.FollowUserService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return nil, false
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return nil, false
+	}
+	service.UserID = uid
+	return &service, true
+}
+
+// @Summary 关注用户
+// @Description 关注用户后，用户发布的文章和公开的照片会出现在关注动态中。已经关注时直接返回成功
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param username path string true "用户名"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20256 {object} internal.Response{data=string}
+// @Router /users/{username}/follow [post]
+func FollowUser(c *gin.Context) {
+	service, ok := bindFollowUserService(c)
+	if !ok {
+		return
+	}
+	if err := service.Follow(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, nil)
+}
+
+// @Summary 取消关注用户
+// @Description 取消关注用户，没有关注时直接返回成功
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param username path string true "用户名"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/follow [delete]
+func UnfollowUser(c *gin.Context) {
+	service, ok := bindFollowUserService(c)
+	if !ok {
+		return
+	}
+	if err := service.Unfollow(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, nil)
+}
+
+// @Summary 是否关注了用户
+// @Description 获取当前登录用户是否关注了该用户
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param username path string true "用户名"
+// @Success 200 {object} internal.Response{data=object{following=bool}}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/follow [get]
+func GetFollowStatus(c *gin.Context) {
+	service, ok := bindFollowUserService(c)
+	if !ok {
+		return
+	}
+	following, err := service.IsFollowing()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{"following": following})
+}
+
+// @Summary 获取用户的粉丝
+// @Description 分页获取关注该用户的用户，按关注时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "用户名"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量，最大为100" default(20)
+// @Success 200 {object} internal.Response{data=object{users=[]service.FollowListItem,total=int64}}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/followers [get]
+func ListFollowers(c *gin.Context) {
+	listFollows(c, (*service.ListFollowsService).Followers)
+}
+
+// @Summary 获取用户关注的人
+// @Description 分页获取该用户关注的用户，按关注时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "用户名"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量，最大为100" default(20)
+// @Success 200 {object} internal.Response{data=object{users=[]service.FollowListItem,total=int64}}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/following [get]
+func ListFollowing(c *gin.Context) {
+	listFollows(c, (*service.ListFollowsService).Following)
+}
+
+func listFollows(c *gin.Context, list func(*service.ListFollowsService) ([]service.FollowListItem, int64, error)) {
+	var service service.ListFollowsService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	users, total, err := list(&service)
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"users": users,
+		"total": total,
+	})
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取注册方式
+// @Description 获取当前的注册方式：open开放注册，invite_only需要邀请码，closed关闭注册
+// @Tags user
+// @Accept json
+// @Produce json
+// @Success 200 {object} internal.Response{data=service.RegistrationModeResult}
+// @Router /user/registrationMode [get]
+func GetRegistrationMode(c *gin.Context) {
+	internal.APIResponse(c, nil, service.GetRegistrationMode())
+}
+
+// @Summary 创建邀请码
+// @Description 创建邀请码，默认只能使用一次，7天后过期。没有users:manage权限的用户最多可以使用10次、有效期最长30天；设置user以外的角色需要roles:manage权限
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body service.CreateInviteCodeService true "邀请码设置"
+// @Success 200 {object} internal.Response{data=models.InviteCode}
+// @Failure 10002 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20902 {object} internal.Response{data=string}
+// @Router /user/invites [post]
+func CreateInviteCode(c *gin.Context) {
+	var service service.CreateInviteCodeService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+	service.UserRole = c.GetString("role")
+
+	invite, err := service.Create()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, invite)
+}
+
+// @Summary 获取自己创建的邀请码
+// @Description 获取自己创建的邀请码及使用每个邀请码注册的用户，按创建时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]service.InviteCodeWithInvitees}
+// @Router /user/invites [get]
+func ListInviteCodes(c *gin.Context) {
+	var service service.ListInviteCodesService
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	invites, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, invites)
+}
+
+// @Summary 作废邀请码
+// @Description 作废邀请码，已经使用该邀请码注册的用户不受影响。拥有users:manage权限的用户可以作废任何邀请码
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "邀请码ID"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20261 {object} internal.Response{data=string}
+// @Router /user/invites/{id} [delete]
+func RevokeInviteCode(c *gin.Context) {
+	var service service.RevokeInviteCodeService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+	service.UserRole = c.GetString("role")
+
+	if err := service.Revoke(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取登录记录
+// @Description 分页获取当前用户的登录记录，包括IP、设备、大致位置、是否成功以及是否为新设备或新位置，按登录时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量，最大为100" default(20)
+// @Success 200 {object} internal.Response{data=object{records=[]models.LoginRecord,total=int64}}
+// @Failure 10003 {object} internal.Response{data=string}
+// @Router /user/loginHistory [get]
+func ListLoginHistory(c *gin.Context) {
+	var service service.ListLoginHistoryService
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	records, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"records": records,
+		"total":   total,
+	})
+}
+
+// @Summary 确认不是本人登录
+// @Description 使用登录提醒邮件中的令牌确认不是本人登录。这次登录的会话会被吊销，账号所有的会话都会下线，并需要通过邮件重置密码后才能再次登录
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param body body service.DenyLoginService true "登录提醒邮件中的令牌"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 10002 {object} internal.Response{data=string}
+// @Failure 20262 {object} internal.Response{data=string}
+// @Router /user/loginAlert/deny [post]
+func DenyLogin(c *gin.Context) {
+	var service service.DenyLoginService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.IP = c.ClientIP()
+	service.UserAgent = c.GetHeader("User-Agent")
+
+	if err := service.Deny(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
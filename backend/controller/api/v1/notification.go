@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/middleware"
+	"blog-server/internal/pubsub"
+	"blog-server/internal/utils"
+	"blog-server/service"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSE心跳间隔，防止连接被代理服务器因空闲而断开
+const notificationHeartbeatInterval = 30 * time.Second
+
+type NotificationController struct{}
+
+// ListNotifications 获取通知列表
+// @Summary 获取通知列表
+// @Description 获取当前登录用户的站内通知，按创建时间倒序
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Param unread_only query bool false "是否只返回未读通知"
+// @Param type query string false "通知类型"
+// @Success 200 {object} internal.Response{data=object{notifications=[]models.Notification,total=int64}}
+// @Failure 20802 {object} internal.Response{data=string}
+// @Router /notifications [get]
+func (n *NotificationController) ListNotifications(c *gin.Context) {
+	var listService service.ListNotificationsService
+	if err := c.ShouldBindQuery(&listService); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	// 从JWT中获取用户ID
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	listService.UserID = uid
+
+	notifications, total, err := listService.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+
+	internal.APIResponse(c, nil, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"page":          listService.Page,
+		"size":          listService.Size,
+	})
+}
+
+// GetUnreadCount 获取未读通知数量
+// @Summary 获取未读通知数量
+// @Description 获取当前登录用户的未读通知数量
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=object{count=int64}}
+// @Failure 20802 {object} internal.Response{data=string}
+// @Router /notifications/unread-count [get]
+func (n *NotificationController) GetUnreadCount(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	countService := service.UnreadNotificationCountService{UserID: uid}
+	count, err := countService.UnreadCount()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+
+	internal.APIResponse(c, nil, gin.H{
+		"count": count,
+	})
+}
+
+// MarkRead 标记通知已读
+// @Summary 标记通知已读
+// @Description 将指定通知标记为已读
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "通知ID"
+// @Success 200 {object} internal.Response
+// @Failure 20801 {object} internal.Response{data=string}
+// @Failure 20803 {object} internal.Response{data=string}
+// @Router /notifications/{id}/read [put]
+func (n *NotificationController) MarkRead(c *gin.Context) {
+	var markService service.MarkNotificationReadService
+	if err := c.ShouldBindUri(&markService); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	markService.UserID = uid
+
+	if err := markService.MarkRead(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, nil)
+}
+
+// MarkAllRead 全部标记已读
+// @Summary 全部标记已读
+// @Description 将当前登录用户的所有未读通知标记为已读
+// @Tags notification
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=object{updated=int64}}
+// @Failure 20803 {object} internal.Response{data=string}
+// @Router /notifications/read-all [put]
+func (n *NotificationController) MarkAllRead(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	markService := service.MarkAllNotificationsReadService{UserID: uid}
+	updated, err := markService.MarkAllRead()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"updated": updated,
+	})
+}
+
+// StreamNotifications 实时通知推送
+// @Summary 实时通知推送
+// @Description 通过Server-Sent Events实时推送新通知(notification)和未读数量(unread_count)，每30秒发送一次ping心跳。
+// @Description EventSource无法设置请求头，可以通过查询参数access_token传递令牌。
+// @Tags notification
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param access_token query string false "访问令牌"
+// @Success 200 {string} string "event stream"
+// @Failure 20804 {object} internal.Response{data=string}
+// @Router /notifications/stream [get]
+func (n *NotificationController) StreamNotifications(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	subscribeService := service.SubscribeNotificationsService{UserID: uid}
+	subscriber, err := subscribeService.Subscribe()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	defer pubsub.Unsubscribe(subscriber)
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	// 关闭Nginx的响应缓冲
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	// 连接建立后先推送一次未读数量
+	countService := service.UnreadNotificationCountService{UserID: uid}
+	if count, err := countService.UnreadCount(); err == nil {
+		c.SSEvent(service.NotificationEventUnreadCount, gin.H{"count": count})
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(notificationHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case payload := <-subscriber.C:
+			var event struct {
+				Event string          `json:"event"`
+				Data  json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return true
+			}
+			c.SSEvent(event.Event, event.Data)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// InitRouter 初始化通知路由
+func (n *NotificationController) InitRouter(router *gin.RouterGroup) error {
+	notificationRouter := router.Group("/notifications")
+	// --------------------需要认证-------------------------
+	notificationRouter.GET("/stream", middleware.JWTAuthQueryMiddleware(), n.StreamNotifications) // 实时通知推送
+	authGroup := notificationRouter.Group("")
+	authGroup.Use(middleware.JWTAuthMiddleware())
+	authGroup.GET("", n.ListNotifications)           // 获取通知列表
+	authGroup.GET("/unread-count", n.GetUnreadCount) // 获取未读通知数量
+	authGroup.PUT("/read-all", n.MarkAllRead)        // 全部标记已读
+	authGroup.PUT("/:id/read", n.MarkRead)           // 标记通知已读
+	return nil
+}
@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 获取第三方登录方式
+// @Description 返回已启用的第三方登录提供方，用于显示登录按钮
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Success 200 {object} internal.Response{data=[]service.OAuthProviderInfo}
+// @Router /user/oauth/providers [get]
+func ListOAuthProviders(c *gin.Context) {
+	var service service.ListOAuthProvidersService
+	internal.APIResponse(c, nil, service.List())
+}
+
+// @Summary 开始第三方登录
+// @Description 生成跳转到提供方的授权地址。前端需要保存返回的state，回调时比对后再调用完成第三方登录接口
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param provider path string true "提供方名称，例如：github, oidc"
+// @Success 200 {object} internal.Response{data=service.OAuthAuthorization}
+// @Failure 20234 {object} internal.Response{data=string}
+// @Router /user/oauth/{provider}/authorize [post]
+func BeginOAuthLogin(c *gin.Context) {
+	var service service.BeginOAuthLoginService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	authorization, err := service.Begin()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, authorization)
+}
+
+// @Summary 完成第三方登录
+// @Description 提交提供方回调时返回的code和state，返回与登录接口相同的用户信息和令牌。未绑定的第三方账号按已验证的邮箱绑定已有用户或自动创建用户，开启两步验证的用户返回two_factor_required和challenge_token
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param provider path string true "提供方名称，例如：github, oidc"
+// @Param data body service.FinishOAuthLoginService true "授权码和state"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20114 {object} internal.Response{data=string}
+// @Failure 20115 {object} internal.Response{data=string}
+// @Failure 20116 {object} internal.Response{data=string}
+// @Failure 20234 {object} internal.Response{data=string}
+// @Failure 20235 {object} internal.Response{data=string}
+// @Failure 20236 {object} internal.Response{data=string}
+// @Failure 20237 {object} internal.Response{data=string}
+// @Router /user/oauth/{provider}/callback [post]
+func FinishOAuthLogin(c *gin.Context) {
+	var service service.FinishOAuthLoginService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.UserAgent = c.GetHeader("User-Agent")
+	service.IP = c.ClientIP()
+
+	result, err := service.Finish()
+	if err != nil {
+		internal.APIResponse(c, err, gin.H{
+			"success": false,
+		})
+		return
+	}
+	if result.ChallengeToken != "" {
+		// 开启了两步验证，需要调用/user/login/twoFactor完成登录
+		internal.APIResponse(c, nil, gin.H{
+			"success":             true,
+			"two_factor_required": true,
+			"challenge_token":     result.ChallengeToken,
+			"methods":             result.Methods,
+		})
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":       true,
+		"user":          result.User,
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
+	})
+}
+
+// @Summary 获取已绑定的第三方账号
+// @Description 获取当前用户绑定的第三方账号
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.UserIdentity}
+// @Router /user/oauth/identities [get]
+func ListOAuthIdentities(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.ListOAuthIdentitiesService{UserID: uid}
+	identities, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, identities)
+}
+
+// @Summary 解除绑定第三方账号
+// @Description 解除当前用户与第三方账号的绑定
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "提供方名称，例如：github, oidc"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20238 {object} internal.Response{data=string}
+// @Router /user/oauth/identities/{provider} [delete]
+func UnlinkOAuthIdentity(c *gin.Context) {
+	var service service.UnlinkOAuthIdentityService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Unlink(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,270 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/utils"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 开始注册通行密钥
+// @Description 生成WebAuthn注册选项，传给navigator.credentials.create()。选项5分钟内有效
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=object}
+// @Router /user/passkeys/register/begin [post]
+func BeginPasskeyRegistration(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.BeginPasskeyRegistrationService{UserID: uid}
+	options, err := service.Begin()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, options)
+}
+
+// @Summary 完成注册通行密钥
+// @Description 校验navigator.credentials.create()返回的凭证并保存通行密钥
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.FinishPasskeyRegistrationService true "名称和凭证"
+// @Success 200 {object} internal.Response{data=models.Passkey}
+// @Failure 20231 {object} internal.Response{data=string}
+// @Failure 20233 {object} internal.Response{data=string}
+// @Router /user/passkeys/register/finish [post]
+func FinishPasskeyRegistration(c *gin.Context) {
+	var service service.FinishPasskeyRegistrationService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+	service.UserAgent = c.GetHeader("User-Agent")
+
+	passkey, err := service.Finish()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, passkey)
+}
+
+// @Summary 获取通行密钥列表
+// @Description 获取当前用户注册的通行密钥
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.Passkey}
+// @Router /user/passkeys [get]
+func ListPasskeys(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.ListPasskeysService{UserID: uid}
+	passkeys, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, passkeys)
+}
+
+// @Summary 重命名通行密钥
+// @Description 修改通行密钥的名称
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "通行密钥ID"
+// @Param data body service.RenamePasskeyService true "新名称"
+// @Success 200 {object} internal.Response{data=models.Passkey}
+// @Failure 20230 {object} internal.Response{data=string}
+// @Router /user/passkeys/{id} [put]
+func RenamePasskey(c *gin.Context) {
+	var service service.RenamePasskeyService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	passkey, err := service.Rename()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, passkey)
+}
+
+// @Summary 删除通行密钥
+// @Description 删除通行密钥，删除后该凭证不能再用于登录
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "通行密钥ID"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20230 {object} internal.Response{data=string}
+// @Router /user/passkeys/{id} [delete]
+func DeletePasskey(c *gin.Context) {
+	var service service.DeletePasskeyService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Delete(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 开始通行密钥登录
+// @Description 生成无用户名登录的选项，options传给navigator.credentials.get()，完成登录时提交session_id
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Success 200 {object} internal.Response{data=service.PasskeyLoginOptions}
+// @Router /user/login/passkey/begin [post]
+func BeginPasskeyLogin(c *gin.Context) {
+	var service service.BeginPasskeyLoginService
+	options, err := service.Begin()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, options)
+}
+
+// @Summary 完成通行密钥登录
+// @Description 校验navigator.credentials.get()返回的凭证，返回与登录接口相同的用户信息和令牌。通行密钥登录不需要两步验证
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Param data body service.FinishPasskeyLoginService true "会话ID和凭证"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20232 {object} internal.Response{data=string}
+// @Failure 20233 {object} internal.Response{data=string}
+// @Router /user/login/passkey/finish [post]
+func FinishPasskeyLogin(c *gin.Context) {
+	var service service.FinishPasskeyLoginService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.UserAgent = c.GetHeader("User-Agent")
+	service.IP = c.ClientIP()
+
+	user, accessToken, refreshToken, err := service.Finish()
+	if err != nil {
+		internal.APIResponse(c, err, gin.H{
+			"success": false,
+		})
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":       true,
+		"user":          user,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// @Summary 开始用通行密钥完成两步验证
+// @Description 登录接口返回的methods包含passkey时，可以用通行密钥代替验证码。返回的选项传给navigator.credentials.get()
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Param data body service.BeginTwoFactorPasskeyService true "挑战令牌"
+// @Success 200 {object} internal.Response{data=object}
+// @Failure 20228 {object} internal.Response{data=string}
+// @Failure 20230 {object} internal.Response{data=string}
+// @Router /user/login/twoFactor/passkey/begin [post]
+func BeginTwoFactorPasskey(c *gin.Context) {
+	var service service.BeginTwoFactorPasskeyService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	options, err := service.Begin()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, options)
+}
+
+// @Summary 用通行密钥完成两步验证
+// @Description 校验navigator.credentials.get()返回的凭证并完成登录，返回与登录接口相同的用户信息和令牌
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Param data body service.FinishTwoFactorPasskeyService true "挑战令牌和凭证"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20228 {object} internal.Response{data=string}
+// @Failure 20232 {object} internal.Response{data=string}
+// @Failure 20233 {object} internal.Response{data=string}
+// @Router /user/login/twoFactor/passkey/finish [post]
+func FinishTwoFactorPasskey(c *gin.Context) {
+	var service service.FinishTwoFactorPasskeyService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.UserAgent = c.GetHeader("User-Agent")
+	service.IP = c.ClientIP()
+
+	user, accessToken, refreshToken, err := service.Finish()
+	if err != nil {
+		internal.APIResponse(c, err, gin.H{
+			"success": false,
+		})
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":       true,
+		"user":          user,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
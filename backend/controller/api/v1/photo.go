@@ -2,9 +2,11 @@ package v1
 
 import (
 	"blog-server/internal"
+	"blog-server/internal/accesstoken"
 	IError "blog-server/internal/code"
 	logger "blog-server/internal/logger"
 	"blog-server/internal/middleware"
+	"blog-server/internal/rbac"
 	"blog-server/service"
 
 	"github.com/gin-gonic/gin"
@@ -76,8 +78,8 @@ func (controller *PhotoController) InitRouter(engine *gin.RouterGroup) error {
 	photoGroup.GET("/list", ListPhoto)
 	// --------------------需要认证-------------------------
 	authPhotoGroup := photoGroup.Group("")
-	authPhotoGroup.Use(middleware.JWTAuthMiddleware())
-	authPhotoGroup.POST("/upload", UploadPhoto)
+	authPhotoGroup.Use(middleware.JWTAuthMiddleware(accesstoken.ScopePhotosWrite))
+	authPhotoGroup.POST("/upload", middleware.RequirePermission(rbac.PermPhotosWrite), UploadPhoto)
 
 	return nil
 }
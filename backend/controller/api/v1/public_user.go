@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/middleware"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicUserController 用户公开主页控制器，除关注和取消关注外无需认证
+type PublicUserController struct{}
+
+// @Summary 获取用户公开资料
+// @Description 获取用户的昵称、头像、简介等公开资料，以及已发布文章数、总浏览数、总点赞数、公开照片数、粉丝数和关注数，不返回邮箱等个人信息
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "用户名"
+// @Success 200 {object} internal.Response{data=service.PublicUserProfile}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username} [get]
+func GetPublicUserProfile(c *gin.Context) {
+	var service service.GetPublicUserProfileService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	profile, err := service.Get()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, profile)
+}
+
+// @Summary 获取用户已发布的文章
+// @Description 分页获取用户已发布的文章，按发布时间倒序，不返回文章内容
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "用户名"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大为100" default(10)
+// @Success 200 {object} internal.Response{data=object{articles=[]models.Article,total=int64,page=int,page_size=int}}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/articles [get]
+func ListPublicUserArticles(c *gin.Context) {
+	var service service.ListPublicUserArticlesService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	articles, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"articles":  articles,
+		"total":     total,
+		"page":      service.Page,
+		"page_size": service.PageSize,
+	})
+}
+
+// @Summary 获取用户公开的照片
+// @Description 分页获取用户公开的日常照片，按创建时间倒序
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param username path string true "用户名"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量，最大为100" default(10)
+// @Success 200 {object} internal.Response{data=object{photos=[]models.DailyPhotograph,total=int64}}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Router /users/{username}/photos [get]
+func ListPublicUserPhotos(c *gin.Context) {
+	var service service.ListPublicUserPhotosService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+	if err := c.ShouldBindQuery(&service); err != nil {
+		internal.APIResponse(c, code.ErrParam, nil)
+		return
+	}
+
+	photos, total, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"photos": photos,
+		"total":  total,
+	})
+}
+
+// InitRouter 初始化用户公开主页路由
+func (controller *PublicUserController) InitRouter(router *gin.RouterGroup) error {
+	logger.Logger.Info("init public user controller")
+
+	usersGroup := router.Group("/users")
+	usersGroup.GET("/:username", GetPublicUserProfile)
+	usersGroup.GET("/:username/articles", ListPublicUserArticles)
+	usersGroup.GET("/:username/photos", ListPublicUserPhotos)
+	usersGroup.GET("/:username/followers", ListFollowers)
+	usersGroup.GET("/:username/following", ListFollowing)
+
+	authUsersGroup := usersGroup.Group("")
+	authUsersGroup.Use(middleware.JWTAuthMiddleware())
+	authUsersGroup.GET("/:username/follow", GetFollowStatus)
+	authUsersGroup.POST("/:username/follow", FollowUser)
+	authUsersGroup.DELETE("/:username/follow", UnfollowUser)
+
+	return nil
+}
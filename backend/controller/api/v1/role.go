@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/middleware"
+	"blog-server/internal/rbac"
+	"blog-server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleController 角色管理控制器
+type RoleController struct{}
+
+// @Summary 获取角色列表
+// @Description 获取所有角色及其权限，需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.Role}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /roles [get]
+func ListRoles(c *gin.Context) {
+	var service service.ListRolesService
+	roles, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, roles)
+}
+
+// @Summary 获取权限列表
+// @Description 获取所有可以分配给角色的权限，需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]rbac.Permission}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Router /roles/permissions [get]
+func ListPermissions(c *gin.Context) {
+	internal.APIResponse(c, nil, rbac.Permissions)
+}
+
+// @Summary 创建角色
+// @Description 创建自定义角色，角色名称只能包含小写字母、数字、下划线和短横线，需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.CreateRoleService true "角色信息"
+// @Success 200 {object} internal.Response{data=models.Role}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20903 {object} internal.Response{data=string}
+// @Failure 20906 {object} internal.Response{data=string}
+// @Router /roles [post]
+func CreateRole(c *gin.Context) {
+	var service service.CreateRoleService
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	role, err := service.Create()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, role)
+}
+
+// @Summary 更新角色
+// @Description 更新角色的说明和权限，permissions为空时只更新说明。管理员角色始终拥有全部权限，不能修改。需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "角色名称"
+// @Param data body service.UpdateRoleService true "角色信息"
+// @Success 200 {object} internal.Response{data=models.Role}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20902 {object} internal.Response{data=string}
+// @Failure 20904 {object} internal.Response{data=string}
+// @Failure 20906 {object} internal.Response{data=string}
+// @Router /roles/{name} [put]
+func UpdateRole(c *gin.Context) {
+	var service service.UpdateRoleService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	if err := c.ShouldBindJSON(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	role, err := service.Update()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, role)
+}
+
+// @Summary 删除角色
+// @Description 删除自定义角色，内置角色和仍有用户使用的角色不能删除，需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "角色名称"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20902 {object} internal.Response{data=string}
+// @Failure 20904 {object} internal.Response{data=string}
+// @Failure 20905 {object} internal.Response{data=string}
+// @Router /roles/{name} [delete]
+func DeleteRole(c *gin.Context) {
+	var service service.DeleteRoleService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	if err := service.Delete(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 修改用户角色
+// @Description 将用户的角色修改为指定角色，用户已签发的令牌会被吊销，重新登录后新角色生效。不能修改自己的角色，操作会记录审计日志。需要roles:manage权限
+// @Tags role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name path string true "角色名称"
+// @Param user_id path string true "用户ID"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20202 {object} internal.Response{data=string}
+// @Failure 20901 {object} internal.Response{data=string}
+// @Failure 20902 {object} internal.Response{data=string}
+// @Failure 20907 {object} internal.Response{data=string}
+// @Failure 20908 {object} internal.Response{data=string}
+// @Router /roles/{name}/users/{user_id} [put]
+func AssignRole(c *gin.Context) {
+	var service service.AssignRoleService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	operator, err := adminOperator(c)
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	service.Operator = operator
+
+	if err := service.Assign(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// InitRouter 初始化角色管理路由，所有接口都需要roles:manage权限
+func (controller *RoleController) InitRouter(router *gin.RouterGroup) error {
+	logger.Logger.Info("init role controller")
+
+	roleGroup := router.Group("/roles")
+	roleGroup.Use(middleware.JWTAuthMiddleware(), middleware.RequirePermission(rbac.PermRolesManage))
+	roleGroup.GET("", ListRoles)
+	roleGroup.GET("/permissions", ListPermissions)
+	roleGroup.POST("", CreateRole)
+	roleGroup.PUT("/:name", UpdateRole)
+	roleGroup.DELETE("/:name", DeleteRole)
+	roleGroup.PUT("/:name/users/:user_id", AssignRole)
+
+	return nil
+}
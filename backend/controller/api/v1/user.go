@@ -4,18 +4,22 @@ import (
 	"blog-server/internal"
 	"blog-server/internal/code"
 	"blog-server/internal/middleware"
+	"blog-server/internal/tokenstore"
 	"blog-server/service"
 	"blog-server/internal/utils"
+	"errors"
+	"strconv"
 
-	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserController struct {
 }
 
 // @Summary 用户登录
-// @Description 用户登录
+// @Description 用户登录。开启两步验证的用户返回two_factor_required和challenge_token，需要再调用/user/login/twoFactor
+// @Description 失败次数过多时返回retry_after（秒）和需要完成的工作量证明challenge，完成后通过pow_challenge和pow_nonce提交
 // @Tags user
 // @Accept multipart/form-data
 // @Produce json
@@ -25,21 +29,45 @@ type UserController struct {
 // @Failure 20114 {object} internal.Response{data=string}
 // @Failure 20115 {object} internal.Response{data=string}
 // @Failure 20116 {object} internal.Response{data=string}
+// @Failure 20263 {object} internal.Response{data=string}
+// @Failure 20264 {object} internal.Response{data=string}
+// @Failure 20265 {object} internal.Response{data=string}
 // @Router /user/login [post]
 func Login(c *gin.Context) {
+	var throttled *service.LoginThrottleError
 	var service service.LoginUserService
 	if err := c.ShouldBind(&service); err == nil {
-		user, accessToken, refreshToken, err := service.Login()
-		if err != nil {
+		service.UserAgent = c.GetHeader("User-Agent")
+		service.IP = c.ClientIP()
+		result, err := service.Login()
+		if errors.As(err, &throttled) {
+			// 失败次数过多，返回还需要等待的秒数或需要完成的工作量证明
+			if throttled.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+			internal.APIResponse(c, throttled.Errno, gin.H{
+				"success":     false,
+				"retry_after": throttled.RetryAfterSeconds(),
+				"challenge":   throttled.Challenge,
+			})
+		} else if err != nil {
 			internal.APIResponse(c, err, gin.H{
 				"success": false,
 			})
+		} else if result.ChallengeToken != "" {
+			// 开启了两步验证，需要调用/user/login/twoFactor完成登录
+			internal.APIResponse(c, nil, gin.H{
+				"success":             true,
+				"two_factor_required": true,
+				"challenge_token":     result.ChallengeToken,
+				"methods":             result.Methods,
+			})
 		} else {
 			internal.APIResponse(c, nil, gin.H{
 				"success": true,
-				"user":    user,
-				"access_token":  accessToken,
-				"refresh_token": refreshToken,
+				"user":    result.User,
+				"access_token":  result.AccessToken,
+				"refresh_token": result.RefreshToken,
 			})
 		}
 	} else {
@@ -48,7 +76,7 @@ func Login(c *gin.Context) {
 }
 
 // @Summary 用户注册
-// @Description 用户注册
+// @Description 用户注册。邀请注册时必须填写邀请码invite_code，开放注册时也可以填写邀请码，使用邀请码注册的用户获得邀请码的角色
 // @Tags user
 // @Accept multipart/form-data
 // @Produce json
@@ -56,6 +84,9 @@ func Login(c *gin.Context) {
 // @Failure 20002 {object} internal.Response{data=string}
 // @Failure 20105 {object} internal.Response{data=string}
 // @Failure 20106 {object} internal.Response{data=string}
+// @Failure 20258 {object} internal.Response{data=string}
+// @Failure 20259 {object} internal.Response{data=string}
+// @Failure 20260 {object} internal.Response{data=string}
 // @Router /user/register [post]
 func Register(c *gin.Context) {
 	var service service.RegisterUserService
@@ -163,7 +194,9 @@ func RefreshToken(c *gin.Context) {
 	// 传入Refresh_Token
 	var service service.RefreshTokenService
 	if err := c.ShouldBind(&service); err == nil {
-		user, accessToken, err := service.RefreshToken()
+		service.IP = c.ClientIP()
+		service.UserAgent = c.GetHeader("User-Agent")
+		user, accessToken, refreshToken, err := service.RefreshToken()
 		if err != nil {
 			internal.APIResponse(c, err, gin.H{
 				"success": false,
@@ -173,6 +206,7 @@ func RefreshToken(c *gin.Context) {
 				"success": true,
 				"user":    user,
 				"access_token":  accessToken,
+				"refresh_token": refreshToken,
 			})
 		}
 	} else {
@@ -279,80 +313,46 @@ func UpdateUserProfile(c *gin.Context) {
 }
 
 // @Summary 上传头像
-// @Description 上传当前登录用户的头像
+// @Description 上传当前登录用户的头像，支持multipart上传文件或Base64编码的图片，格式为JPEG、PNG、GIF或WebP，大小不超过5MB。头像会被裁剪为正方形，并生成64、128、256像素三种尺寸
 // @Tags user
-// @Accept multipart/form-data
+// @Accept multipart/form-data,json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param avatar formData file true "头像文件"
-// @Success 200 {object} internal.Response{data=string}
-// @Failure 20002 {object} internal.Response{data=string}
-// @Failure 20101 {object} internal.Response{data=string}
+// @Param avatar formData file false "头像文件"
+// @Param avatar_base64 formData string false "Base64编码的头像"
+// @Success 200 {object} internal.Response{data=map[string]interface{}}
+// @Failure 10002 {object} internal.Response{data=string}
+// @Failure 20253 {object} internal.Response{data=string}
+// @Failure 20254 {object} internal.Response{data=string}
+// @Failure 20255 {object} internal.Response{data=string}
 // @Router /user/avatar [post]
 func UploadAvatar(c *gin.Context) {
+	var service service.UploadAvatarService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
 	// 从JWT中获取用户ID并转换为UUID
 	uid, err := utils.GetUserIDFromContext(c)
 	if err != nil {
 		internal.APIResponse(c, code.ErrUserNotFound, nil)
 		return
 	}
-	
-	// 获取上传的文件
-	file, header, err := c.Request.FormFile("avatar")
-	if err != nil {
-		internal.APIResponse(c, code.ErrBind, nil)
-		return
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			// 记录关闭文件时的错误，但不影响主要流程
-			fmt.Printf("关闭文件时出错: %v\n", err)
-		}
-	}()
-	
-	// 检查文件大小（限制为5MB）
-	if header.Size > 5*1024*1024 {
-		internal.APIResponse(c, code.ErrBind, nil)
-		return
-	}
-	
-	// 检查文件类型
-	fileType := header.Header.Get("Content-Type")
-	if fileType != "image/jpeg" && fileType != "image/png" && fileType != "image/gif" {
-		internal.APIResponse(c, code.ErrBind, nil)
-		return
-	}
-	
-	// 生成文件名
-	fileName := fmt.Sprintf("avatar_%s_%s", uid.String(), header.Filename)
-	
-	// 保存文件到本地
-	avatarPath := fmt.Sprintf("uploads/avatars/%s", fileName)
-	if err = c.SaveUploadedFile(header, avatarPath); err != nil {
-		internal.APIResponse(c, err, nil)
-		return
-	}
-	
-	// 生成访问URL
-	avatarURL := fmt.Sprintf("/uploads/avatars/%s", fileName)
-	
-	// 创建服务实例
-	service := service.UploadAvatarService{
-		ID: uid,
-	}
-	
+	service.ID = uid
+
 	// 调用服务方法
-	err = service.UploadAvatar()
+	variants, err := service.UploadAvatar()
 	if err != nil {
 		internal.APIResponse(c, err, nil)
 		return
 	}
-	
+
 	// 返回成功响应
 	internal.APIResponse(c, nil, gin.H{
-		"success": true,
-		"message": "头像上传成功",
-		"avatar_url": avatarURL,
+		"success":         true,
+		"message":         "头像上传成功",
+		"avatar_url":      variants["256"],
+		"avatar_variants": variants,
 	})
 }
 
@@ -395,7 +395,344 @@ func ChangePassword(c *gin.Context) {
 	// 返回成功响应
 	internal.APIResponse(c, nil, gin.H{
 		"success": true,
-		"message": "密码修改成功",
+		"message": "密码修改成功，请重新登录",
+	})
+}
+
+// @Summary 退出登录
+// @Description 吊销当前的访问令牌和所在的会话，会话的刷新令牌随之失效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=string}
+// @Router /user/logout [post]
+func Logout(c *gin.Context) {
+	var service service.LogoutService
+	claims, ok := c.Get("claims")
+	if !ok {
+		internal.APIResponse(c, code.ErrTokenInvalid, nil)
+		return
+	}
+	service.Claims = claims.(jwt.MapClaims)
+
+	if err := service.Logout(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 退出所有设备
+// @Description 吊销当前用户在所有设备上的访问令牌和刷新令牌
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=string}
+// @Router /user/logoutAll [post]
+func LogoutAll(c *gin.Context) {
+	var service service.LogoutAllService
+	service.UserID = c.GetString("userID")
+
+	if err := service.LogoutAll(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 获取登录会话
+// @Description 获取当前用户在各设备上有效的登录会话，current表示当前请求所在的会话
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=[]models.UserSession}
+// @Router /user/sessions [get]
+func ListSessions(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.ListSessionsService{
+		UserID:           uid,
+		CurrentSessionID: currentSessionID(c),
+	}
+	sessions, err := service.List()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, sessions)
+}
+
+// @Summary 吊销登录会话
+// @Description 吊销当前用户的一个登录会话，该设备需要重新登录
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "会话ID"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20224 {object} internal.Response{data=string}
+// @Router /user/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	var service service.RevokeSessionService
+	if err := c.ShouldBindUri(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Revoke(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// currentSessionID 获取当前访问令牌所在的会话ID
+func currentSessionID(c *gin.Context) string {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return ""
+	}
+	sessionID, _ := claims.(jwt.MapClaims)[tokenstore.ClaimSession].(string)
+	return sessionID
+}
+
+// @Summary 忘记密码
+// @Description 发送重置密码邮件，邮件中的链接30分钟内有效且只能使用一次。每个邮箱每小时最多3次，每个IP每小时最多10次。
+// @Description 邮箱未注册时同样返回成功
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param data body service.ForgotPasswordService true "邮箱"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20213 {object} internal.Response{data=string}
+// @Failure 20221 {object} internal.Response{data=string}
+// @Failure 20223 {object} internal.Response{data=string}
+// @Router /user/forgotPassword [post]
+func ForgotPassword(c *gin.Context) {
+	var service service.ForgotPasswordService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.IP = c.ClientIP()
+
+	if err := service.ForgotPassword(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+		"message": "如果该邮箱已注册，重置密码邮件已发送",
+	})
+}
+
+// @Summary 重置密码
+// @Description 使用重置密码邮件中的令牌设置新密码，成功后该用户所有已登录的会话都需要重新登录
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param data body service.ResetPasswordService true "令牌和新密码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20005 {object} internal.Response{data=string}
+// @Failure 20222 {object} internal.Response{data=string}
+// @Router /user/resetPassword [post]
+func ResetPassword(c *gin.Context) {
+	var service service.ResetPasswordService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+
+	if err := service.ResetPassword(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+		"message": "密码重置成功，请重新登录",
+	})
+}
+
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的challenge_token和验证器应用的验证码或恢复码完成登录。挑战令牌5分钟内有效，验证码输错5次后需要重新登录
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param data body service.TwoFactorLoginService true "挑战令牌和验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20227 {object} internal.Response{data=string}
+// @Failure 20228 {object} internal.Response{data=string}
+// @Router /user/login/twoFactor [post]
+func TwoFactorLogin(c *gin.Context) {
+	var service service.TwoFactorLoginService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	service.UserAgent = c.GetHeader("User-Agent")
+	service.IP = c.ClientIP()
+
+	user, accessToken, refreshToken, err := service.Login()
+	if err != nil {
+		internal.APIResponse(c, err, gin.H{
+			"success": false,
+		})
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":       true,
+		"user":          user,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// @Summary 开启两步验证
+// @Description 生成TOTP密钥，返回otpauth URI和二维码（PNG格式的Data URL）。密钥需要在10分钟内用验证码确认后才会生效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} internal.Response{data=service.TwoFactorEnrollment}
+// @Failure 20225 {object} internal.Response{data=string}
+// @Router /user/twoFactor/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+
+	service := service.EnrollTwoFactorService{UserID: uid}
+	enrollment, err := service.Enroll()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, enrollment)
+}
+
+// @Summary 确认开启两步验证
+// @Description 使用验证器应用生成的第一个验证码确认密钥，返回一次性的恢复码，恢复码只展示这一次
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.ConfirmTwoFactorService true "验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20227 {object} internal.Response{data=string}
+// @Failure 20229 {object} internal.Response{data=string}
+// @Router /user/twoFactor/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	var service service.ConfirmTwoFactorService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	recoveryCodes, err := service.Confirm()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":        true,
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// @Summary 关闭两步验证
+// @Description 验证当前密码和验证码（或恢复码）后关闭两步验证
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.DisableTwoFactorService true "密码和验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20204 {object} internal.Response{data=string}
+// @Failure 20226 {object} internal.Response{data=string}
+// @Failure 20227 {object} internal.Response{data=string}
+// @Router /user/twoFactor/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var service service.DisableTwoFactorService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	if err := service.Disable(); err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary 重新生成恢复码
+// @Description 验证验证码后重新生成恢复码，之前的恢复码全部失效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body service.RegenerateRecoveryCodesService true "验证码"
+// @Success 200 {object} internal.Response{data=string}
+// @Failure 20226 {object} internal.Response{data=string}
+// @Failure 20227 {object} internal.Response{data=string}
+// @Router /user/twoFactor/recoveryCodes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var service service.RegenerateRecoveryCodesService
+	if err := c.ShouldBind(&service); err != nil {
+		internal.APIResponse(c, code.ErrBind, nil)
+		return
+	}
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		internal.APIResponse(c, code.ErrUserNotFound, nil)
+		return
+	}
+	service.UserID = uid
+
+	recoveryCodes, err := service.Regenerate()
+	if err != nil {
+		internal.APIResponse(c, err, nil)
+		return
+	}
+	internal.APIResponse(c, nil, gin.H{
+		"success":        true,
+		"recovery_codes": recoveryCodes,
 	})
 }
 
@@ -457,12 +794,24 @@ func (controller *UserController) InitRouter(router *gin.RouterGroup) error {
 	userGroup := router.Group("/user")
 	// --------------------无需认证-------------------------
 	userGroup.POST("/login", Login)
+	userGroup.POST("/login/twoFactor", TwoFactorLogin)
+	userGroup.POST("/login/twoFactor/passkey/begin", BeginTwoFactorPasskey)
+	userGroup.POST("/login/twoFactor/passkey/finish", FinishTwoFactorPasskey)
+	userGroup.POST("/login/passkey/begin", BeginPasskeyLogin)
+	userGroup.POST("/login/passkey/finish", FinishPasskeyLogin)
+	userGroup.GET("/oauth/providers", ListOAuthProviders)
+	userGroup.POST("/oauth/:provider/authorize", BeginOAuthLogin)
+	userGroup.POST("/oauth/:provider/callback", FinishOAuthLogin)
 	userGroup.POST("/register", Register)
+	userGroup.GET("/registrationMode", GetRegistrationMode)
 	userGroup.GET("/emailVerificationCode", GetEmailVerificationCode)
 	userGroup.POST("/verifyEmailVerificationCode", VerifyEmailVerificationCode)
 	userGroup.GET("/checkUserName", CheckUserName)
 	userGroup.GET("/checkUserEmail", CheckUserEmail)
 	userGroup.POST("/refreshToken", RefreshToken)
+	userGroup.POST("/forgotPassword", ForgotPassword)
+	userGroup.POST("/resetPassword", ResetPassword)
+	userGroup.POST("/loginAlert/deny", DenyLogin)
 	// --------------------需要认证-------------------------
 	authGroup := userGroup.Group("")
 	authGroup.Use(middleware.JWTAuthMiddleware())
@@ -473,5 +822,35 @@ func (controller *UserController) InitRouter(router *gin.RouterGroup) error {
 	authGroup.POST("/avatar", UploadAvatar)
 	authGroup.PUT("/password", ChangePassword)
 	authGroup.GET("/operation-logs", GetOperationLogs)
+	authGroup.POST("/logout", Logout)
+	authGroup.POST("/logoutAll", LogoutAll)
+	authGroup.GET("/sessions", ListSessions)
+	authGroup.DELETE("/sessions/:id", RevokeSession)
+	authGroup.GET("/loginHistory", ListLoginHistory)
+	authGroup.POST("/twoFactor/enroll", EnrollTwoFactor)
+	authGroup.POST("/twoFactor/confirm", ConfirmTwoFactor)
+	authGroup.POST("/twoFactor/disable", DisableTwoFactor)
+	authGroup.POST("/twoFactor/recoveryCodes", RegenerateRecoveryCodes)
+	authGroup.POST("/passkeys/register/begin", BeginPasskeyRegistration)
+	authGroup.POST("/passkeys/register/finish", FinishPasskeyRegistration)
+	authGroup.GET("/passkeys", ListPasskeys)
+	authGroup.PUT("/passkeys/:id", RenamePasskey)
+	authGroup.DELETE("/passkeys/:id", DeletePasskey)
+	authGroup.GET("/oauth/identities", ListOAuthIdentities)
+	authGroup.DELETE("/oauth/identities/:provider", UnlinkOAuthIdentity)
+	authGroup.POST("/accessTokens", CreateAccessToken)
+	authGroup.GET("/accessTokens", ListAccessTokens)
+	authGroup.DELETE("/accessTokens/:id", RevokeAccessToken)
+	authGroup.POST("/dataExports", RequestDataExport)
+	authGroup.GET("/dataExports", ListDataExports)
+	authGroup.GET("/dataExports/:id/download", DownloadDataExport)
+	authGroup.POST("/accountDeletion", ScheduleAccountDeletion)
+	authGroup.GET("/accountDeletion", GetAccountDeletion)
+	authGroup.DELETE("/accountDeletion", CancelAccountDeletion)
+	authGroup.POST("/emailChange", RequestEmailChange)
+	authGroup.POST("/emailChange/confirm", ConfirmEmailChange)
+	authGroup.POST("/invites", CreateInviteCode)
+	authGroup.GET("/invites", ListInviteCodes)
+	authGroup.DELETE("/invites/:id", RevokeInviteCode)
 	return nil
 }
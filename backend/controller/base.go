@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/jwtkeys"
+	"blog-server/internal/logger"
 
 	"blog-server/internal/version"
 
@@ -19,3 +22,22 @@ func Health(c *gin.Context) {
 func Version(c *gin.Context) {
 	internal.APIResponse(c, nil, version.Get())
 }
+
+// JWKS 公开校验JWT的公钥，包括即将开始签名的新密钥和重叠期内的旧密钥
+// 返回标准的JWK Set格式，方便其他服务校验令牌
+func JWKS(c *gin.Context) {
+	ring := jwtkeys.Default()
+	if ring == nil {
+		internal.APIResponse(c, code.InternalServerError, nil)
+		return
+	}
+	set, err := ring.JWKS()
+	if err != nil {
+		logger.Logger.Errorf("build jwks failed: %v", err)
+		internal.APIResponse(c, code.InternalServerError, nil)
+		return
+	}
+	// 缓存时间短于新密钥提前公开的时间，保证客户端在新密钥开始签名前获取到
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,150 @@
+// Package accesstoken 个人访问令牌的生成、权限范围和校验
+// 令牌只在创建时返回一次，数据库中只保存SHA-256哈希
+package accesstoken
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Prefix 个人访问令牌的前缀，用于和JWT区分，也便于密钥扫描工具识别
+const Prefix = "dzp_"
+
+// displayPrefixLength 列表中展示的令牌前缀长度
+const displayPrefixLength = len(Prefix) + 8
+
+// lastUsedInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+const lastUsedInterval = time.Minute
+
+// 权限范围
+const (
+	ScopeArticlesWrite = "articles:write" // 创建、更新、删除文章
+	ScopePhotosWrite   = "photos:write"   // 上传图片，创建、更新、删除日常照片
+	ScopeCommentsWrite = "comments:write" // 导入、审核、编辑、删除评论
+)
+
+// Scopes 所有可用的权限范围
+var Scopes = []string{ScopeArticlesWrite, ScopePhotosWrite, ScopeCommentsWrite}
+
+// ErrInvalidScope 权限范围为空或不存在
+var ErrInvalidScope = errors.New("accesstoken: invalid scope")
+
+// Generate 生成新令牌，返回令牌、令牌哈希和用于展示的前缀
+func Generate() (token, hash, displayPrefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	token = Prefix + hex.EncodeToString(buf)
+	return token, Hash(token), token[:displayPrefixLength], nil
+}
+
+// Hash 计算令牌的哈希
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAccessToken 判断是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// NormalizeScopes 校验权限范围，去重排序后返回逗号分隔的字符串
+func NormalizeScopes(scopes []string) (string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return "", ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", ErrInvalidScope
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ","), nil
+}
+
+// HasScope 判断授予的权限范围是否包含任意一个要求的权限范围
+func HasScope(granted string, required ...string) bool {
+	for _, scope := range strings.Split(granted, ",") {
+		for _, want := range required {
+			if scope == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate 校验令牌，返回令牌记录和令牌所属的用户
+// 令牌不存在、已吊销、已过期、用户状态异常或需要重置密码时返回对应的错误码
+func Authenticate(ctx context.Context, token, ip string) (*models.PersonalAccessToken, *models.User, error) {
+	db := models.DB.WithContext(ctx)
+
+	var record models.PersonalAccessToken
+	if err := db.Where("token_hash = ?", Hash(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, code.ErrTokenInvalid
+		}
+		logger.Logger.Errorf("get personal access token failed: %v", err)
+		return nil, nil, code.ErrDatabase
+	}
+	if record.RevokedAt != nil {
+		return nil, nil, code.ErrTokenRevoked
+	}
+	if !record.ExpiresAt.After(time.Now()) {
+		return nil, nil, code.ErrTokenExpired
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", record.UserID).First(&user).Error; err != nil {
+		return nil, nil, code.ErrUserNotFound
+	}
+	if user.Status == "suspended" {
+		return nil, nil, code.ErrUserSuspended
+	}
+	if !user.IsActive {
+		return nil, nil, code.ErrUserInactive
+	}
+	if user.PasswordResetRequired {
+		return nil, nil, code.ErrPasswordResetRequired
+	}
+
+	now := time.Now()
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= lastUsedInterval {
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error; err != nil {
+			logger.Logger.Errorf("update personal access token usage failed: %v", err)
+		}
+	}
+	return &record, &user, nil
+}
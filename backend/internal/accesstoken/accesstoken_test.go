@@ -0,0 +1,84 @@
+package accesstoken
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	token, hash, displayPrefix, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !regexp.MustCompile(`^dzp_[0-9a-f]{64}$`).MatchString(token) {
+		t.Errorf("token = %q, want dzp_ followed by 64 hex characters", token)
+	}
+	if !IsAccessToken(token) {
+		t.Errorf("IsAccessToken(%q) = false, want true", token)
+	}
+	if hash != Hash(token) || hash == token {
+		t.Errorf("hash = %q, want Hash(token)", hash)
+	}
+	if displayPrefix != token[:12] {
+		t.Errorf("displayPrefix = %q, want %q", displayPrefix, token[:12])
+	}
+
+	other, _, _, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if other == token {
+		t.Errorf("Generate() returned the same token twice")
+	}
+}
+
+func TestIsAccessToken(t *testing.T) {
+	if IsAccessToken("eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9.e30.sig") {
+		t.Errorf("IsAccessToken() = true for a JWT")
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "单个权限", scopes: []string{"articles:write"}, want: "articles:write"},
+		{name: "去重排序", scopes: []string{"photos:write", " articles:write", "photos:write"}, want: "articles:write,photos:write"},
+		{name: "空列表", scopes: nil, wantErr: true},
+		{name: "未知权限", scopes: []string{"articles:write", "admin"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeScopes(tt.scopes)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidScope) {
+					t.Errorf("NormalizeScopes() error = %v, want ErrInvalidScope", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeScopes() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	granted := "articles:write,photos:write"
+	if !HasScope(granted, ScopePhotosWrite) {
+		t.Errorf("HasScope(%q, photos:write) = false, want true", granted)
+	}
+	if !HasScope(granted, ScopeCommentsWrite, ScopeArticlesWrite) {
+		t.Errorf("HasScope() = false, want true when any scope matches")
+	}
+	if HasScope(granted, ScopeCommentsWrite) {
+		t.Errorf("HasScope(%q, comments:write) = true, want false", granted)
+	}
+	if HasScope(granted) {
+		t.Errorf("HasScope() with no required scopes = true, want false")
+	}
+}
@@ -28,6 +28,9 @@ var (
 	ErrTokenNbfError         = &Errno{Code: 20106, Message: "Token生效时间错误"}
 	ErrRefreshTokenInvalid   = &Errno{Code: 20107, Message: "Refresh token无效"}
 	ErrRefreshTokenExpired   = &Errno{Code: 20108, Message: "Refresh token已过期"}
+	ErrTokenRevoked          = &Errno{Code: 20109, Message: "Token已失效，请重新登录"}
+	ErrRefreshTokenReused    = &Errno{Code: 20110, Message: "Refresh token已被使用，请重新登录"}
+	ErrTokenScope            = &Errno{Code: 20111, Message: "访问令牌没有该接口的权限"}
 
 	// user errors
 	ErrEncrypt                   = &Errno{Code: 20201, Message: "密码加密错误"}
@@ -50,6 +53,51 @@ var (
 	ErrVerificationCodeLength    = &Errno{Code: 20218, Message: "验证码长度不正确"}
 	ErrSendEmailVerificationCode = &Errno{Code: 20219, Message: "发送邮件验证码失败"}
 	ErrEmailExistBefore          = &Errno{Code: 20220, Message: "邮箱已被注册"}
+	ErrPasswordResetTooMany      = &Errno{Code: 20221, Message: "重置密码请求过于频繁，请稍后再试"}
+	ErrPasswordResetTokenInvalid = &Errno{Code: 20222, Message: "重置密码链接无效或已过期"}
+	ErrSendPasswordResetEmail    = &Errno{Code: 20223, Message: "发送重置密码邮件失败"}
+	ErrSessionNotFound           = &Errno{Code: 20224, Message: "会话不存在或已失效"}
+	ErrTwoFactorAlreadyEnabled   = &Errno{Code: 20225, Message: "已开启两步验证"}
+	ErrTwoFactorNotEnabled       = &Errno{Code: 20226, Message: "未开启两步验证"}
+	ErrTwoFactorCodeInvalid      = &Errno{Code: 20227, Message: "两步验证码错误"}
+	ErrTwoFactorChallengeInvalid = &Errno{Code: 20228, Message: "登录验证已过期，请重新登录"}
+	ErrTwoFactorEnrollExpired    = &Errno{Code: 20229, Message: "两步验证设置已过期，请重新开始"}
+	ErrPasskeyNotFound           = &Errno{Code: 20230, Message: "通行密钥不存在"}
+	ErrPasskeyRegisterFailed     = &Errno{Code: 20231, Message: "通行密钥注册失败"}
+	ErrPasskeyLoginFailed        = &Errno{Code: 20232, Message: "通行密钥验证失败"}
+	ErrPasskeySessionExpired     = &Errno{Code: 20233, Message: "通行密钥验证已过期，请重试"}
+	ErrOAuthProviderNotFound     = &Errno{Code: 20234, Message: "不支持该登录方式"}
+	ErrOAuthStateInvalid         = &Errno{Code: 20235, Message: "登录请求无效或已过期，请重新登录"}
+	ErrOAuthExchangeFailed       = &Errno{Code: 20236, Message: "获取第三方账号信息失败"}
+	ErrOAuthEmailUnverified      = &Errno{Code: 20237, Message: "第三方账号没有已验证的邮箱"}
+	ErrOAuthIdentityNotFound     = &Errno{Code: 20238, Message: "第三方账号未绑定"}
+	ErrAccessTokenNotFound       = &Errno{Code: 20239, Message: "访问令牌不存在"}
+	ErrAccessTokenScopeInvalid   = &Errno{Code: 20240, Message: "无效的访问令牌权限范围"}
+	ErrAccessTokenLimit          = &Errno{Code: 20241, Message: "访问令牌数量已达上限"}
+	ErrPasswordResetRequired     = &Errno{Code: 20242, Message: "账号需要重置密码，请通过邮件中的链接或忘记密码重新设置"}
+	ErrDataExportInProgress      = &Errno{Code: 20243, Message: "已有正在进行的数据导出，请等待完成"}
+	ErrDataExportTooMany         = &Errno{Code: 20244, Message: "数据导出过于频繁，请稍后再试"}
+	ErrDataExportNotFound        = &Errno{Code: 20245, Message: "数据导出不存在"}
+	ErrDataExportNotReady        = &Errno{Code: 20246, Message: "数据导出尚未完成或已过期"}
+	ErrAccountDeletionScheduled  = &Errno{Code: 20247, Message: "账号已申请注销"}
+	ErrAccountDeletionNotFound   = &Errno{Code: 20248, Message: "账号没有申请注销"}
+	ErrSendEmail                 = &Errno{Code: 20249, Message: "发送邮件失败"}
+	ErrEmailChangeVerification   = &Errno{Code: 20250, Message: "修改邮箱需要验证新邮箱，请使用修改邮箱功能"}
+	ErrEmailUnchanged            = &Errno{Code: 20251, Message: "新邮箱与当前邮箱相同"}
+	ErrEmailChangeExpired        = &Errno{Code: 20252, Message: "修改邮箱请求已过期，请重新获取验证码"}
+	ErrAvatarInvalid             = &Errno{Code: 20253, Message: "头像必须是JPEG、PNG、GIF或WebP格式的图片"}
+	ErrAvatarTooLarge            = &Errno{Code: 20254, Message: "头像文件过大或尺寸过大"}
+	ErrAvatarUpload              = &Errno{Code: 20255, Message: "上传头像失败"}
+	ErrFollowSelf                = &Errno{Code: 20256, Message: "不能关注自己"}
+	ErrFeedCursorInvalid         = &Errno{Code: 20257, Message: "分页游标无效"}
+	ErrRegistrationClosed        = &Errno{Code: 20258, Message: "暂不开放注册"}
+	ErrInviteCodeRequired        = &Errno{Code: 20259, Message: "注册需要邀请码"}
+	ErrInviteCodeInvalid         = &Errno{Code: 20260, Message: "邀请码无效、已过期或已用完"}
+	ErrInviteCodeNotFound        = &Errno{Code: 20261, Message: "邀请码不存在"}
+	ErrLoginAlertInvalid         = &Errno{Code: 20262, Message: "链接无效或已过期"}
+	ErrLoginThrottled            = &Errno{Code: 20263, Message: "登录失败次数过多，请稍后再试"}
+	ErrLoginChallengeRequired    = &Errno{Code: 20264, Message: "登录失败次数过多，需要完成人机验证"}
+	ErrLoginChallengeInvalid     = &Errno{Code: 20265, Message: "人机验证无效或已过期"}
 
 	// photo errors
 	ErrPhotoUpload = &Errno{Code: 20301, Message: "图片上传失败"}
@@ -75,6 +123,15 @@ var (
 	// article comment errors
 	ErrArticleCommentCreateFailed = &Errno{Code: 20401, Message: "评论创建失败"}
 	ErrArticleCommentListFailed   = &Errno{Code: 20402, Message: "评论列表获取失败"}
+	ErrArticleCommentNotFound     = &Errno{Code: 20403, Message: "评论不存在"}
+	ErrArticleCommentPermission   = &Errno{Code: 20404, Message: "无权限操作此评论"}
+	ErrArticleCommentUpdateFailed = &Errno{Code: 20405, Message: "评论更新失败"}
+	ErrArticleCommentWatchFailed  = &Errno{Code: 20406, Message: "订阅评论失败"}
+	ErrArticleCommentEditExpired  = &Errno{Code: 20407, Message: "评论已超过可编辑时间"}
+	ErrArticleCommentDeleted      = &Errno{Code: 20408, Message: "评论已被删除"}
+	ErrArticleCommentDeleteFailed = &Errno{Code: 20409, Message: "评论删除失败"}
+	ErrArticleCommentImportParse  = &Errno{Code: 20410, Message: "评论导入文件解析失败"}
+	ErrArticleCommentImportFailed = &Errno{Code: 20411, Message: "评论导入失败"}
 
 	// article errors
 	ErrArticleTitleEmpty        = &Errno{Code: 20501, Message: "文章标题不能为空"}
@@ -91,6 +148,22 @@ var (
 	ErrArticleCoverImageInvalid = &Errno{Code: 20512, Message: "封面图片必须是有效的URL或Base64编码的图片"}
 	ErrInvalidArticleID         = &Errno{Code: 20513, Message: "无效的文章ID"}
 
+	// notification errors
+	ErrNotificationNotFound = &Errno{Code: 20801, Message: "通知不存在"}
+	ErrNotificationList     = &Errno{Code: 20802, Message: "获取通知列表失败"}
+	ErrNotificationUpdate   = &Errno{Code: 20803, Message: "更新通知状态失败"}
+	ErrNotificationStream   = &Errno{Code: 20804, Message: "订阅通知失败"}
+
+	// rbac errors
+	ErrPermissionDenied      = &Errno{Code: 20901, Message: "没有权限执行该操作"}
+	ErrRoleNotFound          = &Errno{Code: 20902, Message: "角色不存在"}
+	ErrRoleExist             = &Errno{Code: 20903, Message: "角色已存在"}
+	ErrRoleProtected         = &Errno{Code: 20904, Message: "内置角色不能删除，管理员角色的权限不能修改"}
+	ErrRoleInUse             = &Errno{Code: 20905, Message: "仍有用户使用该角色，不能删除"}
+	ErrRolePermissionInvalid = &Errno{Code: 20906, Message: "权限不存在"}
+	ErrRoleLastAdmin         = &Errno{Code: 20907, Message: "至少需要保留一个管理员"}
+	ErrAdminSelfOperation    = &Errno{Code: 20908, Message: "不能对自己的账号执行该操作"}
+
 )
 
 // Errno ...
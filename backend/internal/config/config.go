@@ -65,11 +65,12 @@ type AppConfig struct {
 	RunMode                string `json:"run_mode" yaml:"run_mode" mapstructure:"run_mode"`
 	Addr                   string `json:"addr" yaml:"addr" mapstructure:"addr"`
 	Port                   string `json:"port" yaml:"port" mapstructure:"port"`
-	JwtExpirationTime      int    `json:"jwt_expiration_time" yaml:"jwt_expiration_time" mapstructure:"jwt_expiration_time"`           // JWT过期时间（小时）- 用于access token
+	JwtExpirationTime      int    `json:"jwt_expiration_time" yaml:"jwt_expiration_time" mapstructure:"jwt_expiration_time"`                // JWT过期时间（小时）- 用于access token
 	RefreshTokenExpiration int    `json:"refresh_token_expiration" yaml:"refresh_token_expiration" mapstructure:"refresh_token_expiration"` // Refresh token过期时间（天）
-	RsaPrivateKeyPath      string `json:"rsa_private_key_path" yaml:"rsa_private_key_path" mapstructure:"rsa_private_key_path"`
-	RsaPublicKeyPath       string `json:"rsa_public_key_path" yaml:"rsa_public_key_path" mapstructure:"rsa_public_key_path"`
+	RsaPrivateKeyPath      string `json:"rsa_private_key_path" yaml:"rsa_private_key_path" mapstructure:"rsa_private_key_path"`             // 旧版本的RSA私钥，JWT密钥目录中没有密钥时导入
+	RsaPublicKeyPath       string `json:"rsa_public_key_path" yaml:"rsa_public_key_path" mapstructure:"rsa_public_key_path"`                // 不再使用，公钥通过/.well-known/jwks.json公开
 	LogOutputDir           string `json:"log_output_dir" yaml:"log_output_dir" mapstructure:"log_output_dir"`
+	SiteURL                string `json:"site_url" yaml:"site_url" mapstructure:"site_url"` // 前端站点地址，用于生成邮件中的链接
 }
 
 type MinioConfig struct {
@@ -156,6 +157,80 @@ type EmailConfig struct {
 	EmailTemplate string `json:"email_template" yaml:"email_template" mapstructure:"email_template"`
 }
 
+// CommentConfig 评论配置
+type CommentConfig struct {
+	EditWindow int `json:"edit_window" yaml:"edit_window" mapstructure:"edit_window"` // 评论发布后允许编辑的时间（分钟），0表示使用默认值15分钟
+}
+
+// AccountConfig 账号配置
+type AccountConfig struct {
+	DeletionGraceDays    int    `json:"deletion_grace_days" yaml:"deletion_grace_days" mapstructure:"deletion_grace_days"`             // 申请注销后的冷静期（天），期间可以撤销，0表示使用默认值14天
+	DataExportExpireDays int    `json:"data_export_expire_days" yaml:"data_export_expire_days" mapstructure:"data_export_expire_days"` // 导出的个人数据保留时间（天），0表示使用默认值7天
+	RegistrationMode     string `json:"registration_mode" yaml:"registration_mode" mapstructure:"registration_mode"`                   // 注册方式：open开放注册，invite_only需要邀请码，closed关闭注册，为空时开放注册
+	GeoIPDatabase        string `json:"geoip_database" yaml:"geoip_database" mapstructure:"geoip_database"`                            // 离线IP数据库文件（CSV），用于登录记录的地理位置，为空时不查询
+	LoginHistoryDays     int    `json:"login_history_days" yaml:"login_history_days" mapstructure:"login_history_days"`                // 登录记录保留时间（天），0表示使用默认值180天
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	TOTPIssuer      string              `json:"totp_issuer" yaml:"totp_issuer" mapstructure:"totp_issuer"`                // 两步验证中显示的签发者名称，为空时使用应用名称
+	WebAuthnRPID    string              `json:"webauthn_rp_id" yaml:"webauthn_rp_id" mapstructure:"webauthn_rp_id"`       // 通行密钥的依赖方ID，为空时使用site_url的域名
+	WebAuthnRPName  string              `json:"webauthn_rp_name" yaml:"webauthn_rp_name" mapstructure:"webauthn_rp_name"` // 通行密钥的依赖方名称，为空时使用totp_issuer
+	WebAuthnOrigins []string            `json:"webauthn_origins" yaml:"webauthn_origins" mapstructure:"webauthn_origins"` // 允许的来源，为空时使用site_url
+	OAuth           OAuthConfig         `json:"oauth" yaml:"oauth" mapstructure:"oauth"`                                  // 第三方登录
+	LoginThrottle   LoginThrottleConfig `json:"login_throttle" yaml:"login_throttle" mapstructure:"login_throttle"`       // 登录限流
+	PasswordHash    PasswordHashConfig  `json:"password_hash" yaml:"password_hash" mapstructure:"password_hash"`          // 密码哈希
+	JWTKeys         JWTKeysConfig       `json:"jwt_keys" yaml:"jwt_keys" mapstructure:"jwt_keys"`                         // JWT签名密钥
+}
+
+// LoginThrottleConfig 登录限流配置，按账号和IP分别统计时间窗口内失败的登录，所有值为0时使用默认值
+type LoginThrottleConfig struct {
+	WindowMinutes         int `json:"window_minutes" yaml:"window_minutes" mapstructure:"window_minutes"`                            // 统计失败次数的滑动窗口（分钟），默认15分钟
+	AccountFreeFailures   int `json:"account_free_failures" yaml:"account_free_failures" mapstructure:"account_free_failures"`       // 同一账号窗口内允许失败的次数，超过后需要等待，默认5次
+	IPFreeFailures        int `json:"ip_free_failures" yaml:"ip_free_failures" mapstructure:"ip_free_failures"`                      // 同一IP窗口内允许失败的次数，超过后需要等待，默认20次
+	AccountChallengeAfter int `json:"account_challenge_after" yaml:"account_challenge_after" mapstructure:"account_challenge_after"` // 同一账号窗口内失败多少次后需要完成工作量证明，默认3次
+	IPChallengeAfter      int `json:"ip_challenge_after" yaml:"ip_challenge_after" mapstructure:"ip_challenge_after"`                // 同一IP窗口内失败多少次后需要完成工作量证明，默认10次
+	BaseDelaySeconds      int `json:"base_delay_seconds" yaml:"base_delay_seconds" mapstructure:"base_delay_seconds"`                // 第一次需要等待的时间（秒），之后每次失败翻倍，默认30秒
+	MaxDelayMinutes       int `json:"max_delay_minutes" yaml:"max_delay_minutes" mapstructure:"max_delay_minutes"`                   // 最长等待时间（分钟），默认15分钟
+	ChallengeDifficulty   int `json:"challenge_difficulty" yaml:"challenge_difficulty" mapstructure:"challenge_difficulty"`          // 工作量证明的难度（哈希前导零的位数），默认18
+}
+
+// PasswordHashConfig 密码哈希配置，新设置的密码使用algorithm指定的算法
+// 用户登录成功时，使用其他算法或参数与当前配置不同的哈希会重新计算
+type PasswordHashConfig struct {
+	Algorithm         string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`                            // 哈希算法：argon2id, bcrypt，默认argon2id
+	Argon2Memory      uint32 `json:"argon2_memory_kib" yaml:"argon2_memory_kib" mapstructure:"argon2_memory_kib"`    // argon2id使用的内存（KiB），默认19456
+	Argon2Iterations  uint32 `json:"argon2_iterations" yaml:"argon2_iterations" mapstructure:"argon2_iterations"`    // argon2id的迭代次数，默认2
+	Argon2Parallelism uint8  `json:"argon2_parallelism" yaml:"argon2_parallelism" mapstructure:"argon2_parallelism"` // argon2id的并行度，默认1
+	BcryptCost        int    `json:"bcrypt_cost" yaml:"bcrypt_cost" mapstructure:"bcrypt_cost"`                      // bcrypt的cost，默认10
+}
+
+// JWTKeysConfig JWT签名密钥配置，密钥保存在dir目录中，多个实例需要共享同一个目录
+type JWTKeysConfig struct {
+	Dir                 string `json:"dir" yaml:"dir" mapstructure:"dir"`                                                       // 密钥目录，目录中没有密钥时导入app.rsa_private_key_path的旧密钥
+	Algorithm           string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`                                     // 新密钥的签名算法：RS256, ES256, EdDSA，默认RS256
+	RotationDays        int    `json:"rotation_days" yaml:"rotation_days" mapstructure:"rotation_days"`                         // 自动轮换的间隔（天），为0时不自动轮换
+	OverlapHours        int    `json:"overlap_hours" yaml:"overlap_hours" mapstructure:"overlap_hours"`                         // 旧密钥被替换后仍可以校验令牌的时间（小时），不短于刷新令牌的有效期
+	PublishAheadMinutes int    `json:"publish_ahead_minutes" yaml:"publish_ahead_minutes" mapstructure:"publish_ahead_minutes"` // 新密钥提前公开的时间（分钟），默认60分钟
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	GitHub OAuthProviderConfig `json:"github" yaml:"github" mapstructure:"github"` // GitHub登录
+	OIDC   OAuthProviderConfig `json:"oidc" yaml:"oidc" mapstructure:"oidc"`       // 通用OpenID Connect登录
+}
+
+// OAuthProviderConfig 第三方登录提供方配置
+type OAuthProviderConfig struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled" mapstructure:"enabled"`                   // 是否启用
+	ClientID     string   `json:"client_id" yaml:"client_id" mapstructure:"client_id"`             // 客户端ID
+	ClientSecret string   `json:"client_secret" yaml:"client_secret" mapstructure:"client_secret"` // 客户端密钥
+	RedirectURL  string   `json:"redirect_url" yaml:"redirect_url" mapstructure:"redirect_url"`    // 回调地址，为空时使用site_url + /oauth/callback/<提供方>
+	Scopes       []string `json:"scopes" yaml:"scopes" mapstructure:"scopes"`                      // 申请的权限，为空时使用默认值
+	DisplayName  string   `json:"display_name" yaml:"display_name" mapstructure:"display_name"`    // 登录按钮上显示的名称
+	Issuer       string   `json:"issuer" yaml:"issuer" mapstructure:"issuer"`                      // OIDC签发者地址，只用于oidc
+}
+
 // Config global config
 // include common and biz config
 type Config struct {
@@ -171,4 +246,10 @@ type Config struct {
 	Redis RedisConfig `json:"redis" yaml:"redis" mapstructure:"redis"`
 	// email
 	Email EmailConfig `json:"email" yaml:"email" mapstructure:"email"`
+	// comment
+	Comment CommentConfig `json:"comment" yaml:"comment" mapstructure:"comment"`
+	// auth
+	Auth AuthConfig `json:"auth" yaml:"auth" mapstructure:"auth"`
+	// account
+	Account AccountConfig `json:"account" yaml:"account" mapstructure:"account"`
 }
@@ -4,3 +4,42 @@ type EmailVerificationMessage struct {
 	Email            string `json:"email"`
 	VerificationCode string `json:"verification_code"`
 }
+
+type PasswordResetMessage struct {
+	Email         string `json:"email"`
+	UserName      string `json:"user_name"`
+	ResetURL      string `json:"reset_url"`
+	ExpireMinutes int    `json:"expire_minutes"`
+}
+
+type DataExportMessage struct {
+	Email       string `json:"email"`
+	UserName    string `json:"user_name"`
+	DownloadURL string `json:"download_url"`
+	ExpireDays  int    `json:"expire_days"`
+}
+
+type AccountDeletionMessage struct {
+	Email       string `json:"email"`
+	UserName    string `json:"user_name"`
+	ScheduledAt string `json:"scheduled_at"`
+	CancelURL   string `json:"cancel_url"`
+}
+
+type EmailChangedMessage struct {
+	Email     string `json:"email"`
+	UserName  string `json:"user_name"`
+	NewEmail  string `json:"new_email"`
+	ChangedAt string `json:"changed_at"`
+}
+
+type LoginAlertMessage struct {
+	Email      string `json:"email"`
+	UserName   string `json:"user_name"`
+	DeviceName string `json:"device_name"`
+	IP         string `json:"ip"`
+	Location   string `json:"location"`
+	LoginAt    string `json:"login_at"`
+	DenyURL    string `json:"deny_url"`
+	ExpireDays int    `json:"expire_days"`
+}
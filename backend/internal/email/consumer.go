@@ -14,7 +14,7 @@ import (
 )
 
 func InitEmailConsumer() error {
-	consumer, err := mq.NewKafkaConsumer("email-group", []string{"email_verification"})
+	consumer, err := mq.NewKafkaConsumer("email-group", []string{"email_verification", "password_reset", "data_export", "account_deletion", "email_changed", "login_alert"})
 	if err != nil {
 		return fmt.Errorf("[%v]init consumer failed, err: %v", utils.GetFullCallerInfo(0), err)
 	}
@@ -31,6 +31,67 @@ func InitEmailConsumer() error {
 		}
 		return nil
 	})
+	consumer.RegisterHandler("password_reset", func(message *sarama.ConsumerMessage) error {
+		var resetMessage dto.PasswordResetMessage
+		if err := json.Unmarshal(message.Value, &resetMessage); err != nil {
+			return fmt.Errorf("[%v]unmarshal message failed, err: %v", utils.GetFullCallerInfo(0), err)
+		}
+		// 发送邮件
+		if err := SendPasswordReset(resetMessage.Email, resetMessage.UserName, resetMessage.ResetURL, resetMessage.ExpireMinutes); err != nil {
+			logger.Logger.Errorf("send password reset email failed: %v", err)
+			return code.ErrSendPasswordResetEmail
+		}
+		return nil
+	})
+	consumer.RegisterHandler("data_export", func(message *sarama.ConsumerMessage) error {
+		var exportMessage dto.DataExportMessage
+		if err := json.Unmarshal(message.Value, &exportMessage); err != nil {
+			return fmt.Errorf("[%v]unmarshal message failed, err: %v", utils.GetFullCallerInfo(0), err)
+		}
+		// 发送邮件
+		if err := SendDataExportReady(exportMessage.Email, exportMessage.UserName, exportMessage.DownloadURL, exportMessage.ExpireDays); err != nil {
+			logger.Logger.Errorf("send data export email failed: %v", err)
+			return code.ErrSendEmail
+		}
+		return nil
+	})
+	consumer.RegisterHandler("account_deletion", func(message *sarama.ConsumerMessage) error {
+		var deletionMessage dto.AccountDeletionMessage
+		if err := json.Unmarshal(message.Value, &deletionMessage); err != nil {
+			return fmt.Errorf("[%v]unmarshal message failed, err: %v", utils.GetFullCallerInfo(0), err)
+		}
+		// 发送邮件
+		if err := SendAccountDeletionScheduled(deletionMessage.Email, deletionMessage.UserName, deletionMessage.ScheduledAt, deletionMessage.CancelURL); err != nil {
+			logger.Logger.Errorf("send account deletion email failed: %v", err)
+			return code.ErrSendEmail
+		}
+		return nil
+	})
+	consumer.RegisterHandler("email_changed", func(message *sarama.ConsumerMessage) error {
+		var changedMessage dto.EmailChangedMessage
+		if err := json.Unmarshal(message.Value, &changedMessage); err != nil {
+			return fmt.Errorf("[%v]unmarshal message failed, err: %v", utils.GetFullCallerInfo(0), err)
+		}
+		// 发送邮件
+		if err := SendEmailChanged(changedMessage.Email, changedMessage.UserName, changedMessage.NewEmail, changedMessage.ChangedAt); err != nil {
+			logger.Logger.Errorf("send email changed notice failed: %v", err)
+			return code.ErrSendEmail
+		}
+		return nil
+	})
+	consumer.RegisterHandler("login_alert", func(message *sarama.ConsumerMessage) error {
+		var alertMessage dto.LoginAlertMessage
+		if err := json.Unmarshal(message.Value, &alertMessage); err != nil {
+			return fmt.Errorf("[%v]unmarshal message failed, err: %v", utils.GetFullCallerInfo(0), err)
+		}
+		// 发送邮件
+		if err := SendLoginAlert(alertMessage.Email, alertMessage.UserName, alertMessage.DeviceName, alertMessage.IP,
+			alertMessage.Location, alertMessage.LoginAt, alertMessage.DenyURL, alertMessage.ExpireDays); err != nil {
+			logger.Logger.Errorf("send login alert failed: %v", err)
+			return code.ErrSendEmail
+		}
+		return nil
+	})
 	go func() {
 		if err := consumer.Start(context.Background()); err != nil {
 			logger.Logger.Errorf("启动邮件消费者失败: %v", err)
@@ -4,6 +4,7 @@ import (
 	"blog-server/internal/config"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"net/smtp"
 	"os"
 	"strings"
@@ -37,6 +38,64 @@ func SentVerifyCode(to string, code string) error {
 	return SendEmail(to, subject, body)
 }
 
+// SendPasswordReset 发送重置密码邮件
+func SendPasswordReset(to, userName, resetURL string, expireMinutes int) error {
+	subject := "MOITY - 重置密码"
+	body := fmt.Sprintf(`<p>%s，你好：</p>
+<p>我们收到了重置你账号密码的请求，请在 %d 分钟内点击下面的链接设置新密码：</p>
+<p><a href="%s">%s</a></p>
+<p>链接只能使用一次。如果不是你本人操作，请忽略这封邮件，你的密码不会改变。</p>`,
+		html.EscapeString(userName), expireMinutes, html.EscapeString(resetURL), html.EscapeString(resetURL))
+	return SendEmail(to, subject, body)
+}
+
+// SendDataExportReady 发送个人数据导出完成邮件
+func SendDataExportReady(to, userName, downloadURL string, expireDays int) error {
+	subject := "MOITY - 个人数据导出完成"
+	body := fmt.Sprintf(`<p>%s，你好：</p>
+<p>你申请导出的个人数据已经打包完成，请登录后在下面的页面下载：</p>
+<p><a href="%s">%s</a></p>
+<p>文件将在 %d 天后删除。如果不是你本人操作，请尽快修改密码。</p>`,
+		html.EscapeString(userName), html.EscapeString(downloadURL), html.EscapeString(downloadURL), expireDays)
+	return SendEmail(to, subject, body)
+}
+
+// SendAccountDeletionScheduled 发送账号注销申请邮件
+func SendAccountDeletionScheduled(to, userName, scheduledAt, cancelURL string) error {
+	subject := "MOITY - 账号注销申请"
+	body := fmt.Sprintf(`<p>%s，你好：</p>
+<p>我们收到了注销你账号的申请，账号将在 %s 被永久删除，删除后无法恢复。</p>
+<p>在此之前你可以登录后在下面的页面撤销注销：</p>
+<p><a href="%s">%s</a></p>
+<p>如果不是你本人操作，请立即撤销注销并修改密码。</p>`,
+		html.EscapeString(userName), html.EscapeString(scheduledAt), html.EscapeString(cancelURL), html.EscapeString(cancelURL))
+	return SendEmail(to, subject, body)
+}
+
+// SendEmailChanged 通知旧邮箱账号邮箱已修改
+func SendEmailChanged(to, userName, newEmail, changedAt string) error {
+	subject := "MOITY - 账号邮箱已修改"
+	body := fmt.Sprintf(`<p>%s，你好：</p>
+<p>你的账号邮箱已于 %s 修改为 %s，之后的通知邮件将发送到新邮箱。</p>
+<p>如果不是你本人操作，请立即联系我们。</p>`,
+		html.EscapeString(userName), html.EscapeString(changedAt), html.EscapeString(newEmail))
+	return SendEmail(to, subject, body)
+}
+
+// SendLoginAlert 发送新设备或新位置登录提醒邮件
+func SendLoginAlert(to, userName, deviceName, ip, location, loginAt, denyURL string, expireDays int) error {
+	subject := "MOITY - 新设备登录提醒"
+	body := fmt.Sprintf(`<p>%s，你好：</p>
+<p>你的账号于 %s 在新的设备或位置登录：</p>
+<p>设备：%s<br>IP：%s<br>位置：%s</p>
+<p>如果是你本人操作，请忽略这封邮件。如果不是你本人登录，请点击下面的链接，我们会让这次登录下线，并要求你重置密码：</p>
+<p><a href="%s">%s</a></p>
+<p>链接在 %d 天内有效。</p>`,
+		html.EscapeString(userName), html.EscapeString(loginAt), html.EscapeString(deviceName), html.EscapeString(ip),
+		html.EscapeString(location), html.EscapeString(denyURL), html.EscapeString(denyURL), expireDays)
+	return SendEmail(to, subject, body)
+}
+
 func SendEmail(to string, subject string, body string) error {
 	e := email.NewEmail()
 	e.From = fmt.Sprintf("%s <%s>", config.Conf.Email.SenderName, config.Conf.Email.SenderEmail)
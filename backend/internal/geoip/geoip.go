@@ -0,0 +1,183 @@
+// Package geoip 根据离线IP数据库文件查询IP的大致地理位置
+//
+// 数据库文件为CSV格式，每行是一个IP段，支持DB-IP的免费数据库：
+//
+//	IP to City Lite:    ip_start,ip_end,continent,country,region,city,latitude,longitude
+//	IP to Country Lite: ip_start,ip_end,country
+//
+// IPv4和IPv6可以混在同一个文件中，IP段之间不能重叠。
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Location IP的大致地理位置，查不到时各字段为空
+type Location struct {
+	Country string `json:"country"` // 国家或地区代码，如CN
+	Region  string `json:"region"`  // 省或州
+	City    string `json:"city"`    // 城市
+}
+
+// IsZero 是否没有查到位置
+func (l Location) IsZero() bool {
+	return l.Country == "" && l.Region == "" && l.City == ""
+}
+
+// String 返回"城市, 省, 国家"格式的位置，忽略为空的部分
+func (l Location) String() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ipRange 一个IP段及其位置
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location Location
+}
+
+// Database 加载到内存中的IP数据库
+type Database struct {
+	ranges []ipRange
+}
+
+var (
+	mu      sync.RWMutex
+	current *Database
+)
+
+// Init 加载离线IP数据库，path为空时不查询地理位置
+func Init(path string) error {
+	if path == "" {
+		SetDatabase(nil)
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open geoip database failed: %w", err)
+	}
+	defer file.Close()
+
+	db, err := Load(file)
+	if err != nil {
+		return fmt.Errorf("load geoip database %s failed: %w", path, err)
+	}
+	SetDatabase(db)
+	return nil
+}
+
+// SetDatabase 替换当前使用的IP数据库，传入nil时不查询地理位置
+func SetDatabase(db *Database) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = db
+}
+
+// Lookup 使用当前的IP数据库查询IP的位置，没有加载数据库或查不到时返回空位置
+func Lookup(ip string) Location {
+	mu.RLock()
+	db := current
+	mu.RUnlock()
+	if db == nil {
+		return Location{}
+	}
+	return db.Lookup(ip)
+}
+
+// Load 从CSV读取IP数据库
+func Load(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	// 相同的地名只保存一份
+	names := make(map[string]string)
+	intern := func(s string) string {
+		s = strings.TrimSpace(s)
+		if v, ok := names[s]; ok {
+			return v
+		}
+		names[s] = s
+		return s
+	}
+
+	db := &Database{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 columns, got %d", line, len(record))
+		}
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			// 跳过表头
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+
+		var location Location
+		if len(record) >= 6 {
+			location = Location{Country: intern(record[3]), Region: intern(record[4]), City: intern(record[5])}
+		} else {
+			location = Location{Country: intern(record[2])}
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, location: location})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+	for i := 1; i < len(db.ranges); i++ {
+		if !db.ranges[i-1].end.Less(db.ranges[i].start) {
+			return nil, fmt.Errorf("overlapping ranges %s-%s and %s-%s",
+				db.ranges[i-1].start, db.ranges[i-1].end, db.ranges[i].start, db.ranges[i].end)
+		}
+	}
+	return db, nil
+}
+
+// Lookup 查询IP的位置，IP无效或查不到时返回空位置
+func (db *Database) Lookup(ip string) Location {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return Location{}
+	}
+	addr = addr.Unmap()
+	// 找到起始地址不大于addr的最后一个IP段
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	}) - 1
+	if i < 0 || db.ranges[i].end.Less(addr) {
+		return Location{}
+	}
+	return db.ranges[i].location
+}
@@ -0,0 +1,76 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDatabase = `ip_start,ip_end,continent,country,region,city,latitude,longitude
+1.0.1.0,1.0.3.255,AS,CN,Fujian,Fuzhou,26.0614,119.306
+8.8.8.0,8.8.8.255,NA,US,California,Mountain View,37.4223,-122.085
+2001:db8::,2001:db8::ffff,EU,DE,Berlin,Berlin,52.52,13.405
+`
+
+func TestLookup(t *testing.T) {
+	db, err := Load(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"1.0.1.0", Location{Country: "CN", Region: "Fujian", City: "Fuzhou"}},
+		{"1.0.2.17", Location{Country: "CN", Region: "Fujian", City: "Fuzhou"}},
+		{"1.0.3.255", Location{Country: "CN", Region: "Fujian", City: "Fuzhou"}},
+		{"1.0.4.0", Location{}},
+		{"8.8.8.8", Location{Country: "US", Region: "California", City: "Mountain View"}},
+		{"::ffff:8.8.8.8", Location{Country: "US", Region: "California", City: "Mountain View"}},
+		{"2001:db8::1", Location{Country: "DE", Region: "Berlin", City: "Berlin"}},
+		{"0.0.0.1", Location{}},
+		{"127.0.0.1", Location{}},
+		{"not an ip", Location{}},
+	}
+	for _, tt := range tests {
+		if got := db.Lookup(tt.ip); got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCountryDatabase(t *testing.T) {
+	db, err := Load(strings.NewReader("1.0.0.0,1.0.0.255,AU\n1.0.1.0,1.0.3.255,CN\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Lookup("1.0.0.1"); got != (Location{Country: "AU"}) {
+		t.Errorf("unexpected location: %+v", got)
+	}
+	if got := db.Lookup("1.0.1.1").String(); got != "CN" {
+		t.Errorf("unexpected location: %q", got)
+	}
+}
+
+func TestLoadInvalidDatabase(t *testing.T) {
+	tests := []string{
+		"1.0.0.0,1.0.0.255,AU\n1.0.0.128,1.0.1.255,CN\n",
+		"1.0.0.255,1.0.0.0,AU\n",
+		"1.0.0.0,2001:db8::,AU\n",
+		"1.0.0.0,1.0.0.255\n",
+		"1.0.0.0,1.0.0.255,AU\nbad,1.0.1.255,CN\n",
+	}
+	for _, data := range tests {
+		if _, err := Load(strings.NewReader(data)); err == nil {
+			t.Errorf("Load(%q) should fail", data)
+		}
+	}
+}
+
+func TestLocationString(t *testing.T) {
+	if got := (Location{Country: "CN", City: "Fuzhou"}).String(); got != "Fuzhou, CN" {
+		t.Errorf("unexpected location: %q", got)
+	}
+	if !(Location{}).IsZero() {
+		t.Error("empty location should be zero")
+	}
+}
@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// disqusExport Disqus导出的XML结构
+type disqusExport struct {
+	Threads []disqusThread `xml:"thread"`
+	Posts   []disqusPost   `xml:"post"`
+}
+
+type disqusThread struct {
+	ID    string `xml:"http://disqus.com/disqus-internals id,attr"`
+	Link  string `xml:"link"`
+	Title string `xml:"title"`
+}
+
+type disqusPost struct {
+	ID        string `xml:"http://disqus.com/disqus-internals id,attr"`
+	Message   string `xml:"message"`
+	CreatedAt string `xml:"createdAt"`
+	IsDeleted bool   `xml:"isDeleted"`
+	IsSpam    bool   `xml:"isSpam"`
+	Author    struct {
+		Name     string `xml:"name"`
+		Username string `xml:"username"`
+	} `xml:"author"`
+	Thread struct {
+		ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+	} `xml:"thread"`
+	Parent struct {
+		ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+	} `xml:"parent"`
+}
+
+// ParseDisqus 解析Disqus导出的XML，跳过已删除和垃圾评论，评论内容转换为纯文本
+func ParseDisqus(r io.Reader) (*Export, error) {
+	var data disqusExport
+	if err := xml.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode disqus xml: %w", err)
+	}
+
+	export := &Export{Source: SourceDisqus}
+	for _, thread := range data.Threads {
+		export.Threads = append(export.Threads, Thread{
+			ID:    thread.ID,
+			URL:   strings.TrimSpace(thread.Link),
+			Title: strings.TrimSpace(thread.Title),
+		})
+	}
+	for _, post := range data.Posts {
+		if post.IsDeleted || post.IsSpam {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339, strings.TrimSpace(post.CreatedAt))
+		if err != nil {
+			return nil, fmt.Errorf("parse createdAt of post %s: %w", post.ID, err)
+		}
+		name := strings.TrimSpace(post.Author.Name)
+		if name == "" {
+			name = strings.TrimSpace(post.Author.Username)
+		}
+		export.Comments = append(export.Comments, Comment{
+			ID:         post.ID,
+			ThreadID:   post.Thread.ID,
+			ParentID:   post.Parent.ID,
+			AuthorName: name,
+			Content:    htmlToText(post.Message),
+			CreatedAt:  createdAt,
+		})
+	}
+	return export, nil
+}
@@ -0,0 +1,102 @@
+// Package importer 解析第三方评论系统(Disqus、Twikoo、Valine)导出的评论数据
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blankLinesPattern 连续的多个空行
+var blankLinesPattern = regexp.MustCompile(`\n\s*\n\s*\n`)
+
+// 支持的评论来源
+const (
+	SourceDisqus = "disqus"
+	SourceTwikoo = "twikoo"
+	SourceValine = "valine"
+)
+
+// Thread 一个评论串，对应原博客的一篇文章
+type Thread struct {
+	ID    string // 原系统中的评论串ID
+	URL   string // 文章链接
+	Title string // 文章标题，Twikoo和Valine的导出中没有标题
+}
+
+// Comment 一条导入的评论
+type Comment struct {
+	ID         string    // 原系统中的评论ID
+	ThreadID   string    // 所属评论串ID
+	ParentID   string    // 父评论ID，顶层评论为空
+	AuthorName string    // 评论者昵称
+	Content    string    // 评论内容
+	CreatedAt  time.Time // 评论时间
+}
+
+// Export 解析后的导出数据
+type Export struct {
+	Source   string
+	Threads  []Thread
+	Comments []Comment
+}
+
+// Parse 根据来源解析导出文件
+func Parse(source string, r io.Reader) (*Export, error) {
+	switch source {
+	case SourceDisqus:
+		return ParseDisqus(r)
+	case SourceTwikoo, SourceValine:
+		export, err := ParseTwikoo(r)
+		if err != nil {
+			return nil, err
+		}
+		export.Source = source
+		return export, nil
+	default:
+		return nil, fmt.Errorf("unsupported comment source: %s", source)
+	}
+}
+
+// htmlToText 将评论的HTML转换为纯文本，Disqus、Twikoo和Valine保存的评论内容都是HTML
+// 去掉标签并反转义实体，<br>转换为换行，段落之间用空行分隔
+func htmlToText(content string) string {
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	skip := 0 // 位于script或style中时不保留文本
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			text := blankLinesPattern.ReplaceAllString(builder.String(), "\n\n")
+			return strings.TrimSpace(text)
+		case html.TextToken:
+			if skip == 0 {
+				builder.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Br:
+				builder.WriteString("\n")
+			case atom.Li:
+				if tokenType == html.StartTagToken {
+					builder.WriteString("\n")
+				}
+			case atom.P, atom.Div, atom.Blockquote, atom.Pre, atom.Ul, atom.Ol:
+				builder.WriteString("\n\n")
+			case atom.Script, atom.Style:
+				if tokenType == html.StartTagToken {
+					skip++
+				} else if tokenType == html.EndTagToken && skip > 0 {
+					skip--
+				}
+			}
+		}
+	}
+}
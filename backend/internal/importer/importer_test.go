@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const disqusXML = `<?xml version="1.0" encoding="utf-8"?>
+<disqus xmlns="http://disqus.com" xmlns:dsq="http://disqus.com/disqus-internals">
+  <category dsq:id="1"><forum>blog</forum><title>General</title></category>
+  <thread dsq:id="100">
+    <link>https://old.example.com/posts/hello-world/</link>
+    <title>Hello World</title>
+  </thread>
+  <post dsq:id="200">
+    <message><![CDATA[<p>First!</p>]]></message>
+    <createdAt>2019-05-01T08:30:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name>Alice</name><isAnonymous>true</isAnonymous></author>
+    <thread dsq:id="100" />
+  </post>
+  <post dsq:id="201">
+    <message><![CDATA[Reply]]></message>
+    <createdAt>2019-05-02T08:30:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name></name><username>bob</username></author>
+    <thread dsq:id="100" />
+    <parent dsq:id="200" />
+  </post>
+  <post dsq:id="202">
+    <message><![CDATA[spam]]></message>
+    <createdAt>2019-05-03T08:30:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>true</isSpam>
+    <author><name>Spammer</name></author>
+    <thread dsq:id="100" />
+  </post>
+</disqus>`
+
+func TestParseDisqus(t *testing.T) {
+	export, err := ParseDisqus(strings.NewReader(disqusXML))
+	if err != nil {
+		t.Fatalf("ParseDisqus() error = %v", err)
+	}
+	if len(export.Threads) != 1 || export.Threads[0].ID != "100" || export.Threads[0].Title != "Hello World" {
+		t.Fatalf("threads = %+v", export.Threads)
+	}
+	if len(export.Comments) != 2 {
+		t.Fatalf("comments = %+v, want 2 without spam", export.Comments)
+	}
+	first, reply := export.Comments[0], export.Comments[1]
+	if first.AuthorName != "Alice" || first.Content != "First!" || first.ThreadID != "100" || first.ParentID != "" {
+		t.Errorf("first comment = %+v", first)
+	}
+	if !first.CreatedAt.Equal(time.Date(2019, 5, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("first comment createdAt = %v", first.CreatedAt)
+	}
+	if reply.AuthorName != "bob" || reply.ParentID != "200" {
+		t.Errorf("reply = %+v", reply)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"<p>First!</p>", "First!"},
+		{"plain text", "plain text"},
+		{"line one<br>line two<br/>line three", "line one\nline two\nline three"},
+		{"<p>first paragraph</p>\n<p>second &amp; <b>last</b></p>", "first paragraph\n\nsecond & last"},
+		{`<p>see <a href="https://example.com">this &lt;link&gt;</a> &quot;now&quot;</p>`, `see this <link> "now"`},
+		{"<ul><li>a</li><li>b</li></ul>", "a\nb"},
+		{"<p>safe</p><script>alert(1)</script>", "safe"},
+	}
+	for _, tt := range tests {
+		if got := htmlToText(tt.input); got != tt.want {
+			t.Errorf("htmlToText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseTwikoo(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantIDs  []string
+		wantTime time.Time
+	}{
+		{
+			name: "twikoo array",
+			input: `[
+				{"_id":"a1","nick":"Alice","comment":"hi","url":"/posts/hello/","created":1556699400000},
+				{"_id":"a2","nick":"Bob","comment":"re","url":"/posts/hello/","pid":"a1","rid":"a1","created":1556785800000},
+				{"_id":"a3","nick":"Spam","comment":"buy","url":"/posts/hello/","created":1556785800000,"isSpam":true}
+			]`,
+			wantIDs:  []string{"a1", "a2"},
+			wantTime: time.UnixMilli(1556699400000),
+		},
+		{
+			name: "twikoo json lines",
+			input: `{"_id":"a1","nick":"Alice","comment":"hi","url":"/posts/hello/","created":1556699400000}
+{"_id":"a2","nick":"Bob","comment":"re","url":"/posts/hello/","pid":"a1","created":1556785800000}`,
+			wantIDs:  []string{"a1", "a2"},
+			wantTime: time.UnixMilli(1556699400000),
+		},
+		{
+			name: "valine leancloud results",
+			input: `{"results":[
+				{"objectId":"v1","nick":"Alice","comment":"hi","url":"/posts/hello.html","insertedAt":{"__type":"Date","iso":"2019-05-01T08:30:00.000Z"},"createdAt":"2019-06-01T00:00:00.000Z"},
+				{"objectId":"v2","nick":"Bob","comment":"re","url":"/posts/hello.html","pid":"v1","createdAt":"2019-05-02T08:30:00.000Z"}
+			]}`,
+			wantIDs:  []string{"v1", "v2"},
+			wantTime: time.Date(2019, 5, 1, 8, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := ParseTwikoo(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseTwikoo() error = %v", err)
+			}
+			if len(export.Threads) != 1 {
+				t.Errorf("threads = %+v, want 1", export.Threads)
+			}
+			if len(export.Comments) != len(tt.wantIDs) {
+				t.Fatalf("comments = %+v, want %v", export.Comments, tt.wantIDs)
+			}
+			for i, id := range tt.wantIDs {
+				if export.Comments[i].ID != id {
+					t.Errorf("comment[%d].ID = %s, want %s", i, export.Comments[i].ID, id)
+				}
+			}
+			if export.Comments[1].ParentID != tt.wantIDs[0] {
+				t.Errorf("reply parent = %s, want %s", export.Comments[1].ParentID, tt.wantIDs[0])
+			}
+			if !export.Comments[0].CreatedAt.Equal(tt.wantTime) {
+				t.Errorf("createdAt = %v, want %v", export.Comments[0].CreatedAt, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestParseTwikooHTMLContent(t *testing.T) {
+	input := `[{"_id":"a1","nick":"Alice","comment":"<p>hi &amp; <a href=\"https://example.com\">welcome</a></p>\n<p>second<br>line</p>","url":"/posts/hello/","created":1556699400000}]`
+	export, err := ParseTwikoo(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseTwikoo() error = %v", err)
+	}
+	if len(export.Comments) != 1 {
+		t.Fatalf("comments = %+v, want 1", export.Comments)
+	}
+	if want := "hi & welcome\n\nsecond\nline"; export.Comments[0].Content != want {
+		t.Errorf("content = %q, want %q", export.Comments[0].Content, want)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	matcher := NewMatcher([]Article{
+		{ID: "0b6f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", Title: "Hello World"},
+		{ID: "1c7f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", Title: "Go 并发模式"},
+		{ID: "2d8f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", Title: "Another Post"},
+	})
+	tests := []struct {
+		name   string
+		thread Thread
+		wantID string
+		wantBy string
+	}{
+		{"article id in url", Thread{URL: "https://blog.example.com/articles/2d8f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e"}, "2d8f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", MatchByURL},
+		{"slug", Thread{URL: "https://old.example.com/posts/hello-world/"}, "0b6f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", MatchBySlug},
+		{"slug with extension", Thread{URL: "/2019/05/hello-world.html"}, "0b6f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", MatchBySlug},
+		{"escaped unicode slug", Thread{URL: "/posts/go-%E5%B9%B6%E5%8F%91%E6%A8%A1%E5%BC%8F/"}, "1c7f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", MatchBySlug},
+		{"title", Thread{URL: "https://old.example.com/?p=42", Title: "  another   post "}, "2d8f3c2e-6d1a-4f0e-9a57-8a1f2b3c4d5e", MatchByTitle},
+		{"no match", Thread{URL: "https://old.example.com/posts/missing/", Title: "Missing"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, by, ok := matcher.Match(tt.thread)
+			if ok != (tt.wantID != "") || article.ID != tt.wantID || by != tt.wantBy {
+				t.Errorf("Match() = %+v, %s, %v, want %s by %s", article, by, ok, tt.wantID, tt.wantBy)
+			}
+		})
+	}
+}
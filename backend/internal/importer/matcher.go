@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 匹配方式
+const (
+	MatchByURL   = "url"
+	MatchBySlug  = "slug"
+	MatchByTitle = "title"
+)
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Article 用于匹配的文章信息
+type Article struct {
+	ID    string
+	Title string
+}
+
+// Matcher 将评论串匹配到文章
+// 依次尝试：链接中包含的文章ID、链接最后一段与标题生成的slug、标题
+type Matcher struct {
+	byID    map[string]Article
+	bySlug  map[string]Article
+	byTitle map[string]Article
+}
+
+// NewMatcher 创建匹配器
+func NewMatcher(articles []Article) *Matcher {
+	matcher := &Matcher{
+		byID:    make(map[string]Article, len(articles)),
+		bySlug:  make(map[string]Article, len(articles)),
+		byTitle: make(map[string]Article, len(articles)),
+	}
+	for _, article := range articles {
+		matcher.byID[strings.ToLower(article.ID)] = article
+		if slug := Slugify(article.Title); slug != "" {
+			matcher.bySlug[slug] = article
+		}
+		if title := normalizeTitle(article.Title); title != "" {
+			matcher.byTitle[title] = article
+		}
+	}
+	return matcher
+}
+
+// Match 匹配评论串，返回匹配到的文章和匹配方式
+func (m *Matcher) Match(thread Thread) (Article, string, bool) {
+	for _, id := range uuidPattern.FindAllString(thread.URL, -1) {
+		if article, ok := m.byID[strings.ToLower(id)]; ok {
+			return article, MatchByURL, true
+		}
+	}
+	if slug := urlSlug(thread.URL); slug != "" {
+		if article, ok := m.bySlug[slug]; ok {
+			return article, MatchBySlug, true
+		}
+	}
+	if title := normalizeTitle(thread.Title); title != "" {
+		if article, ok := m.byTitle[title]; ok {
+			return article, MatchByTitle, true
+		}
+	}
+	return Article{}, "", false
+}
+
+// Slugify 将标题转换为slug，保留字母和数字(包括中文)，其它字符替换为连字符
+func Slugify(s string) string {
+	var builder strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			dash = false
+		} else if !dash && builder.Len() > 0 {
+			builder.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "-")
+}
+
+// urlSlug 取链接路径的最后一段作为slug，忽略常见的页面扩展名
+func urlSlug(rawURL string) string {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		p = u.Path
+	}
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	p = strings.TrimRight(p, "/")
+	base := path.Base(p)
+	if base == "." || base == "/" {
+		return ""
+	}
+	switch ext := path.Ext(base); ext {
+	case ".html", ".htm", ".php", ".md":
+		base = strings.TrimSuffix(base, ext)
+	}
+	if base == "index" {
+		base = path.Base(path.Dir(p))
+	}
+	return Slugify(base)
+}
+
+// normalizeTitle 标题比较时忽略大小写和首尾空白
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
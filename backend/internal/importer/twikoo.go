@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// twikooComment Twikoo和Valine导出的评论，两者字段基本一致
+// Twikoo使用_id和毫秒时间戳created，Valine(LeanCloud)使用objectId和createdAt
+type twikooComment struct {
+	ID        string      `json:"_id"`
+	ObjectID  string      `json:"objectId"`
+	Nick      string      `json:"nick"`
+	Comment   string      `json:"comment"`
+	URL       string      `json:"url"`
+	Href      string      `json:"href"`
+	PID       string      `json:"pid"`
+	Created   json.Number `json:"created"`
+	CreatedAt string      `json:"createdAt"`
+	InsertAt  *valineDate `json:"insertedAt"`
+	IsSpam    bool        `json:"isSpam"`
+}
+
+// valineDate LeanCloud的日期类型
+type valineDate struct {
+	ISO string `json:"iso"`
+}
+
+// ParseTwikoo 解析Twikoo或Valine导出的JSON
+// 支持顶层为数组，或LeanCloud导出的{"results": [...]}格式，也支持每行一个JSON对象
+func ParseTwikoo(r io.Reader) (*Export, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	items, err := decodeTwikooItems(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{Source: SourceTwikoo}
+	threads := make(map[string]bool)
+	for _, item := range items {
+		if item.IsSpam {
+			continue
+		}
+		id := item.ID
+		if id == "" {
+			id = item.ObjectID
+		}
+		createdAt, err := item.createdAt()
+		if err != nil {
+			return nil, fmt.Errorf("parse created time of comment %s: %w", id, err)
+		}
+
+		// 评论串以文章路径区分
+		threadID := strings.TrimSpace(item.URL)
+		if threadID == "" {
+			threadID = strings.TrimSpace(item.Href)
+		}
+		if !threads[threadID] {
+			threads[threadID] = true
+			export.Threads = append(export.Threads, Thread{ID: threadID, URL: threadID})
+		}
+
+		export.Comments = append(export.Comments, Comment{
+			ID:         id,
+			ThreadID:   threadID,
+			ParentID:   item.PID,
+			AuthorName: strings.TrimSpace(item.Nick),
+			Content:    htmlToText(item.Comment),
+			CreatedAt:  createdAt,
+		})
+	}
+	return export, nil
+}
+
+// decodeTwikooItems 解析不同格式的导出内容
+func decodeTwikooItems(raw []byte) ([]twikooComment, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var items []twikooComment
+	switch raw[0] {
+	case '[':
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("decode comment json: %w", err)
+		}
+		return items, nil
+	case '{':
+		var wrapper struct {
+			Results []twikooComment `json:"results"`
+		}
+		if err := json.Unmarshal(raw, &wrapper); err == nil && wrapper.Results != nil {
+			return wrapper.Results, nil
+		}
+		// 每行一个JSON对象
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		for decoder.More() {
+			var item twikooComment
+			if err := decoder.Decode(&item); err != nil {
+				return nil, fmt.Errorf("decode comment json: %w", err)
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("decode comment json: unexpected content")
+	}
+}
+
+// createdAt 获取评论时间
+func (item *twikooComment) createdAt() (time.Time, error) {
+	if item.Created != "" {
+		ms, err := item.Created.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms), nil
+	}
+	if item.InsertAt != nil && item.InsertAt.ISO != "" {
+		return time.Parse(time.RFC3339, item.InsertAt.ISO)
+	}
+	if item.CreatedAt != "" {
+		return time.Parse(time.RFC3339, item.CreatedAt)
+	}
+	return time.Time{}, fmt.Errorf("missing created time")
+}
@@ -0,0 +1,305 @@
+package jwtkeys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "user",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ring, err := Open(Options{Dir: t.TempDir(), Algorithm: algorithm, Overlap: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := ring.SigningKey(time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Algorithm != algorithm {
+				t.Fatalf("unexpected algorithm: %s", key.Algorithm)
+			}
+
+			tokenString, err := ring.Sign(testClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Header["kid"] != key.ID || token.Header["alg"] != algorithm {
+				t.Errorf("unexpected header: %v", token.Header)
+			}
+
+			claims, err := ring.Verify(tokenString)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims["sub"] != "user" {
+				t.Errorf("unexpected claims: %v", claims)
+			}
+
+			// 其他密钥环中没有这个kid
+			other, err := Open(Options{Dir: t.TempDir(), Algorithm: algorithm, Overlap: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := other.Verify(tokenString); err == nil {
+				t.Error("token with unknown kid should be rejected")
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsUnsignedToken(t *testing.T) {
+	ring, err := Open(Options{Dir: t.TempDir(), Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := ring.SigningKey(time.Now())
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims())
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Verify(tokenString); err == nil {
+		t.Error("unsigned token should be rejected")
+	}
+}
+
+func TestOpenImportsLegacyKey(t *testing.T) {
+	legacy, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPath := filepath.Join(t.TempDir(), "rsa_private_key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(legacy)})
+	if err := os.WriteFile(legacyPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本签发的令牌没有kid
+	oldToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims()).SignedString(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 配置的算法只影响之后生成的密钥
+	dir := t.TempDir()
+	ring, err := Open(Options{Dir: dir, Algorithm: AlgorithmEdDSA, Overlap: time.Hour, LegacyKeyPath: legacyPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := ring.Keys()
+	if len(keys) != 1 || keys[0].Algorithm != AlgorithmRS256 {
+		t.Fatalf("legacy key should be imported, got %d keys", len(keys))
+	}
+	if _, err := os.Stat(filepath.Join(dir, keys[0].ID+keyFileExt)); err != nil {
+		t.Errorf("legacy key should be saved to the key dir: %v", err)
+	}
+	if _, err := ring.Verify(oldToken); err != nil {
+		t.Errorf("legacy token should be valid: %v", err)
+	}
+
+	// 重新打开时使用密钥目录中的密钥
+	reopened, err := Open(Options{Dir: dir, Overlap: time.Hour, LegacyKeyPath: "missing.pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Keys(); len(got) != 1 || got[0].ID != keys[0].ID {
+		t.Errorf("unexpected keys after reopen: %v", got)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{
+		Dir:              dir,
+		Algorithm:        AlgorithmES256,
+		RotationInterval: 24 * time.Hour,
+		Overlap:          48 * time.Hour,
+		PublishAhead:     time.Hour,
+	}
+	ring, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	first, _ := ring.SigningKey(now)
+
+	if key, err := ring.RotateIfDue(now); err != nil || key != nil {
+		t.Fatalf("rotation should not be due: %v, %v", key, err)
+	}
+
+	rotatedAt := now.Add(25 * time.Hour)
+	second, err := ring.RotateIfDue(rotatedAt)
+	if err != nil || second == nil {
+		t.Fatalf("rotation should be due: %v", err)
+	}
+	if key, err := ring.RotateIfDue(rotatedAt.Add(30 * time.Minute)); err != nil || key != nil {
+		t.Fatalf("should not rotate while the new key is pending: %v, %v", key, err)
+	}
+
+	// 新密钥提前公开，但还没开始签名
+	if key, _ := ring.SigningKey(rotatedAt); key.ID != first.ID {
+		t.Error("old key should sign before the new key activates")
+	}
+	if got := len(ring.validKeys(rotatedAt)); got != 2 {
+		t.Errorf("both keys should be published, got %d", got)
+	}
+	activatedAt := rotatedAt.Add(time.Hour)
+	if key, _ := ring.SigningKey(activatedAt); key.ID != second.ID {
+		t.Error("new key should sign after it activates")
+	}
+
+	// 其他实例重新加载密钥目录后得到相同的密钥
+	other, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := other.SigningKey(activatedAt); key.ID != second.ID {
+		t.Error("other instance should load the rotated key")
+	}
+
+	// 重叠期内旧密钥仍然有效，之后被删除
+	if removed, err := ring.Prune(activatedAt.Add(47 * time.Hour)); err != nil || len(removed) != 0 {
+		t.Fatalf("old key should be kept during overlap: %v, %v", removed, err)
+	}
+	removed, err := ring.Prune(activatedAt.Add(48 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != first.ID {
+		t.Fatalf("old key should be removed, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.ID+keyFileExt)); !os.IsNotExist(err) {
+		t.Error("old key file should be deleted")
+	}
+	if err := other.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.Keys(); len(got) != 1 || got[0].ID != second.ID {
+		t.Errorf("unexpected keys after reload: %v", got)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA} {
+		ring, err := Open(Options{Dir: t.TempDir(), Algorithm: algorithm, Overlap: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := ring.JWKS()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(set.Keys) != 1 {
+			t.Fatalf("unexpected keys: %+v", set)
+		}
+		jwk := set.Keys[0]
+		key, _ := ring.SigningKey(time.Now())
+		if jwk.Kid != key.ID || jwk.Alg != algorithm || jwk.Use != "sig" {
+			t.Errorf("unexpected jwk: %+v", jwk)
+		}
+		switch algorithm {
+		case AlgorithmRS256:
+			if jwk.Kty != "RSA" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("unexpected rsa jwk: %+v", jwk)
+			}
+		case AlgorithmES256:
+			if jwk.Kty != "EC" || jwk.Crv != "P-256" || len(jwk.X) != 43 || len(jwk.Y) != 43 {
+				t.Errorf("unexpected ec jwk: %+v", jwk)
+			}
+		case AlgorithmEdDSA:
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || len(jwk.X) != 43 {
+				t.Errorf("unexpected okp jwk: %+v", jwk)
+			}
+		}
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 3.1 的示例
+	jwk := JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+	if got := jwk.thumbprint(); got != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("unexpected thumbprint: %s", got)
+	}
+}
+
+func TestParsePEM(t *testing.T) {
+	activatesAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	key, err := GenerateKey("ed25519", activatesAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.ID != key.ID || parsed.Algorithm != AlgorithmEdDSA || !parsed.ActivatesAt.Equal(activatesAt) {
+		t.Errorf("unexpected key: %+v", parsed)
+	}
+
+	if _, err := ParsePEM([]byte("not a pem")); err == nil {
+		t.Error("invalid pem should fail")
+	}
+	// 密钥与PEM头中的算法不匹配
+	mismatched := pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{headerAlgorithm: AlgorithmRS256},
+		Bytes:   mustPKCS8(t, key),
+	})
+	if _, err := ParsePEM(mismatched); err == nil {
+		t.Error("mismatched algorithm should fail")
+	}
+}
+
+func mustPKCS8(t *testing.T, key *Key) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestNormalizeAlgorithm(t *testing.T) {
+	tests := map[string]string{
+		"":        AlgorithmRS256,
+		"rs256":   AlgorithmRS256,
+		"ES256":   AlgorithmES256,
+		"Ed25519": AlgorithmEdDSA,
+		"EdDSA":   AlgorithmEdDSA,
+		"HS256":   "",
+	}
+	for input, want := range tests {
+		if got := NormalizeAlgorithm(input); got != want {
+			t.Errorf("NormalizeAlgorithm(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
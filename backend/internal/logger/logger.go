@@ -4,6 +4,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -17,6 +18,12 @@ var (
 	Logger *zap.SugaredLogger
 )
 
+// sensitiveQueryParams 记录日志时需要隐藏值的查询参数
+// EventSource和WebSocket通过查询参数access_token传递令牌
+var sensitiveQueryParams = map[string]bool{
+	"access_token": true,
+}
+
 func InitLogger(baseDir string) error {
 	_, err := initZapLogger(baseDir)
 	if err != nil {
@@ -29,7 +36,7 @@ func GinLogger(logger *zap.SugaredLogger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -62,7 +69,7 @@ func GinRecovery(logger *zap.SugaredLogger, stack bool) gin.HandlerFunc {
 					}
 				}
 
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
+				httpRequest, _ := httputil.DumpRequest(redactRequest(c.Request), false)
 				if brokenPipe {
 					logger.Errorw(c.Request.URL.Path,
 						"error", err,
@@ -91,3 +98,28 @@ func GinRecovery(logger *zap.SugaredLogger, stack bool) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// redactQuery 隐藏查询字符串中敏感参数的值，其余参数保持原样
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && sensitiveQueryParams[name] {
+			params[i] = key + "=REDACTED"
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactRequest 返回隐藏了敏感查询参数的请求副本，用于打印请求
+func redactRequest(request *http.Request) *http.Request {
+	redacted := *request
+	requestURL := *request.URL
+	requestURL.RawQuery = redactQuery(requestURL.RawQuery)
+	redacted.URL = &requestURL
+	redacted.RequestURI = ""
+	return &redacted
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"net/http/httptest"
+	"net/http/httputil"
+	"strings"
+	"testing"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"no token", "page=1&limit=10", "page=1&limit=10"},
+		{"token only", "access_token=eyJhbGciOi", "access_token=REDACTED"},
+		{"token with others", "last_event_id=42&access_token=eyJhbGciOi&x=1", "last_event_id=42&access_token=REDACTED&x=1"},
+		{"escaped key", "access%5Ftoken=eyJhbGciOi", "access%5Ftoken=REDACTED"},
+		{"similar key", "my_access_token=1", "my_access_token=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactQuery(tt.query); got != tt.want {
+				t.Errorf("redactQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactRequest(t *testing.T) {
+	request := httptest.NewRequest("GET", "/api/v1/notifications/stream?access_token=secret", nil)
+	dump, err := httputil.DumpRequest(redactRequest(request), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(dump), "secret") {
+		t.Errorf("dumped request contains token: %s", dump)
+	}
+	// 原请求不受影响
+	if request.URL.Query().Get("access_token") != "secret" {
+		t.Error("original request should keep the token")
+	}
+}
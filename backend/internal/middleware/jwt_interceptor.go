@@ -2,8 +2,9 @@ package middleware
 
 import (
 	"blog-server/internal"
+	"blog-server/internal/accesstoken"
 	"blog-server/internal/code"
-	"blog-server/internal/rsa"
+	"blog-server/internal/tokenstore"
 	"blog-server/internal/utils"
 	"fmt"
 	"time"
@@ -12,7 +13,9 @@ import (
 )
 
 // JWTAuthMiddleware JWT认证中间件
-func JWTAuthMiddleware() gin.HandlerFunc {
+// scopes为接口接受的个人访问令牌权限范围，令牌包含其中任意一个即可访问；
+// 未指定时只接受登录签发的JWT
+func JWTAuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. 获取 Authorization Header
 		authHeader := c.GetHeader("Authorization")
@@ -20,72 +23,141 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			internal.APIResponseUnauthorized(c, code.ErrAuthorizationNotExist, nil)
 			return
 		}
+		authenticate(c, authHeader, scopes...)
+	}
+}
 
-		// 1.1 检查并处理Bearer前缀
-		tokenString := authHeader
-		const bearerPrefix = "Bearer "
-		if len(authHeader) > len(bearerPrefix) && authHeader[:len(bearerPrefix)] == bearerPrefix {
-			// 如果有Bearer前缀，则去除前缀
-			tokenString = authHeader[len(bearerPrefix):]
-		} else if len(authHeader) > 0 && authHeader[:1] != "e" {
-			// 如果没有Bearer前缀且不是以'e'开头(JWT通常以e开头)，则返回错误
-			internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token格式错误，应以'Bearer '开头")
-			return
+// JWTAuthQueryMiddleware JWT认证中间件，允许通过查询参数access_token传递令牌
+// 用于浏览器EventSource、WebSocket等无法设置Authorization头的场景
+func JWTAuthQueryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			authHeader = c.Query("access_token")
 		}
-
-		// 2. 解析 Token
-		claims, err := utils.ValidateJWT(tokenString, rsa.PublicKey)
-
-		// 3. 验证 Token
-		if err != nil {
-			internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, fmt.Sprintf("[%v] Token错误: %v", utils.GetFullCallerInfo(0), err))
+		if authHeader == "" {
+			internal.APIResponseUnauthorized(c, code.ErrAuthorizationNotExist, nil)
 			return
 		}
+		authenticate(c, authHeader)
+	}
+}
 
-		// 4. 验证发行者
-		if claims["iss"].(string) != "moity" {
-			internal.APIResponseUnauthorized(c, code.ErrTokenIssError, nil)
+// OptionalJWTAuthMiddleware 可选的JWT认证中间件
+// 携带令牌时校验并保存用户信息，未携带时按匿名用户继续处理
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
 			return
 		}
+		authenticate(c, authHeader)
+	}
+}
 
-		// 5. 验证是否到时间可用
-		// 处理JWT中时间字段可能是float64类型的情况
-		nbf, ok := claims["nbf"].(float64)
-		if !ok {
-			internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token中的nbf字段格式错误")
-			return
-		}
-		if int64(nbf) > time.Now().Unix() {
-			internal.APIResponseUnauthorized(c, code.ErrTokenNbfError, nil)
-			return
-		}
+// authenticate 校验令牌，校验通过后将用户信息保存到上下文，失败时中止请求
+// scopes不为空时也接受包含其中任意权限范围的个人访问令牌
+func authenticate(c *gin.Context, authHeader string, scopes ...string) {
+	// 1.1 检查并处理Bearer前缀
+	tokenString := authHeader
+	const bearerPrefix = "Bearer "
+	if len(authHeader) > len(bearerPrefix) && authHeader[:len(bearerPrefix)] == bearerPrefix {
+		// 如果有Bearer前缀，则去除前缀
+		tokenString = authHeader[len(bearerPrefix):]
+	} else if len(authHeader) > 0 && authHeader[:1] != "e" {
+		// 如果没有Bearer前缀且不是以'e'开头(JWT通常以e开头)，则返回错误
+		internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token格式错误，应以'Bearer '开头")
+		return
+	}
 
-		// 6. 验证是否过期
-		exp, ok := claims["exp"].(float64)
-		if !ok {
-			internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token中的exp字段格式错误")
-			return
-		}
-		if int64(exp) < time.Now().Unix() {
-			internal.APIResponseUnauthorized(c, code.ErrTokenExpired, nil)
-			return
-		}
+	// 1.2 个人访问令牌
+	if accesstoken.IsAccessToken(tokenString) {
+		authenticateAccessToken(c, tokenString, scopes)
+		return
+	}
 
-		// 7. 验证token类型是否为access
-		tokenType, ok := claims["type"].(string)
-		if !ok || tokenType != "access" {
-			internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token类型错误")
-			return
-		}
+	// 2. 解析 Token
+	claims, err := utils.ValidateJWT(tokenString)
+
+	// 3. 验证 Token
+	if err != nil {
+		internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, fmt.Sprintf("[%v] Token错误: %v", utils.GetFullCallerInfo(0), err))
+		return
+	}
+
+	// 4. 验证发行者
+	if claims["iss"].(string) != "moity" {
+		internal.APIResponseUnauthorized(c, code.ErrTokenIssError, nil)
+		return
+	}
+
+	// 5. 验证是否到时间可用
+	// 处理JWT中时间字段可能是float64类型的情况
+	nbf, ok := claims["nbf"].(float64)
+	if !ok {
+		internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token中的nbf字段格式错误")
+		return
+	}
+	if int64(nbf) > time.Now().Unix() {
+		internal.APIResponseUnauthorized(c, code.ErrTokenNbfError, nil)
+		return
+	}
 
-		// 8. 将用户信息保存到上下文
-		// 将字符串类型的用户ID转换为UUID类型
-		userIDStr := claims["sub"].(string)
-		username := claims["username"].(string)
-		role := claims["role"].(string)
-		c.Set("userID", userIDStr)
-		c.Set("claims", claims)
-		c.Set("username", username)
-		c.Set("role", role)
+	// 6. 验证是否过期
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token中的exp字段格式错误")
+		return
 	}
+	if int64(exp) < time.Now().Unix() {
+		internal.APIResponseUnauthorized(c, code.ErrTokenExpired, nil)
+		return
+	}
+
+	// 7. 验证token类型是否为access
+	tokenType, ok := claims["type"].(string)
+	if !ok || tokenType != "access" {
+		internal.APIResponseUnauthorized(c, code.ErrTokenInvalid, "Token类型错误")
+		return
+	}
+
+	// 8. 检查token是否已被吊销
+	if err := tokenstore.Check(c.Request.Context(), claims); err != nil {
+		internal.APIResponseUnauthorized(c, err, nil)
+		return
+	}
+
+	// 9. 将用户信息保存到上下文
+	// 将字符串类型的用户ID转换为UUID类型
+	userIDStr := claims["sub"].(string)
+	username := claims["username"].(string)
+	role := claims["role"].(string)
+	c.Set("userID", userIDStr)
+	c.Set("claims", claims)
+	c.Set("username", username)
+	c.Set("role", role)
+}
+
+// authenticateAccessToken 校验个人访问令牌，只有接口接受令牌的权限范围时才能访问
+// 个人访问令牌没有会话，上下文中不保存claims，依赖会话的接口不会接受个人访问令牌
+func authenticateAccessToken(c *gin.Context, token string, scopes []string) {
+	if len(scopes) == 0 {
+		internal.APIResponseForbidden(c, code.ErrTokenScope, nil)
+		return
+	}
+	record, user, err := accesstoken.Authenticate(c.Request.Context(), token, c.ClientIP())
+	if err != nil {
+		internal.APIResponseUnauthorized(c, err, nil)
+		return
+	}
+	if !accesstoken.HasScope(record.Scopes, scopes...) {
+		internal.APIResponseForbidden(c, code.ErrTokenScope, nil)
+		return
+	}
+
+	c.Set("userID", user.ID.String())
+	c.Set("username", user.UserName)
+	c.Set("role", user.Role)
+	c.Set("accessTokenID", record.ID.String())
 }
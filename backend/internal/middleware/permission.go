@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"blog-server/internal"
+	"blog-server/internal/code"
+	"blog-server/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限校验中间件，需要放在JWTAuthMiddleware之后
+// 当前用户的角色没有指定权限时返回403
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, err := rbac.HasPermission(c.GetString("role"), permission)
+		if err != nil {
+			internal.APIResponseInternalServerError(c, err, nil)
+			return
+		}
+		if !allowed {
+			internal.APIResponseForbidden(c, code.ErrPermissionDenied, permission)
+			return
+		}
+		c.Next()
+	}
+}
@@ -29,6 +29,8 @@ type Article struct {
 	TagsArray   []string      `json:"tags" gorm:"type:text;serializer:json"`
 	CoverImage  string        `json:"cover_image" gorm:"type:varchar(255);comment:封面图片URL"`
 	PublishedAt *time.Time    `json:"published_at" gorm:"comment:发布时间;index"`
+	// RenderedContent 渲染@提及链接后的内容，不入库
+	RenderedContent string `json:"rendered_content,omitempty" gorm:"-"`
 }
 
 // Validate 验证文章数据
@@ -1,10 +1,35 @@
 package models
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type ArticleComment struct {
-	SwaggerGormModel `json:"-" gorm:"embedded"`
-	Content          string `json:"content" gorm:"type:text;not null"`
-	ArticleTitle     string `json:"article_title" gorm:"type:jsonb;not null"`
-	IsNotify         bool   `json:"is_notify" gorm:"type:bool;not null;default:false"`
-	IsRead           bool   `json:"is_read" gorm:"type:bool;not null;default:false"`
-	IsPass           bool   `json:"is_pass" gorm:"type:bool;not null;default:false"`
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	ArticleID        uuid.UUID  `json:"article_id" gorm:"type:uuid;index"`   // 文章ID
+	UserID           *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`      // 评论者ID，匿名评论为空
+	ParentID         *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"`    // 父评论ID，顶层评论为空
+	GuestName        string     `json:"guest_name" gorm:"type:varchar(100)"` // 匿名评论者昵称，导入的评论保留原昵称
+	Content          string     `json:"content" gorm:"type:text;not null"`
+	ArticleTitle     string     `json:"article_title" gorm:"type:jsonb;not null"`
+	IsNotify         bool       `json:"is_notify" gorm:"type:bool;not null;default:false"`
+	IsRead           bool       `json:"is_read" gorm:"type:bool;not null;default:false"`
+	IsPass           bool       `json:"is_pass" gorm:"type:bool;not null;default:false"`
+	IsEdited         bool       `json:"is_edited" gorm:"type:bool;not null;default:false"`                        // 是否编辑过
+	EditedAt         *time.Time `json:"edited_at"`                                                                // 最后编辑时间
+	IsDeleted        bool       `json:"is_deleted" gorm:"type:bool;not null;default:false"`                       // 是否已删除，有回复的评论删除后保留为占位
+	ImportSource     string     `json:"import_source,omitempty" gorm:"type:varchar(20);index:idx_comment_import"` // 导入来源(disqus/twikoo/valine)
+	ImportID         string     `json:"-" gorm:"type:varchar(100);index:idx_comment_import"`                      // 原系统中的评论ID，用于避免重复导入
+	RenderedContent  string     `json:"rendered_content,omitempty" gorm:"-"`                                      // 渲染@提及链接后的内容，不入库
+}
+
+// CommentRevision 评论的历史版本，每次编辑前保存旧内容
+type CommentRevision struct {
+	SwaggerGormModel
+	CommentID uuid.UUID      `json:"comment_id" gorm:"type:uuid;not null;index"`                // 评论ID
+	Comment   ArticleComment `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"` // 评论
+	EditorID  uuid.UUID      `json:"editor_id" gorm:"type:uuid;not null"`                       // 编辑者ID
+	Content   string         `json:"content" gorm:"type:text;not null"`                         // 编辑前的内容
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 个人数据导出的状态
+const (
+	DataExportStatusPending    = "pending"    // 等待处理
+	DataExportStatusProcessing = "processing" // 正在打包
+	DataExportStatusCompleted  = "completed"  // 已完成，可以下载
+	DataExportStatusFailed     = "failed"     // 打包失败
+	DataExportStatusExpired    = "expired"    // 已过期，文件已删除
+)
+
+// DataExport 个人数据导出任务，打包好的zip文件保存在对象存储中
+type DataExport struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                      // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户
+	Status           string     `json:"status" gorm:"type:varchar(20);not null;index"`          // 状态：pending, processing, completed, failed, expired
+	ObjectName       string     `json:"-" gorm:"type:varchar(255)"`                             // 对象存储中的文件名
+	FileSize         int64      `json:"file_size" gorm:"type:bigint;not null;default:0"`        // 文件大小（字节）
+	CompletedAt      *time.Time `json:"completed_at" gorm:"type:timestamp"`                     // 完成时间
+	ExpiresAt        *time.Time `json:"expires_at" gorm:"type:timestamp;index"`                 // 过期时间，过期后文件被删除
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Follow 关注关系，每条记录表示一个用户关注了另一个用户
+// 取消关注时直接删除记录，同一对用户只会有一条记录
+type Follow struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	FollowerID       uuid.UUID `json:"follower_id" gorm:"type:uuid;not null;uniqueIndex:idx_follow_pair"`       // 关注者的用户ID
+	Follower         User      `json:"-" gorm:"foreignKey:FollowerID;constraint:OnDelete:CASCADE"`              // 关注者
+	FolloweeID       uuid.UUID `json:"followee_id" gorm:"type:uuid;not null;uniqueIndex:idx_follow_pair;index"` // 被关注的用户ID
+	Followee         User      `json:"-" gorm:"foreignKey:FolloweeID;constraint:OnDelete:CASCADE"`              // 被关注的用户
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InviteCode 注册邀请码，注册时使用邀请码的用户会获得邀请码设置的角色
+type InviteCode struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	Code             string     `json:"code" gorm:"type:varchar(32);not null;uniqueIndex"`         // 邀请码
+	CreatedBy        uuid.UUID  `json:"created_by" gorm:"type:uuid;not null;index"`                // 创建邀请码的用户ID
+	Creator          User       `json:"-" gorm:"foreignKey:CreatedBy;constraint:OnDelete:CASCADE"` // 创建邀请码的用户
+	Role             string     `json:"role" gorm:"type:varchar(32);not null"`                     // 使用邀请码注册的用户的角色
+	MaxUses          int        `json:"max_uses" gorm:"type:int;not null;default:1"`               // 最多可以使用的次数，1表示一次性邀请码
+	UsedCount        int        `json:"used_count" gorm:"type:int;not null;default:0"`             // 已经使用的次数
+	Note             string     `json:"note" gorm:"type:varchar(255)"`                             // 备注，例如发给谁
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"type:timestamp;not null"`                 // 过期时间
+	RevokedAt        *time.Time `json:"revoked_at" gorm:"type:timestamp"`                          // 作废时间，为空表示没有作废
+}
+
+// IsUsable 邀请码是否仍然可以使用
+func (i *InviteCode) IsUsable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && i.UsedCount < i.MaxUses
+}
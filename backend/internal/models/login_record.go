@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 登录方式
+const (
+	LoginMethodPassword = "password" // 账号密码
+	LoginMethodPasskey  = "passkey"  // 通行密钥
+	LoginMethodOAuth    = "oauth"    // 第三方登录
+)
+
+// LoginRecord 登录记录，成功和失败的登录都会记录
+// 从新设备或新位置登录成功时会发送提醒邮件
+type LoginRecord struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index:idx_login_record_user_time"`              // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`                    // 用户
+	SessionID        *uuid.UUID `json:"session_id" gorm:"type:uuid"`                                               // 登录成功时创建的会话ID
+	Method           string     `json:"method" gorm:"type:varchar(32)"`                                            // 登录方式：password, passkey, oauth
+	Success          bool       `json:"success" gorm:"not null"`                                                   // 是否登录成功
+	FailureReason    string     `json:"failure_reason" gorm:"type:varchar(100)"`                                   // 失败原因
+	IP               string     `json:"ip" gorm:"type:varchar(64)"`                                                // 请求IP
+	UserAgent        string     `json:"user_agent" gorm:"type:varchar(500)"`                                       // 用户代理
+	DeviceName       string     `json:"device_name" gorm:"type:varchar(100)"`                                      // 根据用户代理识别的设备，用于判断是否为新设备
+	Country          string     `json:"country" gorm:"type:varchar(64)"`                                           // 国家或地区
+	Region           string     `json:"region" gorm:"type:varchar(100)"`                                           // 省或州
+	City             string     `json:"city" gorm:"type:varchar(100)"`                                             // 城市
+	NewDevice        bool       `json:"new_device" gorm:"not null;default:false"`                                  // 是否为新设备
+	NewLocation      bool       `json:"new_location" gorm:"not null;default:false"`                                // 是否为新位置
+	LoggedAt         time.Time  `json:"logged_at" gorm:"type:timestamp;not null;index:idx_login_record_user_time"` // 登录时间
+	ReportedAt       *time.Time `json:"reported_at" gorm:"type:timestamp"`                                         // 用户确认不是本人登录的时间
+}
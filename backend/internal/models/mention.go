@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// MentionSourceType 提及来源类型
+type MentionSourceType string
+
+const (
+	MentionSourceArticle MentionSourceType = "article" // 文章正文
+	MentionSourceComment MentionSourceType = "comment" // 文章评论
+)
+
+// Mention @提及记录
+// 每条记录表示某篇文章或某条评论中提到了某个用户
+type Mention struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	SourceType       MentionSourceType `json:"source_type" gorm:"type:varchar(20);not null;index:idx_mention_source"` // 来源类型
+	SourceID         uuid.UUID         `json:"source_id" gorm:"type:uuid;not null;index:idx_mention_source"`          // 来源ID（文章ID或评论ID）
+	MentionedUserID  uuid.UUID         `json:"mentioned_user_id" gorm:"type:uuid;not null;index"`                     // 被提及的用户ID
+	MentionedUser    User              `json:"-" gorm:"foreignKey:MentionedUserID;constraint:OnDelete:CASCADE"`       // 被提及的用户
+	MentionerUserID  uuid.UUID         `json:"mentioner_user_id" gorm:"type:uuid"`                                    // 提及者用户ID，游客评论为空
+	Notified         bool              `json:"notified" gorm:"type:boolean;not null;default:false"`                   // 是否已发送通知
+}
@@ -93,6 +93,8 @@ func createDB(databaseConfig config.DataBaseConfig) error {
 	)
 	db, err := gorm.Open(postgres.Open(DB_DSN), &gorm.Config{
 		Logger: getGormLogger(databaseConfig.Gorm),
+		// 将唯一约束冲突转换为gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		logger.Logger.Errorf("Failed to connect to Postgres! [Error]: %v", err)
@@ -186,12 +188,65 @@ func migrate() error {
 	if err := DB.AutoMigrate(&User{}); err != nil {
 		return err
 	}
+	// 角色改为由roles表管理，删除旧版本中限制角色取值的约束
+	if DB.Migrator().HasConstraint(&User{}, "chk_users_role") {
+		if err := DB.Migrator().DropConstraint(&User{}, "chk_users_role"); err != nil {
+			return err
+		}
+	}
+	// 登录失败次数和锁定状态改为保存在Redis中，删除旧版本中的列
+	for _, column := range []string{"failed_login_count", "last_failed_login", "last_failed_reason", "is_locked", "lock_until"} {
+		if DB.Migrator().HasColumn(&User{}, column) {
+			if err := DB.Migrator().DropColumn(&User{}, column); err != nil {
+				return err
+			}
+		}
+	}
 	if err := DB.AutoMigrate(&Article{}); err != nil {
 		return err
 	}
 	if err := DB.AutoMigrate(&OperationLog{}); err != nil {
 		return err
 	}
+	if err := DB.AutoMigrate(&Mention{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&Notification{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&CommentRevision{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&UserSession{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&RecoveryCode{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&Passkey{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&UserIdentity{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&PersonalAccessToken{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&Role{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&DataExport{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&Follow{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&InviteCode{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&LoginRecord{}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType 通知类型
+type NotificationType string
+
+const (
+	NotificationTypeMention NotificationType = "mention" // 被@提及
+)
+
+// Notification 站内通知
+type Notification struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`                  // 接收通知的用户ID
+	User             User             `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`   // 接收通知的用户
+	ActorID          uuid.UUID        `json:"actor_id" gorm:"type:uuid"`                                // 触发通知的用户ID，游客为空
+	Type             NotificationType `json:"type" gorm:"type:varchar(30);not null;index"`              // 通知类型
+	SourceType       string           `json:"source_type" gorm:"type:varchar(20)"`                      // 关联内容类型
+	SourceID         uuid.UUID        `json:"source_id" gorm:"type:uuid"`                               // 关联内容ID
+	Content          string           `json:"content" gorm:"type:varchar(500)"`                         // 通知内容
+	IsRead           bool             `json:"is_read" gorm:"type:boolean;not null;default:false;index"` // 是否已读
+	ReadAt           *time.Time       `json:"read_at"`                                                  // 阅读时间
+}
@@ -12,6 +12,7 @@ type OperationLog struct {
 	UserName         string    `json:"user_name" gorm:"type:varchar(50);not null"`                  // 用户名
 	OperationType    string    `json:"operation_type" gorm:"type:varchar(50);not null;index"`       // 操作类型
 	OperationDesc    string    `json:"operation_desc" gorm:"type:varchar(255);not null"`            // 操作描述
+	TargetID         string    `json:"target_id" gorm:"type:varchar(64);index"`                     // 操作对象ID，例如管理员操作的用户ID
 	RequestIP        string    `json:"request_ip" gorm:"type:varchar(50)"`                          // 请求IP
 	UserAgent        string    `json:"user_agent" gorm:"type:varchar(500)"`                         // 用户代理
 	RequestData      string    `json:"request_data" gorm:"type:text"`                               // 请求数据
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey 用户注册的WebAuthn通行密钥
+type Passkey struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                          // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`     // 用户
+	Name             string     `json:"name" gorm:"type:varchar(100);not null"`                     // 名称，由用户设置
+	CredentialID     []byte     `json:"-" gorm:"type:bytea;not null;uniqueIndex"`                   // 凭证ID
+	PublicKey        []byte     `json:"-" gorm:"type:bytea;not null"`                               // COSE格式的公钥
+	AttestationType  string     `json:"-" gorm:"type:varchar(32)"`                                  // 证明格式
+	AAGUID           []byte     `json:"-" gorm:"type:bytea"`                                        // 验证器型号
+	Transports       string     `json:"transports" gorm:"type:varchar(100)"`                        // 验证器支持的传输方式，逗号分隔
+	SignCount        int64      `json:"-" gorm:"type:bigint;not null;default:0"`                    // 签名计数器
+	BackupEligible   bool       `json:"backup_eligible" gorm:"type:boolean;not null;default:false"` // 是否可以同步到其它设备
+	BackupState      bool       `json:"backup_state" gorm:"type:boolean;not null;default:false"`    // 是否已同步到其它设备
+	LastUsedAt       *time.Time `json:"last_used_at" gorm:"type:timestamp"`                         // 最近使用时间
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PersonalAccessToken 个人访问令牌，用于脚本和CI调用接口，只保存令牌的哈希
+type PersonalAccessToken struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                      // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户
+	Name             string     `json:"name" gorm:"type:varchar(100);not null"`                 // 名称，由用户设置
+	TokenHash        string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`         // 令牌的SHA-256哈希
+	TokenPrefix      string     `json:"token_prefix" gorm:"type:varchar(16);not null"`          // 令牌的前几位，用于区分令牌
+	Scopes           string     `json:"scopes" gorm:"type:varchar(255);not null"`               // 权限范围，逗号分隔
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"type:timestamp;not null;index"`        // 过期时间
+	LastUsedAt       *time.Time `json:"last_used_at" gorm:"type:timestamp"`                     // 最近使用时间
+	LastUsedIP       string     `json:"last_used_ip" gorm:"type:varchar(64)"`                   // 最近使用的IP
+	RevokedAt        *time.Time `json:"-" gorm:"type:timestamp"`                                // 吊销时间
+}
+
+// IsActive 令牌是否仍然有效
+func (t *PersonalAccessToken) IsActive() bool {
+	return t.RevokedAt == nil && t.ExpiresAt.After(time.Now())
+}
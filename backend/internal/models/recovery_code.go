@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode 两步验证的恢复码，每个恢复码只能使用一次，只保存哈希值
+type RecoveryCode struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                      // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户
+	CodeHash         string     `json:"-" gorm:"type:varchar(64);not null;index"`               // 恢复码的SHA-256哈希
+	UsedAt           *time.Time `json:"used_at" gorm:"type:timestamp"`                          // 使用时间，为空表示未使用
+}
@@ -0,0 +1,10 @@
+package models
+
+// Role 角色，用户通过User.Role关联角色名称，角色拥有的权限决定用户可以执行的操作
+type Role struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	Name             string   `json:"name" gorm:"type:varchar(32);not null;uniqueIndex"`    // 角色名称，例如：admin, user, guest
+	Description      string   `json:"description" gorm:"type:varchar(255)"`                 // 角色说明
+	Permissions      []string `json:"permissions" gorm:"type:text;serializer:json"`         // 权限列表，例如：articles:moderate
+	IsSystem         bool     `json:"is_system" gorm:"type:boolean;not null;default:false"` // 是否为内置角色，内置角色不能删除
+}
@@ -2,8 +2,11 @@ package models
 
 import (
 	"blog-server/internal/code"
+	"blog-server/internal/password"
 	"blog-server/internal/utils"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type User struct {
@@ -11,27 +14,33 @@ type User struct {
 	UserName           string    `json:"user_name" gorm:"type:varchar(50);unique;not null"`                                      // 用户名，唯一
 	Password           string    `json:"-" gorm:"type:varchar(255);;not null"`                                                   // 密码，加密存储
 	Nickname           string    `json:"nickname" gorm:"type:varchar(50);not null;index"`                                              // 昵称，默认为用户名
-	Email              string    `json:"email" gorm:"type:varchar(100);not null"`                                                // 邮箱,
+	Email              string    `json:"email" gorm:"type:varchar(100);not null;uniqueIndex:idx_users_email_lower,expression:LOWER(email),where:deleted_at IS NULL AND email <> ''"` // 邮箱，未删除的账号之间不区分大小写唯一
 	Phone              string    `json:"phone" gorm:"type:varchar(20);not null"`                                                 // 手机号,暂不使用
 	Avatar             string    `json:"avatar" gorm:"type:varchar(255)"`                                                        // 头像，默认为空
+	AvatarVariants     map[string]string `json:"avatar_variants" gorm:"type:text;serializer:json"`                                // 不同尺寸头像的URL，键为边长，例如：64, 128, 256
 	Bio                string    `json:"bio" gorm:"type:varchar(255)"`                                                          // 个人简介
 	Website            string    `json:"website" gorm:"type:varchar(255)"`                                                      // 个人网站
 	Location           string    `json:"location" gorm:"type:varchar(100)"`                                                     // 所在地
 	Birthday           string    `json:"birthday" gorm:"type:varchar(20)"`                                                      // 生日
 	Gender             string    `json:"gender" gorm:"type:varchar(10)"`                                                         // 性别
-	Role               string    `json:"-" gorm:"type:varchar(10);not null;check:role IN ('admin', 'user', 'guest')"`            // 角色，例如：admin, user, guest
+	Role               string    `json:"-" gorm:"type:varchar(32);not null;index"`                                               // 角色名称，对应roles表，例如：admin, user, guest
 	Status             string    `json:"-" gorm:"type:varchar(10);not null;check:status IN ('active', 'inactive', 'suspended')"` // 状态，例如：active, inactive, suspended
 	LastLogin          time.Time `json:"-" gorm:"type:timestamp;not null"`                                                       // 上次登录时间
 	LastLogout         time.Time `json:"-" gorm:"type:timestamp;not null"`                                                       // 上次登出时间
 	LoginCount         int       `json:"-" gorm:"type:int;not null"`                                                             // 登录次数
-	FailedLoginCount   int       `json:"-" gorm:"type:int;not null"`                                                             // 失败登录次数
-	LastFailedLogin    time.Time `json:"-" gorm:"type:timestamp;not null"`                                                       // 上次失败登录时间
-	LastFailedReason   string    `json:"-" gorm:"type:varchar(255)"`                                                             // 上次失败原因
-	IsLocked           bool      `json:"is_locked" gorm:"type:boolean;not null;default:false"`                                   // 是否锁定
-	LockUntil          time.Time `json:"lock_until" gorm:"type:timestamp"`                                                       // 锁定截止时间
 	DeletedBy          string    `json:"-" gorm:"type:varchar(255)"`                                                             // 删除人
 	DeletedReason      string    `json:"-" gorm:"type:varchar(255)"`                                                             // 删除原因
 	IsActive           bool      `json:"-" gorm:"type:boolean;not null;default:false"`                                           // 是否激活
+	MuteMentions       bool      `json:"mute_mentions" gorm:"type:boolean;not null;default:false"`                               // 是否屏蔽@提及通知
+	TwoFactorEnabled   bool       `json:"two_factor_enabled" gorm:"type:boolean;not null;default:false"`                     // 是否开启TOTP两步验证
+	TwoFactorSecret    string     `json:"-" gorm:"type:varchar(64)"`                                                             // TOTP密钥，Base32编码
+	TwoFactorEnabledAt *time.Time `json:"-" gorm:"type:timestamp"`                                                               // 开启两步验证的时间
+	PasswordResetRequired bool    `json:"-" gorm:"type:boolean;not null;default:false"`                                          // 是否需要重置密码后才能登录，由管理员设置
+	DeletionScheduledAt *time.Time `json:"-" gorm:"type:timestamp;index"`                                                       // 申请注销后计划删除账号的时间，为空表示没有申请注销
+	DeletionMode        string     `json:"-" gorm:"type:varchar(20)"`                                                           // 注销时如何处理用户的内容：anonymize, delete
+	InvitedBy           *uuid.UUID `json:"-" gorm:"type:uuid;index"`                                                            // 邀请该用户注册的用户ID，没有使用邀请码时为空
+	Inviter             *User      `json:"-" gorm:"foreignKey:InvitedBy;constraint:OnDelete:SET NULL"`                          // 邀请该用户注册的用户
+	InviteCodeID        *uuid.UUID `json:"-" gorm:"type:uuid"`                                                                  // 注册时使用的邀请码ID
 	DailyPhotographs   []DailyPhotograph `json:"daily_photographs" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`       // 用户的日常照片，一对多关系，级联删除
 }
 
@@ -62,3 +71,8 @@ func (u *User) ComparePassword(password string) bool {
 	// TODO: 实现密码比较逻辑
 	return utils.ComparePassword(password, u.Password)
 }
+
+// PasswordNeedsRehash 密码哈希是旧的bcrypt或强度低于当前配置，需要在下次验证密码后重新计算
+func (u *User) PasswordNeedsRehash() bool {
+	return password.NeedsRehash(u.Password)
+}
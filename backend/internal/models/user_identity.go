@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity 用户绑定的第三方账号，同一个第三方账号只能绑定一个用户
+type UserIdentity struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                                          // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`                     // 用户
+	Provider         string     `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:idx_provider_subject"` // 提供方，例如：github, oidc
+	Subject          string     `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_provider_subject"`       // 第三方账号的唯一ID
+	Email            string     `json:"email" gorm:"type:varchar(100)"`                                             // 第三方账号的邮箱
+	Username         string     `json:"username" gorm:"type:varchar(100)"`                                          // 第三方账号的用户名
+	LastLoginAt      *time.Time `json:"last_login_at" gorm:"type:timestamp"`                                        // 最近一次通过该账号登录的时间
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSession 用户在一台设备上的登录会话，每个会话有独立的刷新令牌
+type UserSession struct {
+	SwaggerGormModel `json:",inline" gorm:"embedded"`
+	UserID           uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`                      // 用户ID
+	User             User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"` // 用户
+	DeviceName       string     `json:"device_name" gorm:"type:varchar(100)"`                   // 设备名称
+	UserAgent        string     `json:"user_agent" gorm:"type:varchar(500)"`                    // 用户代理
+	IP               string     `json:"ip" gorm:"type:varchar(64)"`                             // 最近一次使用的IP
+	RefreshJTI       string     `json:"-" gorm:"type:varchar(64);index"`                        // 当前有效的刷新令牌ID
+	LastSeenAt       time.Time  `json:"last_seen_at" gorm:"type:timestamp"`                     // 最近活跃时间
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"type:timestamp;index"`                 // 过期时间
+	RevokedAt        *time.Time `json:"-" gorm:"type:timestamp"`                                // 吊销时间
+	Current          bool       `json:"current" gorm:"-"`                                       // 是否为当前请求所在的会话，不入库
+}
+
+// IsActive 会话是否仍然有效
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// GitHubProviderName GitHub提供方名称
+const GitHubProviderName = "github"
+
+// githubAPIURL GitHub REST API地址
+const githubAPIURL = "https://api.github.com"
+
+// githubProvider GitHub OAuth App登录
+// GitHub不支持OIDC，账号信息和已验证的邮箱通过REST API读取
+type githubProvider struct {
+	config Config
+	oauth2 *oauth2.Config
+	apiURL string
+	client *http.Client
+}
+
+// NewGitHub 创建GitHub提供方
+func NewGitHub(config Config) (Provider, error) {
+	if config.ClientID == "" || config.ClientSecret == "" {
+		return nil, ErrNotConfigured
+	}
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+	return &githubProvider{
+		config: config,
+		oauth2: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     github.Endpoint,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       scopes,
+		},
+		apiURL: githubAPIURL,
+	}, nil
+}
+
+func (p *githubProvider) Name() string {
+	return GitHubProviderName
+}
+
+func (p *githubProvider) DisplayName() string {
+	return displayName(p.config, "GitHub")
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	ctx = withHTTPClient(ctx, p.client)
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+	client := p.oauth2.Client(ctx, token)
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := p.get(ctx, client, "/user", &user); err != nil {
+		return nil, err
+	}
+	identity := &Identity{
+		Provider:  GitHubProviderName,
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Email:     user.Email,
+		Username:  user.Login,
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+	}
+
+	// /user返回的是公开邮箱，不一定经过验证，优先使用已验证的主邮箱
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.get(ctx, client, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			identity.Email = email.Email
+			identity.EmailVerified = true
+			break
+		}
+	}
+	return identity, nil
+}
+
+// get 请求GitHub API并解析JSON响应
+func (p *githubProvider) get(ctx context.Context, client *http.Client, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth: github %s returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
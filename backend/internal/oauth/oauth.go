@@ -0,0 +1,78 @@
+// Package oauth 实现第三方账号登录（OAuth2授权码模式和OpenID Connect）
+// 只负责生成授权地址、用授权码换取令牌并读取第三方账号信息，state的保存和账号的绑定由调用方完成
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// requestTimeout 请求第三方接口的超时时间
+const requestTimeout = 10 * time.Second
+
+var (
+	// ErrNotConfigured 提供方没有配置客户端ID或密钥
+	ErrNotConfigured = errors.New("oauth: provider is not configured")
+	// ErrNonceMismatch ID令牌中的nonce与授权请求不一致
+	ErrNonceMismatch = errors.New("oauth: id token nonce does not match")
+	// ErrMissingIDToken 令牌响应中没有ID令牌
+	ErrMissingIDToken = errors.New("oauth: token response has no id_token")
+)
+
+// Config 提供方配置
+type Config struct {
+	ClientID     string   // 客户端ID
+	ClientSecret string   // 客户端密钥
+	RedirectURL  string   // 回调地址，必须与在提供方登记的一致
+	Scopes       []string // 申请的权限，为空时使用提供方的默认值
+	DisplayName  string   // 显示名称，为空时使用提供方名称
+	Issuer       string   // OIDC签发者地址，只用于OIDC提供方
+}
+
+// Identity 第三方账号信息
+type Identity struct {
+	Provider      string // 提供方名称
+	Subject       string // 第三方账号的唯一ID
+	Email         string // 邮箱
+	EmailVerified bool   // 邮箱是否已由提供方验证
+	Username      string // 第三方账号的用户名
+	Name          string // 显示名称
+	AvatarURL     string // 头像地址
+}
+
+// Provider 第三方登录提供方
+type Provider interface {
+	// Name 提供方名称，用于路由和账号绑定
+	Name() string
+	// DisplayName 提供方显示名称，用于登录按钮
+	DisplayName() string
+	// AuthCodeURL 生成授权地址，verifier为PKCE的code_verifier，nonce只用于OIDC
+	AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error)
+	// Exchange 用授权码换取令牌并读取第三方账号信息
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error)
+}
+
+// GenerateVerifier 生成PKCE的code_verifier
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// withHTTPClient 为请求设置超时时间，oauth2和oidc都从context中读取HTTP客户端
+func withHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+// displayName 返回配置的显示名称，为空时使用默认值
+func displayName(config Config, fallback string) string {
+	if config.DisplayName != "" {
+		return config.DisplayName
+	}
+	return fallback
+}
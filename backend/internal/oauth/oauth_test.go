@@ -0,0 +1,221 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID     = "client-id"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://blog.example.com/oauth/callback"
+	testCode         = "authorization-code"
+)
+
+// authorize 解析授权地址，返回state和code_challenge
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+	return query
+}
+
+// tokenHandler 模拟令牌接口，只接受与授权请求匹配的授权码和code_verifier
+func tokenHandler(t *testing.T, challenge *string, extra map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse token request: %v", err)
+		}
+		if r.PostForm.Get("code") != testCode || oauth2.S256ChallengeFromVerifier(r.PostForm.Get("code_verifier")) != *challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		response := map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		}
+		for k, v := range extra {
+			response[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
+
+func TestGitHubExchange(t *testing.T) {
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", tokenHandler(t, &challenge, nil))
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id": 42, "login": "octocat", "name": "The Octocat", "email": "public@example.com",
+			"avatar_url": "https://avatars.example.com/42",
+		})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "public@example.com", "primary": false, "verified": false},
+			{"email": "octocat@example.com", "primary": true, "verified": true},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider, err := NewGitHub(Config{ClientID: testClientID, ClientSecret: testClientSecret, RedirectURL: testRedirectURL})
+	if err != nil {
+		t.Fatalf("NewGitHub() error = %v", err)
+	}
+	github := provider.(*githubProvider)
+	github.oauth2.Endpoint = oauth2.Endpoint{
+		AuthURL:  server.URL + "/login/oauth/authorize",
+		TokenURL: server.URL + "/login/oauth/access_token",
+	}
+	github.apiURL = server.URL
+
+	verifier := GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", verifier, "")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	query := authorize(t, authURL)
+	if query.Get("state") != "state-1" || query.Get("redirect_uri") != testRedirectURL {
+		t.Errorf("auth url = %s, want state and redirect_uri", authURL)
+	}
+	challenge = query.Get("code_challenge")
+
+	// code_verifier不匹配时不能换取令牌
+	if _, err := provider.Exchange(context.Background(), testCode, GenerateVerifier(), ""); err == nil {
+		t.Errorf("Exchange() accepted a wrong code_verifier")
+	}
+
+	identity, err := provider.Exchange(context.Background(), testCode, verifier, "")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{
+		Provider: "github", Subject: "42", Email: "octocat@example.com", EmailVerified: true,
+		Username: "octocat", Name: "The Octocat", AvatarURL: "https://avatars.example.com/42",
+	}
+	if *identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &oidctest.Server{
+		PublicKeys: []oidctest.PublicKey{{PublicKey: key.Public(), KeyID: "key-1", Algorithm: oidc.RS256}},
+	}
+
+	var challenge, idToken string
+	extra := map[string]interface{}{}
+	mux := http.NewServeMux()
+	mux.Handle("/", issuer)
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		extra["id_token"] = idToken
+		tokenHandler(t, &challenge, extra)(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	issuer.SetIssuer(server.URL)
+
+	signIDToken := func(nonce string, emailVerified bool) string {
+		claims, _ := json.Marshal(map[string]interface{}{
+			"iss": server.URL, "aud": testClientID, "sub": "user-1", "nonce": nonce,
+			"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+			"email": "alice@example.com", "email_verified": emailVerified,
+			"preferred_username": "alice", "name": "Alice",
+		})
+		return oidctest.SignIDToken(key, "key-1", oidc.RS256, string(claims))
+	}
+
+	provider, err := NewOIDC("oidc", Config{ClientID: testClientID, ClientSecret: testClientSecret, RedirectURL: testRedirectURL, Issuer: server.URL})
+	if err != nil {
+		t.Fatalf("NewOIDC() error = %v", err)
+	}
+	verifier := GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	query := authorize(t, authURL)
+	if query.Get("nonce") != "nonce-1" || query.Get("scope") != "openid profile email" {
+		t.Errorf("auth url = %s, want nonce and openid scope", authURL)
+	}
+	challenge = query.Get("code_challenge")
+
+	idToken = signIDToken("nonce-1", true)
+	identity, err := provider.Exchange(context.Background(), testCode, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{
+		Provider: "oidc", Subject: "user-1", Email: "alice@example.com", EmailVerified: true,
+		Username: "alice", Name: "Alice",
+	}
+	if *identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+	}
+
+	// ID令牌必须对应本次授权请求
+	idToken = signIDToken("nonce-2", true)
+	if _, err := provider.Exchange(context.Background(), testCode, verifier, "nonce-1"); !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("Exchange() error = %v, want ErrNonceMismatch", err)
+	}
+
+	// 签发者没有验证的邮箱不能用于绑定已有账号
+	idToken = signIDToken("nonce-1", false)
+	identity, err = provider.Exchange(context.Background(), testCode, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if identity.EmailVerified {
+		t.Errorf("EmailVerified = true, want false")
+	}
+
+	// 其他签发者签名的ID令牌
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	claims := `{"iss":"` + server.URL + `","aud":"` + testClientID + `","sub":"user-1","nonce":"nonce-1","exp":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `}`
+	idToken = oidctest.SignIDToken(other, "key-1", oidc.RS256, claims)
+	if _, err := provider.Exchange(context.Background(), testCode, verifier, "nonce-1"); err == nil {
+		t.Errorf("Exchange() accepted an id token with an invalid signature")
+	}
+}
+
+func TestNewProviderRequiresConfig(t *testing.T) {
+	if _, err := NewGitHub(Config{ClientID: testClientID}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewGitHub() error = %v, want ErrNotConfigured", err)
+	}
+	if _, err := NewOIDC("oidc", Config{ClientID: testClientID}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("NewOIDC() error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcProvider 通用的OpenID Connect登录
+// 第一次使用时才请求签发者的发现文档，避免签发者不可用时影响服务启动
+type oidcProvider struct {
+	name   string
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+	oauth2   *oauth2.Config
+}
+
+// NewOIDC 创建OIDC提供方，name用于区分不同的签发者
+func NewOIDC(name string, config Config) (Provider, error) {
+	if config.ClientID == "" || config.Issuer == "" {
+		return nil, ErrNotConfigured
+	}
+	return &oidcProvider{name: name, config: config}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) DisplayName() string {
+	return displayName(p.config, p.name)
+}
+
+// discover 读取签发者的发现文档，成功后缓存，失败时下次重试
+func (p *oidcProvider) discover(ctx context.Context) (*oidc.Provider, *oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider != nil {
+		return p.provider, p.oauth2, nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, p.httpClient()), p.config.Issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	p.provider = provider
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       scopes,
+	}
+	return p.provider, p.oauth2, nil
+}
+
+func (p *oidcProvider) httpClient() *http.Client {
+	if p.client != nil {
+		return p.client
+	}
+	return &http.Client{Timeout: requestTimeout}
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	_, config, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce)), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	provider, config, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withHTTPClient(ctx, p.client)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     *bool  `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+		Picture           string `json:"picture"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Provider: p.name,
+		Subject:  idToken.Subject,
+		Email:    claims.Email,
+		// 没有email_verified声明时视为未验证
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+		Username:      claims.PreferredUsername,
+		Name:          claims.Name,
+		AvatarURL:     claims.Picture,
+	}, nil
+}
@@ -141,6 +141,26 @@ func DeleteFileFromBucketMinio(bucketName string, fileName string) error {
 	return nil
 }
 
+// GetFileMinio 读取桶中的文件
+// bucketName: 桶名
+// objectName: 对象名
+// 返回值: 文件内容、文件大小和error，调用方负责关闭文件
+func GetFileMinio(bucketName, objectName string) (io.ReadCloser, int64, error) {
+	ctx := context.Background()
+	object, err := minioClient.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		logger.Logger.Errorf("Minio Get File Error: %v", err)
+		return nil, 0, err
+	}
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		logger.Logger.Errorf("Minio Stat File Error: %v", err)
+		return nil, 0, err
+	}
+	return object, info.Size, nil
+}
+
 // ClearDeleteBucketMinio 清空桶并删除桶
 // bucketName: 桶名
 // 返回值: error
@@ -184,6 +204,20 @@ func GeneratePublicURLMinio(bucketName, objectName string) string {
 	return "/" + bucketName + "/" + objectName
 }
 
+// ParsePublicURLMinio 从GeneratePublicURLMinio生成的URL中解析桶名和对象名
+// url: 公开访问的URL
+// 返回值: 桶名、对象名，URL不是本系统生成的时ok为false
+func ParsePublicURLMinio(url string) (bucketName, objectName string, ok bool) {
+	if !strings.HasPrefix(url, "/") {
+		return "", "", false
+	}
+	bucketName, objectName, ok = strings.Cut(strings.TrimPrefix(url, "/"), "/")
+	if !ok || bucketName == "" || objectName == "" {
+		return "", "", false
+	}
+	return bucketName, objectName, true
+}
+
 // SetBucketPublicPolicy 设置桶的公共访问策略
 func SetBucketPublicPolicy(bucketName string) error {
 	policy := `{
@@ -269,3 +269,38 @@ func TestGeneratePublicURLMinio(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePublicURLMinio(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		wantBucketName string
+		wantObjectName string
+		wantOK         bool
+	}{
+		{
+			name:           "解析本系统生成的 URL",
+			url:            GeneratePublicURLMinio("moity-blog", "avatars/a.png"),
+			wantBucketName: "moity-blog",
+			wantObjectName: "avatars/a.png",
+			wantOK:         true,
+		},
+		{
+			name: "外部 URL",
+			url:  "https://example.com/a.png",
+		},
+		{
+			name: "缺少对象名",
+			url:  "/moity-blog/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucketName, objectName, ok := ParsePublicURLMinio(tt.url)
+			if bucketName != tt.wantBucketName || objectName != tt.wantObjectName || ok != tt.wantOK {
+				t.Errorf("ParsePublicURLMinio(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.url, bucketName, objectName, ok, tt.wantBucketName, tt.wantObjectName, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package passkey 封装WebAuthn的注册和认证仪式
+// 只负责生成选项和校验客户端的响应，挑战的保存和凭证的持久化由调用方完成
+package passkey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// ceremonyTimeout 注册和认证仪式的超时时间
+const ceremonyTimeout = 5 * time.Minute
+
+var (
+	// ErrCloneDetected 签名计数器没有增长，凭证可能被克隆
+	ErrCloneDetected = errors.New("passkey: sign counter did not increase, the authenticator may be cloned")
+	// ErrNoCredentials 用户没有注册通行密钥
+	ErrNoCredentials = errors.New("passkey: user has no credentials")
+)
+
+// Config 依赖方（Relying Party）配置
+type Config struct {
+	RPID    string   // 依赖方ID，通常为站点的域名
+	RPName  string   // 依赖方名称，在验证器中显示
+	Origins []string // 允许的来源，网页为站点地址，iOS应用通过关联域名使用站点地址
+}
+
+// User 参与WebAuthn仪式的用户
+type User struct {
+	ID          []byte                // 用户句柄，不能包含个人信息
+	Name        string                // 账号名称
+	DisplayName string                // 显示名称
+	Credentials []webauthn.Credential // 已注册的凭证
+}
+
+// WebAuthnID 实现webauthn.User接口
+func (u *User) WebAuthnID() []byte {
+	return u.ID
+}
+
+// WebAuthnName 实现webauthn.User接口
+func (u *User) WebAuthnName() string {
+	return u.Name
+}
+
+// WebAuthnDisplayName 实现webauthn.User接口
+func (u *User) WebAuthnDisplayName() string {
+	if u.DisplayName == "" {
+		return u.Name
+	}
+	return u.DisplayName
+}
+
+// WebAuthnCredentials 实现webauthn.User接口
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+// RelyingParty 依赖方，执行注册和认证仪式
+type RelyingParty struct {
+	webAuthn *webauthn.WebAuthn
+}
+
+// New 创建依赖方
+func New(config Config) (*RelyingParty, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPName,
+		RPOrigins:     config.Origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    ceremonyTimeout,
+				TimeoutUVD: ceremonyTimeout,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce:    true,
+				Timeout:    ceremonyTimeout,
+				TimeoutUVD: ceremonyTimeout,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RelyingParty{webAuthn: webAuthn}, nil
+}
+
+// BeginRegistration 开始注册仪式，返回发给客户端的选项和需要保存的会话数据
+// 要求创建可发现凭证以支持无用户名登录，已注册的凭证会被排除
+func (rp *RelyingParty) BeginRegistration(user *User) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	return rp.webAuthn.BeginRegistration(user,
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationPreferred,
+		}),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(user.Credentials).CredentialDescriptors()),
+		webauthn.WithConveyancePreference(protocol.PreferNoAttestation),
+	)
+}
+
+// FinishRegistration 校验客户端返回的注册响应，返回新的凭证
+func (rp *RelyingParty) FinishRegistration(user *User, session webauthn.SessionData, response []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, err
+	}
+	return rp.webAuthn.CreateCredential(user, session, parsed)
+}
+
+// BeginLogin 为已知用户开始认证仪式，用于两步验证，只允许用户已注册的凭证
+func (rp *RelyingParty) BeginLogin(user *User) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	if len(user.Credentials) == 0 {
+		return nil, nil, ErrNoCredentials
+	}
+	return rp.webAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+}
+
+// FinishLogin 校验已知用户的认证响应，返回更新了签名计数器的凭证
+func (rp *RelyingParty) FinishLogin(user *User, session webauthn.SessionData, response []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := rp.webAuthn.ValidateLogin(user, session, parsed)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, ErrCloneDetected
+	}
+	return credential, nil
+}
+
+// BeginDiscoverableLogin 开始无用户名的认证仪式，用于通行密钥直接登录
+// 通行密钥代替了密码，必须经过用户验证（生物识别或PIN）
+func (rp *RelyingParty) BeginDiscoverableLogin() (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	return rp.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+}
+
+// FinishDiscoverableLogin 校验无用户名的认证响应
+// lookup根据凭证ID和用户句柄查找用户，返回找到的用户和更新了签名计数器的凭证
+func (rp *RelyingParty) FinishDiscoverableLogin(lookup func(credentialID, userHandle []byte) (*User, error), session webauthn.SessionData, response []byte) (*User, *webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, nil, err
+	}
+	var user *User
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		found, err := lookup(rawID, userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user = found
+		return found, nil
+	}
+	_, credential, err := rp.webAuthn.ValidatePasskeyLogin(handler, session, parsed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, nil, ErrCloneDetected
+	}
+	return user, credential, nil
+}
@@ -0,0 +1,290 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	testRPID   = "blog.example.com"
+	testOrigin = "https://blog.example.com"
+)
+
+// 验证器数据中的标志位
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// softAuthenticator 软件实现的验证器，只保存一个ES256凭证
+type softAuthenticator struct {
+	origin       string
+	credentialID []byte
+	userHandle   []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T, origin string) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("generate credential id: %v", err)
+	}
+	return &softAuthenticator{origin: origin, credentialID: credentialID, key: key}
+}
+
+// create 模拟navigator.credentials.create，返回JSON格式的注册响应
+func (a *softAuthenticator) create(t *testing.T, options *protocol.CredentialCreation) []byte {
+	t.Helper()
+	a.userHandle = []byte(options.Response.User.ID.(protocol.URLEncodedBase64))
+	clientData := a.clientData(t, protocol.CreateCeremony, options.Response.Challenge)
+
+	coseKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("marshal cose key: %v", err)
+	}
+	authData := a.authenticatorData(options.Response.RelyingParty.ID, flagUserPresent|flagUserVerified|flagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, coseKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation object: %v", err)
+	}
+
+	return a.marshal(t, map[string]interface{}{
+		"clientDataJSON":    encode(clientData),
+		"attestationObject": encode(attestationObject),
+	})
+}
+
+// get 模拟navigator.credentials.get，返回JSON格式的认证响应
+func (a *softAuthenticator) get(t *testing.T, options *protocol.CredentialAssertion) []byte {
+	t.Helper()
+	a.signCount++
+	clientData := a.clientData(t, protocol.AssertCeremony, options.Response.Challenge)
+	authData := a.authenticatorData(options.Response.RelyingPartyID, flagUserPresent|flagUserVerified)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+
+	return a.marshal(t, map[string]interface{}{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	clientData, err := json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": challenge.String(),
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+	return clientData
+}
+
+func (a *softAuthenticator) authenticatorData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, flags)
+	return binary.BigEndian.AppendUint32(authData, a.signCount)
+}
+
+func (a *softAuthenticator) marshal(t *testing.T, response map[string]interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+	return data
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newTestRelyingParty(t *testing.T) *RelyingParty {
+	t.Helper()
+	rp, err := New(Config{RPID: testRPID, RPName: "Test Blog", Origins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return rp
+}
+
+// register 用软件验证器完成注册仪式
+func register(t *testing.T, rp *RelyingParty, user *User, authenticator *softAuthenticator) *webauthn.Credential {
+	t.Helper()
+	options, session, err := rp.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	credential, err := rp.FinishRegistration(user, *session, authenticator.create(t, options))
+	if err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+	return credential
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := &User{ID: []byte("user-1"), Name: "alice@example.com", DisplayName: "alice"}
+	authenticator := newSoftAuthenticator(t, testOrigin)
+
+	credential := register(t, rp, user, authenticator)
+	if string(credential.ID) != string(authenticator.credentialID) {
+		t.Fatalf("credential id = %x, want %x", credential.ID, authenticator.credentialID)
+	}
+	user.Credentials = append(user.Credentials, *credential)
+
+	// 已注册的凭证不能重复注册
+	options, _, err := rp.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	if len(options.Response.CredentialExcludeList) != 1 {
+		t.Errorf("exclude list = %d credentials, want 1", len(options.Response.CredentialExcludeList))
+	}
+
+	// 作为第二步验证
+	assertion, session, err := rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	if len(assertion.Response.AllowedCredentials) != 1 {
+		t.Errorf("allowed credentials = %d, want 1", len(assertion.Response.AllowedCredentials))
+	}
+	updated, err := rp.FinishLogin(user, *session, authenticator.get(t, assertion))
+	if err != nil {
+		t.Fatalf("FinishLogin() error = %v", err)
+	}
+	if updated.Authenticator.SignCount != 1 {
+		t.Errorf("sign count = %d, want 1", updated.Authenticator.SignCount)
+	}
+	user.Credentials[0] = *updated
+
+	// 作为主要登录方式
+	assertion, session, err = rp.BeginDiscoverableLogin()
+	if err != nil {
+		t.Fatalf("BeginDiscoverableLogin() error = %v", err)
+	}
+	lookup := func(credentialID, userHandle []byte) (*User, error) {
+		if string(userHandle) != string(user.ID) {
+			return nil, errors.New("user not found")
+		}
+		return user, nil
+	}
+	found, updated, err := rp.FinishDiscoverableLogin(lookup, *session, authenticator.get(t, assertion))
+	if err != nil {
+		t.Fatalf("FinishDiscoverableLogin() error = %v", err)
+	}
+	if found != user || updated.Authenticator.SignCount != 2 {
+		t.Errorf("FinishDiscoverableLogin() = %v, sign count %d, want user-1 and 2", found, updated.Authenticator.SignCount)
+	}
+}
+
+func TestLoginRejectsClonedAuthenticator(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := &User{ID: []byte("user-1"), Name: "alice@example.com"}
+	authenticator := newSoftAuthenticator(t, testOrigin)
+	user.Credentials = append(user.Credentials, *register(t, rp, user, authenticator))
+
+	assertion, session, err := rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	updated, err := rp.FinishLogin(user, *session, authenticator.get(t, assertion))
+	if err != nil {
+		t.Fatalf("FinishLogin() error = %v", err)
+	}
+	user.Credentials[0] = *updated
+
+	// 签名计数器回退，说明存在另一个拷贝
+	authenticator.signCount = 0
+	assertion, session, err = rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	if _, err := rp.FinishLogin(user, *session, authenticator.get(t, assertion)); !errors.Is(err, ErrCloneDetected) {
+		t.Errorf("FinishLogin() error = %v, want ErrCloneDetected", err)
+	}
+}
+
+func TestCeremonyRejectsInvalidResponses(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := &User{ID: []byte("user-1"), Name: "alice@example.com"}
+
+	// 来源不在允许列表中
+	phishing := newSoftAuthenticator(t, "https://blog.example.com.evil.test")
+	options, session, err := rp.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	if _, err := rp.FinishRegistration(user, *session, phishing.create(t, options)); err == nil {
+		t.Errorf("FinishRegistration() accepted a response from another origin")
+	}
+
+	// 响应必须对应会话中的挑战
+	authenticator := newSoftAuthenticator(t, testOrigin)
+	user.Credentials = append(user.Credentials, *register(t, rp, user, authenticator))
+	assertion, _, err := rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	_, otherSession, err := rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	if _, err := rp.FinishLogin(user, *otherSession, authenticator.get(t, assertion)); err == nil {
+		t.Errorf("FinishLogin() accepted a response to another challenge")
+	}
+
+	// 没有注册凭证的用户不能开始第二步验证
+	if _, _, err := rp.BeginLogin(&User{ID: []byte("user-2"), Name: "bob@example.com"}); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("BeginLogin() error = %v, want ErrNoCredentials", err)
+	}
+}
@@ -0,0 +1,222 @@
+// Package password 计算和校验密码哈希
+//
+// 哈希使用自描述的格式，校验时根据哈希本身识别算法和参数，不依赖当前配置：
+//
+//	argon2id: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>（PHC字符串格式，salt和hash为不带填充的base64）
+//	bcrypt:   $2a$10$<salt+hash>
+//
+// 新的密码使用配置的算法和参数，NeedsRehash可以判断已有的哈希是否需要升级。
+package password
+
+import (
+	"blog-server/internal/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 支持的哈希算法
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// argon2id的默认参数，参考OWASP的推荐值
+const (
+	defaultArgon2Memory      = 19 * 1024
+	defaultArgon2Iterations  = 2
+	defaultArgon2Parallelism = 1
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+	// maxArgon2Memory 校验时允许的最大内存（KiB），防止哈希被篡改后占用过多内存
+	maxArgon2Memory = 1024 * 1024
+)
+
+// ErrInvalidHash 无法识别的哈希
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// Params 计算新哈希时使用的算法和参数
+type Params struct {
+	Algorithm         string // 哈希算法
+	Argon2Memory      uint32 // argon2id使用的内存（KiB）
+	Argon2Iterations  uint32 // argon2id的迭代次数
+	Argon2Parallelism uint8  // argon2id的并行度
+	BcryptCost        int    // bcrypt的cost
+}
+
+// DefaultParams 默认使用argon2id
+func DefaultParams() Params {
+	return Params{
+		Algorithm:         AlgorithmArgon2id,
+		Argon2Memory:      defaultArgon2Memory,
+		Argon2Iterations:  defaultArgon2Iterations,
+		Argon2Parallelism: defaultArgon2Parallelism,
+		BcryptCost:        bcrypt.DefaultCost,
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultParams()
+)
+
+// Init 根据配置设置计算新哈希时使用的算法和参数，为0的参数使用默认值
+func Init(conf config.PasswordHashConfig) error {
+	params := DefaultParams()
+	if conf.Algorithm != "" {
+		params.Algorithm = strings.ToLower(strings.TrimSpace(conf.Algorithm))
+	}
+	if conf.Argon2Memory > 0 {
+		params.Argon2Memory = conf.Argon2Memory
+	}
+	if conf.Argon2Iterations > 0 {
+		params.Argon2Iterations = conf.Argon2Iterations
+	}
+	if conf.Argon2Parallelism > 0 {
+		params.Argon2Parallelism = conf.Argon2Parallelism
+	}
+	if conf.BcryptCost > 0 {
+		params.BcryptCost = conf.BcryptCost
+	}
+	return SetParams(params)
+}
+
+// SetParams 替换计算新哈希时使用的算法和参数
+func SetParams(params Params) error {
+	switch params.Algorithm {
+	case AlgorithmArgon2id:
+		if params.Argon2Memory < 8*uint32(params.Argon2Parallelism) || params.Argon2Memory > maxArgon2Memory ||
+			params.Argon2Iterations == 0 || params.Argon2Parallelism == 0 {
+			return fmt.Errorf("invalid argon2id params: m=%d, t=%d, p=%d",
+				params.Argon2Memory, params.Argon2Iterations, params.Argon2Parallelism)
+		}
+	case AlgorithmBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("invalid bcrypt cost: %d", params.BcryptCost)
+		}
+	default:
+		return fmt.Errorf("unsupported password hash algorithm: %q", params.Algorithm)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = params
+	return nil
+}
+
+// currentParams 当前计算新哈希时使用的算法和参数
+func currentParams() Params {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Hash 使用当前配置的算法计算密码的哈希
+func Hash(password string) (string, error) {
+	params := currentParams()
+	if params.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), params.BcryptCost)
+		return string(hash), err
+	}
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, argon2KeyLength)
+	return encodeArgon2id(argon2idHash{
+		Memory:      params.Argon2Memory,
+		Iterations:  params.Argon2Iterations,
+		Parallelism: params.Argon2Parallelism,
+		Salt:        salt,
+		Hash:        hash,
+	}), nil
+}
+
+// Verify 校验密码与哈希是否匹配，根据哈希识别算法
+func Verify(password, encoded string) bool {
+	if isBcrypt(encoded) {
+		return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+	}
+	parsed, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false
+	}
+	hash := argon2.IDKey([]byte(password), parsed.Salt, parsed.Iterations, parsed.Memory, parsed.Parallelism, uint32(len(parsed.Hash)))
+	return subtle.ConstantTimeCompare(hash, parsed.Hash) == 1
+}
+
+// NeedsRehash 哈希是旧的bcrypt或强度低于当前配置时需要重新计算，无法识别的哈希不需要
+// 只会升级：argon2id的哈希不会改为bcrypt，参数高于当前配置的哈希也保持不变
+func NeedsRehash(encoded string) bool {
+	params := currentParams()
+	if isBcrypt(encoded) {
+		if params.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err == nil && cost < params.BcryptCost
+	}
+	parsed, err := decodeArgon2id(encoded)
+	if err != nil || params.Algorithm != AlgorithmArgon2id {
+		return false
+	}
+	return parsed.Memory < params.Argon2Memory ||
+		parsed.Iterations < params.Argon2Iterations ||
+		parsed.Parallelism < params.Argon2Parallelism ||
+		len(parsed.Salt) < argon2SaltLength ||
+		len(parsed.Hash) < argon2KeyLength
+}
+
+// isBcrypt 是否为bcrypt的哈希，bcrypt使用$2a$、$2b$、$2y$前缀
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// argon2idHash 解析后的argon2id哈希
+type argon2idHash struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	Salt        []byte
+	Hash        []byte
+}
+
+// encodeArgon2id 编码为PHC字符串格式
+func encodeArgon2id(h argon2idHash) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgorithmArgon2id, argon2.Version,
+		h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(h.Salt), base64.RawStdEncoding.EncodeToString(h.Hash))
+}
+
+// decodeArgon2id 解析PHC字符串格式的argon2id哈希，只支持0x13版本
+func decodeArgon2id(encoded string) (argon2idHash, error) {
+	var h argon2idHash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != AlgorithmArgon2id {
+		return h, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return h, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.Memory, &h.Iterations, &h.Parallelism); err != nil {
+		return h, ErrInvalidHash
+	}
+	if h.Memory > maxArgon2Memory || h.Iterations == 0 || h.Parallelism == 0 {
+		return h, ErrInvalidHash
+	}
+	var err error
+	if h.Salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(h.Salt) == 0 {
+		return h, ErrInvalidHash
+	}
+	if h.Hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.Hash) < 16 {
+		return h, ErrInvalidHash
+	}
+	return h, nil
+}
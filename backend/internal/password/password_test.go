@@ -0,0 +1,216 @@
+package password
+
+import (
+	"blog-server/internal/config"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams 测试使用较小的参数，减少运行时间
+func testParams() Params {
+	return Params{
+		Algorithm:         AlgorithmArgon2id,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        bcrypt.MinCost,
+	}
+}
+
+func setParams(t *testing.T, params Params) {
+	t.Helper()
+	previous := currentParams()
+	t.Cleanup(func() { current = previous })
+	if err := SetParams(params); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHashArgon2id(t *testing.T) {
+	setParams(t, testParams())
+	hash, err := Hash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected hash: %q", hash)
+	}
+	if !Verify("123456", hash) {
+		t.Error("password should match")
+	}
+	if Verify("1234567", hash) {
+		t.Error("wrong password should not match")
+	}
+	if NeedsRehash(hash) {
+		t.Error("hash with current params should not need rehash")
+	}
+	other, err := Hash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("hashes should use different salts")
+	}
+}
+
+func TestVerifyKnownArgon2id(t *testing.T) {
+	// 参考实现 argon2 -id -t 2 -m 16 -p 1 生成的哈希
+	hash := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	if !Verify("password", hash) {
+		t.Error("password should match")
+	}
+	if Verify("Password", hash) {
+		t.Error("wrong password should not match")
+	}
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	hash := "$2a$10$fohs970e09Cb/crDk2J0t.oT9OdtQtCmVIWf9okuUg/nJ6UxV7i1i"
+	if !Verify("123456", hash) {
+		t.Error("password should match")
+	}
+	if Verify("1234567", hash) {
+		t.Error("wrong password should not match")
+	}
+}
+
+func TestHashBcrypt(t *testing.T) {
+	params := testParams()
+	params.Algorithm = AlgorithmBcrypt
+	setParams(t, params)
+	hash, err := Hash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$2a$04$") {
+		t.Fatalf("unexpected hash: %q", hash)
+	}
+	if !Verify("123456", hash) {
+		t.Error("password should match")
+	}
+	if NeedsRehash(hash) {
+		t.Error("hash with current params should not need rehash")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	setParams(t, testParams())
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := Hash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 使用argon2id时，bcrypt的哈希需要升级
+	if !NeedsRehash(string(bcryptHash)) {
+		t.Error("bcrypt hash should need rehash")
+	}
+
+	// 参数变化后，旧参数的哈希需要重新计算
+	stronger := testParams()
+	stronger.Argon2Iterations = 2
+	setParams(t, stronger)
+	if !NeedsRehash(argon2Hash) {
+		t.Error("hash with old params should need rehash")
+	}
+
+	// 切换回bcrypt时，cost更低的哈希需要重新计算，argon2id的哈希不会降级
+	bcryptParams := testParams()
+	bcryptParams.Algorithm = AlgorithmBcrypt
+	bcryptParams.BcryptCost = 5
+	setParams(t, bcryptParams)
+	if !NeedsRehash(string(bcryptHash)) {
+		t.Error("bcrypt hash with old cost should need rehash")
+	}
+	if NeedsRehash(argon2Hash) {
+		t.Error("argon2id hash should be kept when using bcrypt")
+	}
+
+	if NeedsRehash("not a hash") {
+		t.Error("invalid hash should not need rehash")
+	}
+}
+
+func TestNeedsRehashKeepsStrongerHash(t *testing.T) {
+	stronger := testParams()
+	stronger.Argon2Memory = 128
+	stronger.Argon2Iterations = 2
+	stronger.Argon2Parallelism = 2
+	stronger.BcryptCost = 6
+	setParams(t, stronger)
+	argon2Hash, err := Hash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("123456"), stronger.BcryptCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 配置的参数降低后，参数更高的哈希保持不变
+	setParams(t, testParams())
+	if NeedsRehash(argon2Hash) {
+		t.Error("argon2id hash with stronger params should be kept")
+	}
+	weaker := testParams()
+	weaker.Algorithm = AlgorithmBcrypt
+	setParams(t, weaker)
+	if NeedsRehash(string(bcryptHash)) {
+		t.Error("bcrypt hash with higher cost should be kept")
+	}
+
+	// 只有部分参数更高时，更低的参数仍然需要升级
+	mixed := stronger
+	mixed.Argon2Memory = 64
+	mixed.Argon2Iterations = 3
+	setParams(t, mixed)
+	if !NeedsRehash(argon2Hash) {
+		t.Error("argon2id hash with lower iterations should need rehash")
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	tests := []string{
+		"",
+		"123456",
+		"$argon2i$v=19$m=64,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=1,p=1$$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$!!!",
+		"$2a$10$kjgyHIt1rB.",
+	}
+	for _, hash := range tests {
+		if Verify("123456", hash) {
+			t.Errorf("Verify with %q should fail", hash)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	setParams(t, testParams())
+	if err := Init(config.PasswordHashConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentParams(); got != DefaultParams() {
+		t.Errorf("empty config should use default params, got %+v", got)
+	}
+	if err := Init(config.PasswordHashConfig{Algorithm: "BCrypt", BcryptCost: 12}); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentParams(); got.Algorithm != AlgorithmBcrypt || got.BcryptCost != 12 {
+		t.Errorf("unexpected params: %+v", got)
+	}
+	if err := Init(config.PasswordHashConfig{Algorithm: "md5"}); err == nil {
+		t.Error("unsupported algorithm should fail")
+	}
+	if err := Init(config.PasswordHashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 40}); err == nil {
+		t.Error("invalid bcrypt cost should fail")
+	}
+}
@@ -0,0 +1,142 @@
+package pubsub
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/redis"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 订阅者缓冲区大小，缓冲区满时丢弃消息，避免慢客户端阻塞分发
+const subscriberBufferSize = 32
+
+var (
+	hub     *Hub
+	hubOnce sync.Once
+)
+
+// Subscriber 某个主题的本地订阅者
+type Subscriber struct {
+	topic string
+	C     chan []byte // 收到的消息
+}
+
+// Hub 基于Redis发布订阅的消息分发中心
+// 所有实例都订阅同一个频道模式，收到消息后分发给本实例中订阅了对应主题的客户端，
+// 因此无论客户端连接在哪个实例上都能收到消息
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[*Subscriber]struct{}
+	redisPubSub *goredis.PubSub
+	cancel      context.CancelFunc
+}
+
+// Init 初始化消息分发中心并开始监听Redis频道，需要在Redis初始化之后调用
+func Init() error {
+	var initErr error
+	hubOnce.Do(func() {
+		redisClient := redis.GetRedisClient()
+		if redisClient == nil {
+			initErr = fmt.Errorf("redis client is not initialized")
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		redisPubSub := redisClient.PSubscribe(ctx, channelName("*"))
+		// 等待订阅确认，确保Redis可用
+		if _, err := redisPubSub.Receive(ctx); err != nil {
+			cancel()
+			initErr = fmt.Errorf("subscribe redis channel failed: %v", err)
+			return
+		}
+		hub = &Hub{
+			subscribers: make(map[string]map[*Subscriber]struct{}),
+			redisPubSub: redisPubSub,
+			cancel:      cancel,
+		}
+		go hub.run()
+	})
+	return initErr
+}
+
+// Close 停止监听Redis频道
+func Close() error {
+	if hub == nil {
+		return nil
+	}
+	hub.cancel()
+	return hub.redisPubSub.Close()
+}
+
+// Publish 向主题发布消息，消息经Redis转发给所有实例
+func Publish(ctx context.Context, topic string, payload []byte) error {
+	redisClient := redis.GetRedisClient()
+	if redisClient == nil {
+		return fmt.Errorf("redis client is not initialized")
+	}
+	return redisClient.Publish(ctx, channelName(topic), payload).Err()
+}
+
+// Subscribe 订阅主题，使用完毕后需要调用Unsubscribe
+func Subscribe(topic string) (*Subscriber, error) {
+	if hub == nil {
+		return nil, fmt.Errorf("pubsub hub is not initialized")
+	}
+	subscriber := &Subscriber{
+		topic: topic,
+		C:     make(chan []byte, subscriberBufferSize),
+	}
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	if hub.subscribers[topic] == nil {
+		hub.subscribers[topic] = make(map[*Subscriber]struct{})
+	}
+	hub.subscribers[topic][subscriber] = struct{}{}
+	return subscriber, nil
+}
+
+// Unsubscribe 取消订阅
+func Unsubscribe(subscriber *Subscriber) {
+	if hub == nil || subscriber == nil {
+		return
+	}
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	if subscribers, ok := hub.subscribers[subscriber.topic]; ok {
+		delete(subscribers, subscriber)
+		if len(subscribers) == 0 {
+			delete(hub.subscribers, subscriber.topic)
+		}
+	}
+}
+
+// run 接收Redis消息并分发给本地订阅者
+func (h *Hub) run() {
+	prefix := channelName("")
+	for message := range h.redisPubSub.Channel() {
+		topic := strings.TrimPrefix(message.Channel, prefix)
+		h.dispatch(topic, []byte(message.Payload))
+	}
+}
+
+// dispatch 将消息分发给本地订阅了该主题的客户端
+func (h *Hub) dispatch(topic string, payload []byte) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for subscriber := range h.subscribers[topic] {
+		select {
+		case subscriber.C <- payload:
+		default:
+			logger.Logger.Warnf("pubsub subscriber buffer is full, drop message of topic %s", topic)
+		}
+	}
+}
+
+// channelName 返回主题对应的Redis频道名
+func channelName(topic string) string {
+	return config.Conf.Redis.KeyPrefix + ":pubsub:" + topic
+}
@@ -0,0 +1,104 @@
+package pubsub
+
+import (
+	"blog-server/internal/logger"
+	"testing"
+)
+
+// setTestHub 使用不连接Redis的分发中心，测试结束后恢复
+func setTestHub(t *testing.T) {
+	t.Helper()
+	if err := logger.InitLogger("../../logs"); err != nil {
+		t.Fatal(err)
+	}
+	previous := hub
+	hub = &Hub{subscribers: make(map[string]map[*Subscriber]struct{})}
+	t.Cleanup(func() { hub = previous })
+}
+
+// receive 非阻塞地读取订阅者收到的消息
+func receive(subscriber *Subscriber) (string, bool) {
+	select {
+	case payload := <-subscriber.C:
+		return string(payload), true
+	default:
+		return "", false
+	}
+}
+
+func TestSubscribeWithoutHub(t *testing.T) {
+	previous := hub
+	hub = nil
+	defer func() { hub = previous }()
+
+	if _, err := Subscribe("topic"); err == nil {
+		t.Error("Subscribe() without hub should fail")
+	}
+	// 没有初始化时取消订阅不做任何操作
+	Unsubscribe(&Subscriber{topic: "topic"})
+	Unsubscribe(nil)
+}
+
+func TestHubDispatch(t *testing.T) {
+	setTestHub(t)
+
+	first, err := Subscribe("notification:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Subscribe("notification:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Subscribe("notification:bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一主题的所有订阅者都收到消息，其他主题的订阅者收不到
+	hub.dispatch("notification:alice", []byte("hello"))
+	for name, subscriber := range map[string]*Subscriber{"first": first, "second": second} {
+		if got, ok := receive(subscriber); !ok || got != "hello" {
+			t.Errorf("%s subscriber received %q, %v, want hello", name, got, ok)
+		}
+	}
+	if got, ok := receive(other); ok {
+		t.Errorf("other topic received %q", got)
+	}
+
+	// 取消订阅后不再收到消息
+	Unsubscribe(first)
+	hub.dispatch("notification:alice", []byte("again"))
+	if got, ok := receive(first); ok {
+		t.Errorf("unsubscribed subscriber received %q", got)
+	}
+	if got, ok := receive(second); !ok || got != "again" {
+		t.Errorf("second subscriber received %q, %v, want again", got, ok)
+	}
+
+	// 主题的最后一个订阅者取消订阅后删除主题
+	Unsubscribe(second)
+	Unsubscribe(second)
+	if _, ok := hub.subscribers["notification:alice"]; ok {
+		t.Error("topic without subscribers should be removed")
+	}
+	if len(hub.subscribers["notification:bob"]) != 1 {
+		t.Error("other topic should keep its subscriber")
+	}
+}
+
+func TestHubDispatchDropsWhenBufferFull(t *testing.T) {
+	setTestHub(t)
+	subscriber, err := Subscribe("topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 慢客户端的缓冲区满时丢弃消息，不阻塞分发
+	for i := 0; i < subscriberBufferSize+5; i++ {
+		hub.dispatch("topic", []byte("message"))
+	}
+	if got := len(subscriber.C); got != subscriberBufferSize {
+		t.Errorf("buffered messages = %d, want %d", got, subscriberBufferSize)
+	}
+}
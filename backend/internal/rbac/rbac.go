@@ -0,0 +1,203 @@
+// Package rbac 基于角色的权限控制
+// 权限名称由代码定义，角色及角色拥有的权限保存在数据库中，管理员可以通过接口调整
+package rbac
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 权限
+const (
+	PermArticlesWrite    = "articles:write"    // 创建文章，管理自己的文章
+	PermArticlesModerate = "articles:moderate" // 查看和管理所有用户的文章
+	PermPhotosWrite      = "photos:write"      // 上传图片，管理自己的日常照片
+	PermPhotosModerate   = "photos:moderate"   // 管理所有用户的日常照片
+	PermCommentsModerate = "comments:moderate" // 审核、删除所有文章下的评论
+	PermCommentsImport   = "comments:import"   // 从其他评论系统导入评论
+	PermRolesManage      = "roles:manage"      // 管理角色和用户的角色
+	PermUsersManage      = "users:manage"      // 查询用户，封禁、解锁、删除用户
+)
+
+// 内置角色
+const (
+	RoleAdmin = "admin" // 管理员，始终拥有全部权限
+	RoleUser  = "user"  // 注册用户的默认角色
+	RoleGuest = "guest" // 访客，没有写权限
+)
+
+// cacheTTL 角色权限的缓存时间，多实例部署时其他实例修改角色后最多延迟这么久生效
+const cacheTTL = 30 * time.Second
+
+// Permission 权限及说明
+type Permission struct {
+	Name        string `json:"name"`        // 权限名称
+	Description string `json:"description"` // 权限说明
+}
+
+// Permissions 所有可用的权限
+var Permissions = []Permission{
+	{Name: PermArticlesWrite, Description: "创建文章，管理自己的文章"},
+	{Name: PermArticlesModerate, Description: "查看和管理所有用户的文章"},
+	{Name: PermPhotosWrite, Description: "上传图片，管理自己的日常照片"},
+	{Name: PermPhotosModerate, Description: "管理所有用户的日常照片"},
+	{Name: PermCommentsModerate, Description: "审核、删除所有文章下的评论"},
+	{Name: PermCommentsImport, Description: "从其他评论系统导入评论"},
+	{Name: PermRolesManage, Description: "管理角色和用户的角色"},
+	{Name: PermUsersManage, Description: "查询用户，封禁、解锁、删除用户"},
+}
+
+// ErrInvalidPermission 权限不存在
+var ErrInvalidPermission = errors.New("rbac: invalid permission")
+
+// DefaultRoles 内置角色及其默认权限
+func DefaultRoles() []models.Role {
+	return []models.Role{
+		{Name: RoleAdmin, Description: "管理员", Permissions: allPermissions(), IsSystem: true},
+		{Name: RoleUser, Description: "注册用户", Permissions: []string{PermArticlesWrite, PermPhotosWrite}, IsSystem: true},
+		{Name: RoleGuest, Description: "访客", Permissions: []string{}, IsSystem: true},
+	}
+}
+
+// Seed 写入缺少的内置角色，管理员角色的权限每次启动时同步为全部权限
+func Seed() error {
+	for _, role := range DefaultRoles() {
+		role := role
+		var existing models.Role
+		result := models.DB.Where("name = ?", role.Name).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := models.DB.Create(&role).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if role.Name == RoleAdmin {
+			if err := models.DB.Model(&existing).Select("permissions").Updates(&role).Error; err != nil {
+				return err
+			}
+		}
+	}
+	Invalidate()
+	return nil
+}
+
+// NormalizePermissions 校验权限，去重排序后返回
+func NormalizePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, permission := range permissions {
+		permission = strings.TrimSpace(permission)
+		if !isKnownPermission(permission) {
+			return nil, ErrInvalidPermission
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			normalized = append(normalized, permission)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// HasPermission 判断角色是否拥有权限，不存在的角色没有任何权限
+func HasPermission(role, permission string) (bool, error) {
+	if role == RoleAdmin {
+		return true, nil
+	}
+	roles, err := cachedRoles()
+	if err != nil {
+		return false, err
+	}
+	return roles[role][permission], nil
+}
+
+// CanManage 判断用户能否修改或删除资源
+// 资源所有者需要ownPermission，其他用户需要anyPermission
+func CanManage(role string, userID, ownerID uuid.UUID, ownPermission, anyPermission string) (bool, error) {
+	if userID != uuid.Nil && userID == ownerID {
+		allowed, err := HasPermission(role, ownPermission)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return HasPermission(role, anyPermission)
+}
+
+// Invalidate 清空角色权限缓存，修改角色后调用
+func Invalidate() {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.roles = nil
+}
+
+var cache struct {
+	sync.Mutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+// loadRoles 从数据库读取所有角色的权限，测试中可以替换
+var loadRoles = func() (map[string][]string, error) {
+	var roles []models.Role
+	if err := models.DB.Find(&roles).Error; err != nil {
+		logger.Logger.Errorf("load roles failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	result := make(map[string][]string, len(roles))
+	for _, role := range roles {
+		result[role.Name] = role.Permissions
+	}
+	return result, nil
+}
+
+func cachedRoles() (map[string]map[string]bool, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.roles != nil && time.Since(cache.loadedAt) < cacheTTL {
+		return cache.roles, nil
+	}
+	loaded, err := loadRoles()
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]map[string]bool, len(loaded))
+	for name, permissions := range loaded {
+		set := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			set[permission] = true
+		}
+		roles[name] = set
+	}
+	cache.roles = roles
+	cache.loadedAt = time.Now()
+	return roles, nil
+}
+
+func allPermissions() []string {
+	names := make([]string, 0, len(Permissions))
+	for _, permission := range Permissions {
+		names = append(names, permission.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isKnownPermission(name string) bool {
+	for _, permission := range Permissions {
+		if permission.Name == name {
+			return true
+		}
+	}
+	return false
+}
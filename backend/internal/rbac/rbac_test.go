@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// useRoles 使用固定的角色权限替换数据库
+func useRoles(t *testing.T, roles map[string][]string) {
+	t.Helper()
+	original := loadRoles
+	loadRoles = func() (map[string][]string, error) { return roles, nil }
+	Invalidate()
+	t.Cleanup(func() {
+		loadRoles = original
+		Invalidate()
+	})
+}
+
+func TestNormalizePermissions(t *testing.T) {
+	got, err := NormalizePermissions([]string{"photos:write", " articles:write", "photos:write"})
+	if err != nil || !reflect.DeepEqual(got, []string{"articles:write", "photos:write"}) {
+		t.Errorf("NormalizePermissions() = %v, %v", got, err)
+	}
+	if got, err := NormalizePermissions(nil); err != nil || len(got) != 0 {
+		t.Errorf("NormalizePermissions(nil) = %v, %v, want empty list", got, err)
+	}
+	if _, err := NormalizePermissions([]string{"articles:write", "admin"}); !errors.Is(err, ErrInvalidPermission) {
+		t.Errorf("NormalizePermissions() error = %v, want ErrInvalidPermission", err)
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	useRoles(t, map[string][]string{
+		RoleUser:    {PermArticlesWrite},
+		"moderator": {PermCommentsModerate},
+	})
+
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{RoleAdmin, PermRolesManage, true},
+		{RoleUser, PermArticlesWrite, true},
+		{RoleUser, PermArticlesModerate, false},
+		{"moderator", PermCommentsModerate, true},
+		{"unknown", PermArticlesWrite, false},
+	}
+	for _, tt := range tests {
+		got, err := HasPermission(tt.role, tt.permission)
+		if err != nil || got != tt.want {
+			t.Errorf("HasPermission(%q, %q) = %v, %v, want %v", tt.role, tt.permission, got, err, tt.want)
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	useRoles(t, map[string][]string{
+		RoleUser:    {PermArticlesWrite},
+		RoleGuest:   {},
+		"moderator": {PermArticlesWrite, PermArticlesModerate},
+	})
+	owner, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		role   string
+		userID uuid.UUID
+		want   bool
+	}{
+		{"作者", RoleUser, owner, true},
+		{"其他用户", RoleUser, other, false},
+		{"没有写权限的作者", RoleGuest, owner, false},
+		{"有管理权限的其他用户", "moderator", other, true},
+		{"管理员", RoleAdmin, other, true},
+		{"匿名用户", RoleUser, uuid.Nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ownerID := owner
+			if tt.userID == uuid.Nil {
+				ownerID = uuid.Nil
+			}
+			got, err := CanManage(tt.role, tt.userID, ownerID, PermArticlesWrite, PermArticlesModerate)
+			if err != nil || got != tt.want {
+				t.Errorf("CanManage() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRoles(t *testing.T) {
+	for _, role := range DefaultRoles() {
+		if _, err := NormalizePermissions(role.Permissions); err != nil {
+			t.Errorf("role %q has invalid permissions: %v", role.Name, err)
+		}
+	}
+}
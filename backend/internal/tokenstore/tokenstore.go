@@ -0,0 +1,138 @@
+// Package tokenstore 在Redis中保存令牌的吊销状态
+// 单个令牌通过jti加入黑名单吊销，一个会话的令牌通过会话ID吊销，用户的所有令牌通过递增令牌版本号吊销
+package tokenstore
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/redis"
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Claims中的字段名
+const (
+	ClaimJTI     = "jti" // 令牌ID
+	ClaimVersion = "ver" // 用户令牌版本号
+	ClaimSession = "sid" // 会话ID
+)
+
+// NewJTI 生成令牌ID
+func NewJTI() string {
+	return uuid.NewString()
+}
+
+// Revoke 将令牌加入黑名单，直到令牌过期
+func Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return redis.GetRedisClient().Set(ctx, denylistKey(jti), 1, ttl).Err()
+}
+
+// RevokeClaims 将Claims对应的令牌加入黑名单
+func RevokeClaims(ctx context.Context, claims jwt.MapClaims) error {
+	jti, _ := claims[ClaimJTI].(string)
+	exp, _ := claims["exp"].(float64)
+	return Revoke(ctx, jti, time.Unix(int64(exp), 0))
+}
+
+// IsRevoked 判断令牌是否在黑名单中
+func IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	count, err := redis.GetRedisClient().Exists(ctx, denylistKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeSession 吊销会话签发的所有令牌，ttl应不小于访问令牌的有效期
+// 会话的刷新令牌由数据库中的会话状态控制，这里只需要拦截尚未过期的访问令牌
+func RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	if sessionID == "" || ttl <= 0 {
+		return nil
+	}
+	return redis.GetRedisClient().Set(ctx, sessionKey(sessionID), 1, ttl).Err()
+}
+
+// IsSessionRevoked 判断会话是否已被吊销
+func IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	count, err := redis.GetRedisClient().Exists(ctx, sessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Version 获取用户当前的令牌版本号，未设置时为0
+func Version(ctx context.Context, userID string) (int64, error) {
+	version, err := redis.GetRedisClient().Get(ctx, versionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// BumpVersion 递增用户的令牌版本号，使之前签发的所有令牌失效
+func BumpVersion(ctx context.Context, userID string) error {
+	return redis.GetRedisClient().Incr(ctx, versionKey(userID)).Err()
+}
+
+// Check 检查令牌是否已被吊销，返回对应的错误码
+// 没有jti和ver的旧令牌视为版本0
+func Check(ctx context.Context, claims jwt.MapClaims) error {
+	jti, _ := claims[ClaimJTI].(string)
+	revoked, err := IsRevoked(ctx, jti)
+	if err != nil {
+		logger.Logger.Errorf("check token denylist failed: %v", err)
+		return code.ErrDatabase
+	}
+	if revoked {
+		return code.ErrTokenRevoked
+	}
+
+	sessionID, _ := claims[ClaimSession].(string)
+	revoked, err = IsSessionRevoked(ctx, sessionID)
+	if err != nil {
+		logger.Logger.Errorf("check session revoked failed: %v", err)
+		return code.ErrDatabase
+	}
+	if revoked {
+		return code.ErrTokenRevoked
+	}
+
+	userID, _ := claims["sub"].(string)
+	current, err := Version(ctx, userID)
+	if err != nil {
+		logger.Logger.Errorf("get token version failed: %v", err)
+		return code.ErrDatabase
+	}
+	version, _ := claims[ClaimVersion].(float64)
+	if int64(version) != current {
+		return code.ErrTokenRevoked
+	}
+	return nil
+}
+
+func denylistKey(jti string) string {
+	return config.Conf.Redis.KeyPrefix + ":token_denylist:" + jti
+}
+
+func sessionKey(sessionID string) string {
+	return config.Conf.Redis.KeyPrefix + ":session_revoked:" + sessionID
+}
+
+func versionKey(userID string) string {
+	return config.Conf.Redis.KeyPrefix + ":token_version:" + userID
+}
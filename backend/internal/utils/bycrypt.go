@@ -1,15 +1,15 @@
 package utils
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"blog-server/internal/password"
 )
 
-func GenerateEncryptedPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hash), err
+// GenerateEncryptedPassword 使用配置的算法计算密码哈希，默认为argon2id
+func GenerateEncryptedPassword(plain string) (string, error) {
+	return password.Hash(plain)
 }
 
-func ComparePassword(password string, encryptedPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(encryptedPassword), []byte(password))
-	return err == nil
+// ComparePassword 校验密码，支持argon2id和bcrypt的哈希
+func ComparePassword(plain string, encryptedPassword string) bool {
+	return password.Verify(plain, encryptedPassword)
 }
@@ -1,30 +1,17 @@
 package utils
 
 import (
-	"crypto/rsa"
+	"blog-server/internal/jwtkeys"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(claims jwt.MapClaims, privateKey *rsa.PrivateKey) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(privateKey)
+// GenerateJWT 使用密钥环当前的签名密钥签发令牌，JWT头中包含kid
+func GenerateJWT(claims jwt.MapClaims) (string, error) {
+	return jwtkeys.Sign(claims)
 }
 
-func ValidateJWT(tokenString string, publicKey *rsa.PublicKey) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return publicKey, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, jwt.ErrSignatureInvalid
+// ValidateJWT 根据令牌的kid选择密钥环中的公钥校验令牌
+func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
+	return jwtkeys.Verify(tokenString)
 }
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MentionProfilePath 用户公开主页的路径前缀，@提及会被渲染为指向该路径的链接
+const MentionProfilePath = "/users/"
+
+var (
+	// mentionPattern 匹配@用户名，@前面不能是字母数字、'@'、'['或'/'，避免误匹配邮箱、已有链接和URL
+	mentionPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_@\[/])@([A-Za-z0-9_][A-Za-z0-9_.\-]{0,49})`)
+	// codePattern 匹配Markdown中的代码块和行内代码，其中的@不视为提及
+	codePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+)
+
+// ParseMentions 解析内容中的@提及
+// 返回去重后的用户名列表，顺序与首次出现的顺序一致
+// 代码块和行内代码中的@不视为提及
+func ParseMentions(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	forEachTextSegment(content, func(text string) string {
+		for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+			name := trimMentionName(match[2])
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+		return text
+	})
+	return names
+}
+
+// RenderMentions 将内容中的@提及渲染为指向用户公开主页的Markdown链接
+// exists用于判断用户名是否存在，不存在的用户名保持原样
+func RenderMentions(content string, exists func(userName string) bool) string {
+	return forEachTextSegment(content, func(text string) string {
+		return mentionPattern.ReplaceAllStringFunc(text, func(match string) string {
+			sub := mentionPattern.FindStringSubmatch(match)
+			prefix, raw := sub[1], sub[2]
+			name := trimMentionName(raw)
+			if name == "" || !exists(name) {
+				return match
+			}
+			// 被裁剪掉的结尾标点保留在链接之外
+			suffix := raw[len(name):]
+			return prefix + "[@" + name + "](" + MentionProfileURL(name) + ")" + suffix
+		})
+	})
+}
+
+// MentionProfileURL 返回用户公开主页的相对URL
+func MentionProfileURL(userName string) string {
+	return MentionProfilePath + url.PathEscape(userName)
+}
+
+// trimMentionName 去掉用户名结尾的标点，例如句末的"."
+func trimMentionName(name string) string {
+	return strings.TrimRight(name, ".-")
+}
+
+// forEachTextSegment 对内容中代码以外的片段调用fn，并用fn的返回值替换该片段
+func forEachTextSegment(content string, fn func(text string) string) string {
+	var builder strings.Builder
+	last := 0
+	for _, loc := range codePattern.FindAllStringIndex(content, -1) {
+		builder.WriteString(fn(content[last:loc[0]]))
+		builder.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	builder.WriteString(fn(content[last:]))
+	return builder.String()
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "single mention",
+			content: "hello @alice",
+			want:    []string{"alice"},
+		},
+		{
+			name:    "duplicate and trailing dot",
+			content: "@bob thanks @alice. and @bob again",
+			want:    []string{"bob", "alice"},
+		},
+		{
+			name:    "email is not a mention",
+			content: "mail me at test@example.com",
+			want:    nil,
+		},
+		{
+			name:    "code is ignored",
+			content: "see `@ignored` and\n```\n@alsoignored\n```\nbut @carol",
+			want:    []string{"carol"},
+		},
+		{
+			name:    "existing link is ignored",
+			content: "[@alice](/users/alice) and https://x.com/@dave",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMentions(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMentions(t *testing.T) {
+	exists := func(userName string) bool {
+		return userName == "alice"
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "known user",
+			content: "hi @alice.",
+			want:    "hi [@alice](/users/alice).",
+		},
+		{
+			name:    "unknown user",
+			content: "hi @nobody",
+			want:    "hi @nobody",
+		},
+		{
+			name:    "code is untouched",
+			content: "`@alice` @alice",
+			want:    "`@alice` [@alice](/users/alice)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderMentions(tt.content, exists)
+			if got != tt.want {
+				t.Errorf("RenderMentions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
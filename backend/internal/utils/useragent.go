@@ -0,0 +1,60 @@
+package utils
+
+import "strings"
+
+// userAgentRule 根据User-Agent中的关键字识别浏览器或系统
+type userAgentRule struct {
+	keyword string
+	name    string
+}
+
+// 顺序很重要：Edge和Opera的User-Agent中也包含Chrome，Chrome的User-Agent中也包含Safari
+var browserRules = []userAgentRule{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"MicroMessenger/", "WeChat"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"okhttp/", "App"},
+	{"CFNetwork/", "App"},
+}
+
+var osRules = []userAgentRule{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"okhttp/", "Android"},
+	{"CFNetwork/", "iOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Darwin", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceNameFromUserAgent 根据User-Agent生成可读的设备名称，例如"Chrome on Windows"
+// 无法识别时返回"Unknown device"
+func DeviceNameFromUserAgent(userAgent string) string {
+	browser := matchUserAgent(userAgent, browserRules)
+	os := matchUserAgent(userAgent, osRules)
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchUserAgent(userAgent string, rules []userAgentRule) string {
+	for _, rule := range rules {
+		if strings.Contains(userAgent, rule.keyword) {
+			return rule.name
+		}
+	}
+	return ""
+}
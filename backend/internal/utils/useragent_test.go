@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestDeviceNameFromUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "chrome on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			want:      "Chrome on Windows",
+		},
+		{
+			name:      "edge on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+			want:      "Edge on Windows",
+		},
+		{
+			name:      "safari on iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			want:      "Safari on iPhone",
+		},
+		{
+			name:      "firefox on linux",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			want:      "Firefox on Linux",
+		},
+		{
+			name:      "ios app",
+			userAgent: "DreamZero/1.0 CFNetwork/1490.0.4 Darwin/23.2.0",
+			want:      "App on iOS",
+		},
+		{
+			name:      "unknown",
+			userAgent: "curl/8.5.0",
+			want:      "Unknown device",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeviceNameFromUserAgent(tt.userAgent); got != tt.want {
+				t.Errorf("DeviceNameFromUserAgent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 	"github.com/google/uuid"
 	"github.com/gin-gonic/gin"
@@ -61,6 +62,19 @@ func ValidateEmail(email string) bool {
 	return reg.MatchString(email)
 }
 
+// MaskEmail 隐藏邮箱用户名部分的中间字符，用于在邮件和页面中展示
+// 例如：alice@example.com -> a***e@example.com
+func MaskEmail(email string) string {
+	name, domain, ok := strings.Cut(email, "@")
+	if !ok || name == "" {
+		return email
+	}
+	if len(name) <= 2 {
+		return name[:1] + "***@" + domain
+	}
+	return name[:1] + "***" + name[len(name)-1:] + "@" + domain
+}
+
 func GenerateVerificationCode() string {
 	// 生成6位随机数字验证码
 	const charset = "0123456789"
@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "alice@example.com", want: "a***e@example.com"},
+		{email: "ab@example.com", want: "a***@example.com"},
+		{email: "a@example.com", want: "a***@example.com"},
+		{email: "not-an-email", want: "not-an-email"},
+	}
+	for _, tt := range tests {
+		if got := MaskEmail(tt.email); got != tt.want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ws
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket 令牌桶限流器
+// 以固定速率生成令牌，桶的容量决定了允许的突发数量
+type TokenBucket struct {
+	mutex    sync.Mutex
+	rate     float64   // 每秒生成的令牌数
+	capacity float64   // 桶的容量
+	tokens   float64   // 当前令牌数
+	last     time.Time // 上次更新令牌的时间
+	now      func() time.Time
+}
+
+// NewTokenBucket 创建令牌桶，初始时桶是满的
+func NewTokenBucket(rate, capacity int) *TokenBucket {
+	return &TokenBucket{
+		rate:     float64(rate),
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Allow 尝试消耗一个令牌，令牌不足时返回false
+func (bucket *TokenBucket) Allow() bool {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	now := bucket.now()
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.capacity {
+		bucket.tokens = bucket.capacity
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
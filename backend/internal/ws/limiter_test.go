@@ -0,0 +1,43 @@
+package ws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	current := time.Now()
+	bucket := NewTokenBucket(2, 3)
+	bucket.last = current
+	bucket.now = func() time.Time { return current }
+
+	// 初始时可以突发消耗完整个桶
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("Allow() = false at %d, want true", i)
+		}
+	}
+	if bucket.Allow() {
+		t.Fatalf("Allow() = true after burst, want false")
+	}
+
+	// 半秒后生成一个令牌
+	current = current.Add(500 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Fatalf("Allow() = false after refill, want true")
+	}
+	if bucket.Allow() {
+		t.Fatalf("Allow() = true without tokens, want false")
+	}
+
+	// 令牌数不会超过桶的容量
+	current = current.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("Allow() = false at %d after long idle, want true", i)
+		}
+	}
+	if bucket.Allow() {
+		t.Fatalf("Allow() = true beyond capacity, want false")
+	}
+}
@@ -0,0 +1,161 @@
+package ws
+
+import (
+	"blog-server/internal/logger"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait 写消息的超时时间
+	writeWait = 10 * time.Second
+	// pongWait 等待客户端pong的超时时间，超时后断开连接
+	pongWait = 60 * time.Second
+	// pingPeriod 发送ping心跳的间隔，必须小于pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize 客户端消息的最大字节数
+	maxMessageSize = 4096
+	// messageRate 每个连接每秒允许的客户端消息数
+	messageRate = 5
+	// messageBurst 每个连接允许的突发消息数
+	messageBurst = 10
+)
+
+// Upgrader 将HTTP连接升级为WebSocket连接
+// 跨域策略与CorsMiddleware保持一致，允许所有来源
+var Upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// Message 客户端与服务端之间的消息
+type Message struct {
+	Type string          `json:"type"`           // 消息类型
+	Data json.RawMessage `json:"data,omitempty"` // 消息数据
+}
+
+// Client 一个WebSocket客户端连接
+// 服务端消息从send通道写出，客户端目前只需要发送ping消息，其它消息经过限流后忽略
+type Client struct {
+	conn    *websocket.Conn
+	send    <-chan []byte
+	limiter *TokenBucket
+	writeMu sync.Mutex
+}
+
+// NewClient 创建客户端，send为需要推送给客户端的消息
+func NewClient(conn *websocket.Conn, send <-chan []byte) *Client {
+	return &Client{
+		conn:    conn,
+		send:    send,
+		limiter: NewTokenBucket(messageRate, messageBurst),
+	}
+}
+
+// Run 运行客户端的读写循环，直到连接断开
+func (client *Client) Run() {
+	done := make(chan struct{})
+	go client.writePump(done)
+	client.readPump()
+	close(done)
+}
+
+// writeJSON 向客户端写JSON消息
+func (client *Client) writeJSON(v interface{}) error {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	if err := client.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return client.conn.WriteJSON(v)
+}
+
+// readPump 读取客户端消息，处理心跳和限流
+func (client *Client) readPump() {
+	defer func() {
+		if err := client.conn.Close(); err != nil {
+			logger.Logger.Debugf("close websocket failed: %v", err)
+		}
+	}()
+
+	client.conn.SetReadLimit(maxMessageSize)
+	_ = client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var message Message
+		if err := client.conn.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.Logger.Debugf("read websocket message failed: %v", err)
+			}
+			return
+		}
+
+		// 超出频率限制时断开连接
+		if !client.limiter.Allow() {
+			client.close(websocket.ClosePolicyViolation, "rate limit exceeded")
+			return
+		}
+
+		// 收到任何消息都视为客户端存活
+		_ = client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		if message.Type == "ping" {
+			if err := client.writeJSON(Message{Type: "pong"}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writePump 推送服务端消息并定时发送ping心跳
+func (client *Client) writePump(done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case payload, ok := <-client.send:
+			if !ok {
+				client.close(websocket.CloseNormalClosure, "")
+				return
+			}
+			if err := client.writeMessage(websocket.TextMessage, payload); err != nil {
+				_ = client.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := client.writeMessage(websocket.PingMessage, nil); err != nil {
+				_ = client.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// writeMessage 写入一条消息
+func (client *Client) writeMessage(messageType int, payload []byte) error {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	if err := client.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return client.conn.WriteMessage(messageType, payload)
+}
+
+// close 发送关闭帧并关闭连接
+func (client *Client) close(closeCode int, reason string) {
+	client.writeMu.Lock()
+	_ = client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(writeWait))
+	client.writeMu.Unlock()
+	_ = client.conn.Close()
+}
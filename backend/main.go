@@ -1,22 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	"blog-server/internal/config"
+	"blog-server/internal/geoip"
 	// "blog-server/internal/email"
 	// "blog-server/internal/email"
 	"blog-server/internal/logger"
 	"blog-server/internal/middleware"
 	"blog-server/internal/models"
 	"blog-server/internal/oss"
+	"blog-server/internal/password"
+	"blog-server/internal/pubsub"
+	"blog-server/internal/rbac"
 	"blog-server/internal/redis"
-	"blog-server/internal/rsa"
 	"blog-server/internal/server"
 	"blog-server/internal/version"
 	"blog-server/router"
+	"blog-server/service"
 
 	"github.com/urfave/cli"
 	"github.com/gin-contrib/pprof"
@@ -74,13 +79,19 @@ func main() {
 			return err
 		}
 
-		// init RSA keys
-		if privateKey, publicKey, err := rsa.InitRSAKeys(config.Conf.App.RsaPrivateKeyPath, config.Conf.App.RsaPublicKeyPath); err != nil {
+		// init jwt keys, 第一次启动时导入旧的RSA私钥
+		if err := service.InitSigningKeys(); err != nil {
+			return err
+		}
+
+		// init password hasher, 新设置的密码使用配置的哈希算法
+		if err := password.Init(config.Conf.Auth.PasswordHash); err != nil {
+			return err
+		}
+
+		// init geoip, 用于登录记录的地理位置
+		if err := geoip.Init(config.Conf.Account.GeoIPDatabase); err != nil {
 			return err
-		} else {
-			// 将密钥保存到全局变量中
-			rsa.PrivateKey = privateKey
-			rsa.PublicKey = publicKey
 		}
 
 		// init oss, such as minio
@@ -94,6 +105,11 @@ func main() {
 		}
 		defer models.Close()
 
+		// init rbac, 写入内置角色
+		if err := rbac.Seed(); err != nil {
+			return err
+		}
+
 		// init redis
 		if err := redis.Init(config.Conf.Redis); err != nil {
 			return err
@@ -104,6 +120,22 @@ func main() {
 			}
 		}()
 
+		// init pubsub, 基于Redis发布订阅在多个实例之间分发实时消息
+		if err := pubsub.Init(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := pubsub.Close(); err != nil {
+				logger.Logger.Errorf("关闭发布订阅失败: %v", err)
+			}
+		}()
+
+		// init account maintenance, 定期删除冷静期已结束的账号、过期的数据导出和登录记录
+		service.StartAccountMaintenance(context.Background())
+
+		// init jwt key rotation, 定期轮换签发JWT的密钥并删除过期的密钥
+		service.StartSigningKeyRotation(context.Background())
+
 		// // init email
 		// if err := email.InitEmail(); err != nil {
 		// 	return err
@@ -14,6 +14,11 @@ func RegisterAPIV1(apiGroup *gin.RouterGroup) {
 		userController           *v1.UserController
 		articleController        *v1.ArticleController
 		dailyPhotographController *v1.DailyPhotographController
+		notificationController    *v1.NotificationController
+		roleController            *v1.RoleController
+		adminUserController       *v1.AdminUserController
+		publicUserController      *v1.PublicUserController
+		feedController            *v1.FeedController
 	)
 	if err := photoController.InitRouter(apiGroup); err != nil {
 		panic(err)
@@ -30,4 +35,19 @@ func RegisterAPIV1(apiGroup *gin.RouterGroup) {
 	if err := dailyPhotographController.InitRouter(apiGroup); err != nil {
 		panic(err)
 	}
+	if err := notificationController.InitRouter(apiGroup); err != nil {
+		panic(err)
+	}
+	if err := roleController.InitRouter(apiGroup); err != nil {
+		panic(err)
+	}
+	if err := adminUserController.InitRouter(apiGroup); err != nil {
+		panic(err)
+	}
+	if err := publicUserController.InitRouter(apiGroup); err != nil {
+		panic(err)
+	}
+	if err := feedController.InitRouter(apiGroup); err != nil {
+		panic(err)
+	}
 }
@@ -27,4 +27,5 @@ func InitRouter(server *server.Server) {
 func registerBaseAPI(server *server.Server) {
 	server.GinEngine.GET("/", controller.Health)
 	server.GinEngine.GET("/version", controller.Version)
+	server.GinEngine.GET("/.well-known/jwks.json", controller.JWKS)
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"blog-server/internal/accesstoken"
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultAccessTokenDays 未指定有效期时个人访问令牌的有效天数
+	defaultAccessTokenDays = 30
+	// maxAccessTokensPerUser 每个用户最多同时拥有的有效令牌数量
+	maxAccessTokensPerUser = 50
+)
+
+// CreatedAccessToken 新创建的个人访问令牌，Token只在创建时返回一次
+type CreatedAccessToken struct {
+	models.PersonalAccessToken
+	Token string `json:"token"` // 令牌，请立即保存
+}
+
+// CreateAccessTokenService 创建个人访问令牌服务结构体
+type CreateAccessTokenService struct {
+	Name          string    `json:"name" form:"name" binding:"required,max=100"`              // 名称，必填
+	Scopes        []string  `json:"scopes" form:"scopes" binding:"required"`                  // 权限范围，必填
+	ExpiresInDays int       `json:"expires_in_days" form:"expires_in_days" binding:"max=365"` // 有效天数，最多365天，为空时30天
+	UserID        uuid.UUID `json:"-" form:"-"`                                               // 用户ID，从JWT中获取
+}
+
+// Create 创建个人访问令牌，数据库中只保存哈希
+func (service *CreateAccessTokenService) Create() (*CreatedAccessToken, error) {
+	scopes, err := accesstoken.NormalizeScopes(service.Scopes)
+	if err != nil {
+		return nil, code.ErrAccessTokenScopeInvalid
+	}
+	days := service.ExpiresInDays
+	if days <= 0 {
+		days = defaultAccessTokenDays
+	}
+
+	var count int64
+	if err := models.DB.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", service.UserID, time.Now()).
+		Count(&count).Error; err != nil {
+		logger.Logger.Errorf("count personal access tokens failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, code.ErrAccessTokenLimit
+	}
+
+	token, hash, displayPrefix, err := accesstoken.Generate()
+	if err != nil {
+		logger.Logger.Errorf("generate personal access token failed: %v", err)
+		return nil, code.InternalServerError
+	}
+	record := models.PersonalAccessToken{
+		UserID:      service.UserID,
+		Name:        service.Name,
+		TokenHash:   hash,
+		TokenPrefix: displayPrefix,
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, days),
+	}
+	if err := models.DB.Create(&record).Error; err != nil {
+		logger.Logger.Errorf("create personal access token failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &CreatedAccessToken{PersonalAccessToken: record, Token: token}, nil
+}
+
+// ListAccessTokensService 获取个人访问令牌列表服务结构体
+type ListAccessTokensService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// List 返回当前用户未吊销的个人访问令牌，包括已过期的令牌
+func (service *ListAccessTokensService) List() ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	if err := models.DB.Where("user_id = ? AND revoked_at IS NULL", service.UserID).
+		Order("created_at DESC").Find(&tokens).Error; err != nil {
+		logger.Logger.Errorf("list personal access tokens failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return tokens, nil
+}
+
+// RevokeAccessTokenService 吊销个人访问令牌服务结构体
+type RevokeAccessTokenService struct {
+	ID     string    `uri:"id" binding:"required,uuid"` // 令牌ID
+	UserID uuid.UUID `json:"-" form:"-"`                // 用户ID，从JWT中获取
+}
+
+// Revoke 吊销令牌，吊销后立即失效
+func (service *RevokeAccessTokenService) Revoke() error {
+	result := models.DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", service.ID, service.UserID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		logger.Logger.Errorf("revoke personal access token failed: %v", result.Error)
+		return code.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		return code.ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// revokeUserAccessTokens 吊销用户所有未吊销的个人访问令牌
+func revokeUserAccessTokens(userID uuid.UUID) error {
+	return models.DB.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
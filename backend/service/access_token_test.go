@@ -0,0 +1,97 @@
+package service
+
+import (
+	"blog-server/internal/accesstoken"
+	"blog-server/internal/code"
+	"blog-server/internal/models"
+	"blog-server/internal/tokenstore"
+	"context"
+	"testing"
+	"time"
+)
+
+// createTestUser 创建测试用户
+func createTestUser(t *testing.T, userName string) *models.User {
+	t.Helper()
+	user := &models.User{
+		UserName: userName,
+		Password: "hash",
+		Nickname: userName,
+		Email:    userName + "@example.com",
+		Role:     "user",
+		Status:   "active",
+		IsActive: true,
+	}
+	if err := models.DB.Create(user).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	return user
+}
+
+// createTestAccessToken 为用户创建个人访问令牌，返回令牌明文
+func createTestAccessToken(t *testing.T, user *models.User) string {
+	t.Helper()
+	token, hash, prefix, err := accesstoken.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &models.PersonalAccessToken{
+		UserID:      user.ID,
+		Name:        "ci",
+		TokenHash:   hash,
+		TokenPrefix: prefix,
+		Scopes:      accesstoken.ScopeArticlesWrite,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	if err := models.DB.Create(record).Error; err != nil {
+		t.Fatalf("create access token failed: %v", err)
+	}
+	return token
+}
+
+func TestAuthenticateAccessTokenPasswordResetRequired(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.PersonalAccessToken{})
+	user := createTestUser(t, "alice")
+	token := createTestAccessToken(t, user)
+
+	if _, _, err := accesstoken.Authenticate(context.Background(), token, "127.0.0.1"); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	// 令牌没有被吊销时，需要重置密码的用户也不能使用
+	if err := models.DB.Model(user).Update("password_reset_required", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := accesstoken.Authenticate(context.Background(), token, "127.0.0.1"); err != code.ErrPasswordResetRequired {
+		t.Errorf("Authenticate() error = %v, want ErrPasswordResetRequired", err)
+	}
+}
+
+func TestRequirePasswordResetRevokesAccessTokens(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.PersonalAccessToken{}, &models.UserSession{})
+	newTestRedis(t)
+	user := createTestUser(t, "alice")
+	other := createTestUser(t, "bob")
+	token := createTestAccessToken(t, user)
+	otherToken := createTestAccessToken(t, other)
+
+	if err := requirePasswordReset(user); err != nil {
+		t.Fatalf("requirePasswordReset() error = %v", err)
+	}
+
+	if version, err := tokenstore.Version(context.Background(), user.ID.String()); err != nil || version != 1 {
+		t.Errorf("token version = %d, %v, want 1", version, err)
+	}
+	var active int64
+	models.DB.Model(&models.PersonalAccessToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+	if active != 0 {
+		t.Errorf("active access tokens = %d, want 0", active)
+	}
+	if _, _, err := accesstoken.Authenticate(context.Background(), token, "127.0.0.1"); err != code.ErrTokenRevoked {
+		t.Errorf("Authenticate() error = %v, want ErrTokenRevoked", err)
+	}
+	// 其他用户的令牌不受影响
+	if _, _, err := accesstoken.Authenticate(context.Background(), otherToken, "127.0.0.1"); err != nil {
+		t.Errorf("Authenticate() other user's token error = %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/dto"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/oss"
+	"blog-server/internal/redis"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 注销账号时处理用户内容的方式
+const (
+	AccountDeletionModeAnonymize = "anonymize" // 保留文章、评论和照片，作者显示为已注销用户
+	AccountDeletionModeDelete    = "delete"    // 删除文章、照片，评论保留为占位
+)
+
+const (
+	// defaultAccountDeletionGrace 申请注销后默认的冷静期
+	defaultAccountDeletionGrace = 14 * 24 * time.Hour
+	// accountMaintenanceInterval 后台清理任务的执行间隔
+	accountMaintenanceInterval = time.Hour
+	// accountPurgeBatchSize 每次清理的账号数量
+	accountPurgeBatchSize = 100
+	// deletedUserNickname 匿名化后的昵称
+	deletedUserNickname = "已注销用户"
+)
+
+// AccountDeletionStatus 账号注销状态
+type AccountDeletionStatus struct {
+	Scheduled   bool       `json:"scheduled"`    // 是否已申请注销
+	ScheduledAt *time.Time `json:"scheduled_at"` // 计划删除账号的时间
+	Mode        string     `json:"mode"`         // 处理内容的方式：anonymize, delete
+}
+
+// ScheduleAccountDeletionService 申请注销账号服务结构体
+type ScheduleAccountDeletionService struct {
+	Password string    `json:"password" form:"password" binding:"required"`                // 当前密码，必填
+	Code     string    `json:"code" form:"code"`                                           // 验证码或恢复码，开启两步验证时必填
+	Mode     string    `json:"mode" form:"mode" binding:"required,oneof=anonymize delete"` // 处理内容的方式，必填
+	UserID   uuid.UUID `json:"-" form:"-"`                                                 // 用户ID，从JWT中获取
+}
+
+// Schedule 验证密码和两步验证后申请注销账号
+// 冷静期结束后由后台任务删除账号，冷静期内可以撤销
+func (service *ScheduleAccountDeletionService) Schedule() (*AccountDeletionStatus, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil, code.ErrAccountDeletionScheduled
+	}
+	if !user.ComparePassword(service.Password) {
+		return nil, code.ErrPasswordIncorrect
+	}
+	if user.TwoFactorEnabled {
+		if err := verifySecondFactor(&user, service.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	scheduledAt := time.Now().Add(accountDeletionGrace())
+	if err := models.DB.Model(&user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": scheduledAt,
+		"deletion_mode":         service.Mode,
+	}).Error; err != nil {
+		logger.Logger.Errorf("schedule account deletion failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	message := dto.AccountDeletionMessage{
+		Email:       user.Email,
+		UserName:    user.UserName,
+		ScheduledAt: scheduledAt.Format("2006-01-02 15:04"),
+		CancelURL:   siteURL(privacySettingsPath),
+	}
+	if err := publishEmailMessage(context.Background(), "account_deletion", user.Email, message); err != nil {
+		logger.Logger.Errorf("send account deletion email failed: %v", err)
+	}
+
+	return &AccountDeletionStatus{
+		Scheduled:   true,
+		ScheduledAt: &scheduledAt,
+		Mode:        service.Mode,
+	}, nil
+}
+
+// GetAccountDeletionService 获取账号注销状态服务结构体
+type GetAccountDeletionService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// Get 获取账号注销状态
+func (service *GetAccountDeletionService) Get() (*AccountDeletionStatus, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	return &AccountDeletionStatus{
+		Scheduled:   user.DeletionScheduledAt != nil,
+		ScheduledAt: user.DeletionScheduledAt,
+		Mode:        user.DeletionMode,
+	}, nil
+}
+
+// CancelAccountDeletionService 撤销注销账号服务结构体
+type CancelAccountDeletionService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// Cancel 在冷静期内撤销注销
+func (service *CancelAccountDeletionService) Cancel() error {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return code.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt == nil {
+		return code.ErrAccountDeletionNotFound
+	}
+	if err := models.DB.Model(&user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": nil,
+		"deletion_mode":         "",
+	}).Error; err != nil {
+		logger.Logger.Errorf("cancel account deletion failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// StartAccountMaintenance 启动后台任务，定期删除冷静期已结束的账号、过期的数据导出和登录记录
+// 多个实例同时运行时通过Redis锁保证每个周期只有一个实例执行
+func StartAccountMaintenance(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(accountMaintenanceInterval)
+		defer ticker.Stop()
+		for {
+			runAccountMaintenance(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runAccountMaintenance 执行一次后台清理
+func runAccountMaintenance(ctx context.Context) {
+	key := config.Conf.Redis.KeyPrefix + ":account_maintenance_lock"
+	locked, err := redis.GetRedisClient().SetNX(ctx, key, 1, accountMaintenanceInterval-time.Minute).Result()
+	if err != nil {
+		logger.Logger.Errorf("acquire account maintenance lock failed: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	purgeDeletedAccounts()
+	expireDataExports()
+	purgeLoginRecords()
+}
+
+// purgeDeletedAccounts 删除冷静期已结束的账号
+func purgeDeletedAccounts() {
+	var users []models.User
+	if err := models.DB.Where("deletion_scheduled_at <= ?", time.Now()).
+		Limit(accountPurgeBatchSize).Find(&users).Error; err != nil {
+		logger.Logger.Errorf("list accounts to delete failed: %v", err)
+		return
+	}
+	for i := range users {
+		if err := purgeAccount(&users[i]); err != nil {
+			logger.Logger.Errorf("delete account %s failed: %v", users[i].ID, err)
+			continue
+		}
+		logger.Logger.Infof("account %s deleted, mode: %s", users[i].ID, users[i].DeletionMode)
+	}
+}
+
+// purgeAccount 按用户选择的方式删除账号
+// 两种方式都会删除会话、登录方式、操作日志和导出文件等个人数据：
+// anonymize保留内容并清除账号上的个人信息；delete删除文章和照片后删除账号，
+// 照片、会话等由外键级联删除，照片和头像在对象存储中的文件在事务提交后删除
+func purgeAccount(user *models.User) error {
+	var objects []string
+	var exports []models.DataExport
+	if err := models.DB.Where("user_id = ? AND object_name <> ''", user.ID).Find(&exports).Error; err != nil {
+		return err
+	}
+	exportObjects := make([]string, 0, len(exports))
+	for _, export := range exports {
+		exportObjects = append(exportObjects, export.ObjectName)
+	}
+	if user.DeletionMode == AccountDeletionModeDelete {
+		var photoURLs []string
+		if err := models.DB.Unscoped().Model(&models.DailyPhotograph{}).Where("user_id = ?", user.ID).
+			Pluck("image_url", &photoURLs).Error; err != nil {
+			return err
+		}
+		objects = append(objects, photoURLs...)
+	}
+	objects = append(objects, user.Avatar)
+	for _, url := range user.AvatarVariants {
+		objects = append(objects, url)
+	}
+
+	// 先吊销令牌，吊销失败时不删除账号，下次清理时重试
+	if err := revokeUserTokens(user); err != nil {
+		return err
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.OperationLog{}).Error; err != nil {
+			return err
+		}
+		if err := anonymizeComments(tx, user.ID, user.DeletionMode == AccountDeletionModeDelete); err != nil {
+			return err
+		}
+		if user.DeletionMode == AccountDeletionModeDelete {
+			return deleteAccountContent(tx, user)
+		}
+		return anonymizeAccount(tx, user)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, url := range objects {
+		if bucketName, objectName, ok := oss.ParsePublicURLMinio(url); ok {
+			_ = oss.DeleteFileFromBucketMinio(bucketName, objectName)
+		}
+	}
+	for _, objectName := range exportObjects {
+		_ = oss.DeleteFileFromBucketMinio(dataExportBucket, objectName)
+	}
+	return nil
+}
+
+// anonymizeComments 处理用户发表的评论
+// 删除内容时评论保留为占位，匿名化时只解除与账号的关联，评论显示为已注销用户
+func anonymizeComments(tx *gorm.DB, userID uuid.UUID, removeContent bool) error {
+	updates := map[string]interface{}{
+		"user_id":    nil,
+		"guest_name": deletedUserNickname,
+	}
+	if removeContent {
+		var commentIDs []uuid.UUID
+		if err := tx.Model(&models.ArticleComment{}).Where("user_id = ?", userID).Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if len(commentIDs) > 0 {
+			if err := tx.Unscoped().Where("comment_id IN ?", commentIDs).Delete(&models.CommentRevision{}).Error; err != nil {
+				return err
+			}
+		}
+		updates["content"] = ""
+		updates["is_deleted"] = true
+	}
+	if err := tx.Model(&models.ArticleComment{}).Where("user_id = ?", userID).Updates(updates).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("mentioner_user_id = ?", userID).Delete(&models.Mention{}).Error
+}
+
+// deleteAccountContent 删除用户的文章、文章下的评论和照片记录，然后删除账号
+func deleteAccountContent(tx *gorm.DB, user *models.User) error {
+	var articleIDs []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Article{}).Where("user_id = ?", user.ID).Pluck("id", &articleIDs).Error; err != nil {
+		return err
+	}
+	if len(articleIDs) > 0 {
+		if err := tx.Unscoped().Where("article_id IN ?", articleIDs).Delete(&models.ArticleComment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("source_type = ? AND source_id IN ?", models.MentionSourceArticle, articleIDs).
+			Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", articleIDs).Delete(&models.Article{}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.DailyPhotograph{}).Error; err != nil {
+		return err
+	}
+	// 会话、通行密钥、第三方账号、访问令牌、通知和导出记录由外键级联删除
+	return tx.Unscoped().Delete(user).Error
+}
+
+// anonymizeAccount 清除账号上的个人信息，删除登录方式后软删除账号
+// 文章和照片仍然关联到该账号，作者显示为已注销用户
+func anonymizeAccount(tx *gorm.DB, user *models.User) error {
+	for _, model := range []interface{}{
+		&models.UserSession{},
+		&models.RecoveryCode{},
+		&models.Passkey{},
+		&models.UserIdentity{},
+		&models.PersonalAccessToken{},
+		&models.Notification{},
+		&models.DataExport{},
+	} {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("mentioned_user_id = ?", user.ID).Delete(&models.Mention{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("follower_id = ? OR followee_id = ?", user.ID, user.ID).Delete(&models.Follow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.LoginRecord{}).Error; err != nil {
+		return err
+	}
+	// 已删除用户的邀请码不能再使用
+	if err := tx.Model(&models.InviteCode{}).Where("created_by = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"user_name":             "deleted-" + user.ID.String(),
+		"nickname":              deletedUserNickname,
+		"email":                 "",
+		"phone":                 "",
+		"password":              "",
+		"avatar":                "",
+		"avatar_variants":       nil,
+		"bio":                   "",
+		"website":               "",
+		"location":              "",
+		"birthday":              "",
+		"gender":                "",
+		"status":                "inactive",
+		"is_active":             false,
+		"two_factor_enabled":    false,
+		"two_factor_secret":     "",
+		"two_factor_enabled_at": nil,
+		"deletion_scheduled_at": nil,
+		"deleted_by":            user.ID.String(),
+		"deleted_reason":        "用户注销账号",
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(user).Error
+}
+
+// accountDeletionGrace 申请注销后的冷静期
+func accountDeletionGrace() time.Duration {
+	if config.Conf.Account.DeletionGraceDays > 0 {
+		return time.Duration(config.Conf.Account.DeletionGraceDays) * 24 * time.Hour
+	}
+	return defaultAccountDeletionGrace
+}
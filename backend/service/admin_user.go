@@ -0,0 +1,425 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 管理员操作用户的审计日志类型
+const (
+	AdminOperationSuspend            = "admin_user_suspend"              // 封禁用户
+	AdminOperationReactivate         = "admin_user_reactivate"           // 解除封禁
+	AdminOperationUnlock             = "admin_user_unlock"               // 解除锁定
+	AdminOperationChangeRole         = "admin_user_change_role"          // 修改角色
+	AdminOperationForcePasswordReset = "admin_user_force_password_reset" // 要求重置密码
+	AdminOperationDelete             = "admin_user_delete"               // 删除用户
+)
+
+// AdminOperator 执行管理操作的管理员，用于记录审计日志
+type AdminOperator struct {
+	ID        uuid.UUID // 管理员ID
+	UserName  string    // 管理员用户名
+	IP        string    // 请求IP
+	UserAgent string    // 用户代理
+}
+
+// AdminUser 管理员查看的用户信息，包含普通接口不返回的状态字段
+type AdminUser struct {
+	ID                    uuid.UUID  `json:"id"`                      // 用户ID
+	UserName              string     `json:"user_name"`               // 用户名
+	Nickname              string     `json:"nickname"`                // 昵称
+	Email                 string     `json:"email"`                   // 邮箱
+	Avatar                string     `json:"avatar"`                  // 头像
+	Role                  string     `json:"role"`                    // 角色
+	Status                string     `json:"status"`                  // 状态：active, inactive, suspended
+	IsActive              bool       `json:"is_active"`               // 是否激活
+	IsLocked              bool       `json:"is_locked"`               // 是否因密码错误次数过多被锁定
+	LockUntil             time.Time  `json:"lock_until"`              // 锁定截止时间，没有锁定时为零值
+	TwoFactorEnabled      bool       `json:"two_factor_enabled"`      // 是否开启两步验证
+	PasswordResetRequired bool       `json:"password_reset_required"` // 是否需要重置密码后才能登录
+	LoginCount            int        `json:"login_count"`             // 登录次数
+	LastLogin             time.Time  `json:"last_login"`              // 上次登录时间
+	FailedLoginCount      int        `json:"failed_login_count"`      // 限流窗口内失败登录的次数
+	LastFailedLogin       time.Time  `json:"last_failed_login"`       // 上次失败登录时间
+	CreatedAt             time.Time  `json:"created_at"`              // 注册时间
+	InvitedBy             *uuid.UUID `json:"invited_by"`              // 邀请人ID，不是通过邀请码注册时为空
+	InviteCodeID          *uuid.UUID `json:"invite_code_id"`          // 注册时使用的邀请码ID
+	DeletedAt             *time.Time `json:"deleted_at"`              // 删除时间，未删除时为空
+	DeletedBy             string     `json:"deleted_by"`              // 删除人
+	DeletedReason         string     `json:"deleted_reason"`          // 删除原因
+}
+
+// newAdminUser 转换为管理员查看的用户信息，锁定状态和失败登录记录从Redis的登录限流记录中读取
+func newAdminUser(user *models.User) AdminUser {
+	result := AdminUser{
+		ID:                    user.ID,
+		UserName:              user.UserName,
+		Nickname:              user.Nickname,
+		Email:                 user.Email,
+		Avatar:                user.Avatar,
+		Role:                  user.Role,
+		Status:                user.Status,
+		IsActive:              user.IsActive,
+		TwoFactorEnabled:      user.TwoFactorEnabled,
+		PasswordResetRequired: user.PasswordResetRequired,
+		LoginCount:            user.LoginCount,
+		LastLogin:             user.LastLogin,
+		CreatedAt:             user.CreatedAt,
+		InvitedBy:             user.InvitedBy,
+		InviteCodeID:          user.InviteCodeID,
+		DeletedBy:             user.DeletedBy,
+		DeletedReason:         user.DeletedReason,
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		result.DeletedAt = &deletedAt
+	}
+	// Redis不可用时只是无法显示锁定状态，不影响查看用户
+	if status, err := userThrottleStatus(user); err != nil {
+		logger.Logger.Errorf("get login throttle status failed: %v", err)
+	} else {
+		result.IsLocked = status.Locked()
+		result.LockUntil = status.LockUntil
+		result.FailedLoginCount = status.FailedLoginCount
+		result.LastFailedLogin = status.LastFailedLogin
+	}
+	return result
+}
+
+// AdminListUsersService 管理员查询用户服务结构体
+type AdminListUsersService struct {
+	Keyword string `form:"keyword"`                                                    // 用户名、昵称或邮箱关键词
+	Role    string `form:"role"`                                                       // 角色筛选
+	Status  string `form:"status" binding:"omitempty,oneof=active inactive suspended"` // 状态筛选
+	Locked  bool   `form:"locked"`                                                     // 只返回被锁定的用户
+	Deleted bool   `form:"deleted"`                                                    // 只返回已删除的用户
+	Page    int    `form:"page"`                                                       // 页码，默认为1
+	Size    int    `form:"size"`                                                       // 每页数量，默认为20，最大为100
+}
+
+// List 按条件查询用户，按注册时间倒序
+func (service *AdminListUsersService) List() ([]AdminUser, int64, error) {
+	if service.Page <= 0 {
+		service.Page = 1
+	}
+	if service.Size <= 0 {
+		service.Size = 20
+	}
+	if service.Size > 100 {
+		service.Size = 100
+	}
+
+	query := models.DB.Model(&models.User{})
+	if service.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if keyword := strings.TrimSpace(service.Keyword); keyword != "" {
+		like := "%" + strings.ToLower(keyword) + "%"
+		query = query.Where("(LOWER(user_name) LIKE ? OR LOWER(nickname) LIKE ? OR LOWER(email) LIKE ?)", like, like, like)
+	}
+	if service.Role != "" {
+		query = query.Where("role = ?", service.Role)
+	}
+	if service.Status != "" {
+		query = query.Where("status = ?", service.Status)
+	}
+	if service.Locked {
+		ids, err := lockedUserIDs()
+		if err != nil {
+			logger.Logger.Errorf("list locked users failed: %v", err)
+			return nil, 0, code.ErrDatabase
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count users failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var users []models.User
+	offset := (service.Page - 1) * service.Size
+	if err := query.Order("created_at DESC").Offset(offset).Limit(service.Size).Find(&users).Error; err != nil {
+		logger.Logger.Errorf("list users failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+
+	result := make([]AdminUser, 0, len(users))
+	for i := range users {
+		result = append(result, newAdminUser(&users[i]))
+	}
+	return result, total, nil
+}
+
+// AdminGetUserService 管理员获取用户详情服务结构体
+type AdminGetUserService struct {
+	ID string `uri:"id" binding:"required,uuid"` // 用户ID
+}
+
+// Get 获取用户详情，包括已删除的用户
+func (service *AdminGetUserService) Get() (*AdminUser, error) {
+	user, err := findUserForAdmin(service.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	result := newAdminUser(user)
+	return &result, nil
+}
+
+// AdminLoginRecord 管理员查看的登录记录，包括失败的登录
+// 登录成功时附带创建的会话的状态
+type AdminLoginRecord struct {
+	models.LoginRecord
+	SessionActive    bool       `json:"session_active"`     // 登录创建的会话是否仍然有效
+	SessionRevokedAt *time.Time `json:"session_revoked_at"` // 会话的吊销时间，包括退出登录
+}
+
+// AdminListLoginsService 管理员查看用户登录记录服务结构体
+type AdminListLoginsService struct {
+	ID   string `uri:"id" binding:"required,uuid"` // 用户ID
+	Page int    `form:"page"`                      // 页码，默认为1
+	Size int    `form:"size"`                      // 每页数量，默认为20，最大为100
+}
+
+// List 返回用户的登录记录，包括失败的登录，按登录时间倒序
+func (service *AdminListLoginsService) List() ([]AdminLoginRecord, int64, error) {
+	if service.Page <= 0 {
+		service.Page = 1
+	}
+	if service.Size <= 0 {
+		service.Size = 20
+	}
+	if service.Size > 100 {
+		service.Size = 100
+	}
+
+	query := models.DB.Model(&models.LoginRecord{}).Where("user_id = ?", service.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count login records failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var logins []models.LoginRecord
+	offset := (service.Page - 1) * service.Size
+	if err := query.Order("logged_at DESC").Offset(offset).Limit(service.Size).Find(&logins).Error; err != nil {
+		logger.Logger.Errorf("list login records failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+
+	// 查询登录成功时创建的会话
+	sessionIDs := make([]uuid.UUID, 0, len(logins))
+	for _, login := range logins {
+		if login.SessionID != nil {
+			sessionIDs = append(sessionIDs, *login.SessionID)
+		}
+	}
+	sessions := make(map[uuid.UUID]models.UserSession, len(sessionIDs))
+	if len(sessionIDs) > 0 {
+		var rows []models.UserSession
+		if err := models.DB.Where("id IN ?", sessionIDs).Find(&rows).Error; err != nil {
+			logger.Logger.Errorf("list user sessions failed: %v", err)
+			return nil, 0, code.ErrDatabase
+		}
+		for _, session := range rows {
+			sessions[session.ID] = session
+		}
+	}
+
+	records := make([]AdminLoginRecord, 0, len(logins))
+	for _, login := range logins {
+		record := AdminLoginRecord{LoginRecord: login}
+		if login.SessionID != nil {
+			if session, ok := sessions[*login.SessionID]; ok {
+				record.SessionActive = session.IsActive()
+				record.SessionRevokedAt = session.RevokedAt
+			}
+		}
+		records = append(records, record)
+	}
+	return records, total, nil
+}
+
+// AdminListAuditLogsService 管理员查看用户审计日志服务结构体
+type AdminListAuditLogsService struct {
+	ID   string `uri:"id" binding:"required,uuid"` // 用户ID
+	Page int    `form:"page"`                      // 页码，默认为1
+	Size int    `form:"size"`                      // 每页数量，默认为20，最大为100
+}
+
+// List 返回管理员对该用户执行过的操作，按时间倒序
+func (service *AdminListAuditLogsService) List() ([]models.OperationLog, int64, error) {
+	if service.Page <= 0 {
+		service.Page = 1
+	}
+	if service.Size <= 0 {
+		service.Size = 20
+	}
+	if service.Size > 100 {
+		service.Size = 100
+	}
+
+	query := models.DB.Model(&models.OperationLog{}).Where("target_id = ?", service.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count audit logs failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var logs []models.OperationLog
+	offset := (service.Page - 1) * service.Size
+	if err := query.Order("operation_time DESC").Offset(offset).Limit(service.Size).Find(&logs).Error; err != nil {
+		logger.Logger.Errorf("list audit logs failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	return logs, total, nil
+}
+
+// AdminUserActionService 管理员操作用户服务结构体
+// 封禁、解除封禁、解锁、要求重置密码和删除共用，每次操作无论成功与否都记录审计日志
+type AdminUserActionService struct {
+	ID       string        `uri:"id" json:"-" binding:"required,uuid"` // 用户ID，从URL路径获取
+	Reason   string        `json:"reason" binding:"max=255"`           // 操作原因，删除用户时必填
+	Operator AdminOperator `uri:"-" json:"-"`                          // 执行操作的管理员
+}
+
+// Suspend 封禁用户，并吊销用户已签发的令牌
+func (service *AdminUserActionService) Suspend() error {
+	return service.run(AdminOperationSuspend, "封禁用户", true, func(user *models.User) error {
+		if err := models.DB.Model(user).Update("status", "suspended").Error; err != nil {
+			logger.Logger.Errorf("suspend user failed: %v", err)
+			return code.ErrDatabase
+		}
+		return revokeUserTokens(user)
+	})
+}
+
+// Reactivate 解除封禁
+func (service *AdminUserActionService) Reactivate() error {
+	return service.run(AdminOperationReactivate, "解除封禁", true, func(user *models.User) error {
+		if err := models.DB.Model(user).Update("status", "active").Error; err != nil {
+			logger.Logger.Errorf("reactivate user failed: %v", err)
+			return code.ErrDatabase
+		}
+		return nil
+	})
+}
+
+// Unlock 解除因密码错误导致的锁定，清空账号的失败登录记录
+// IP的失败记录不受影响
+func (service *AdminUserActionService) Unlock() error {
+	return service.run(AdminOperationUnlock, "解除锁定", false, func(user *models.User) error {
+		if err := clearUserThrottle(user); err != nil {
+			logger.Logger.Errorf("clear login throttle failed: %v", err)
+			return code.ErrDatabase
+		}
+		return nil
+	})
+}
+
+// ForcePasswordReset 要求用户重置密码
+// 吊销用户已签发的令牌并发送重置密码邮件，重置密码前所有登录方式都会被拒绝
+func (service *AdminUserActionService) ForcePasswordReset() error {
+	return service.run(AdminOperationForcePasswordReset, "要求重置密码", true, forcePasswordReset)
+}
+
+// Delete 软删除用户，记录删除人和删除原因，并吊销用户已签发的令牌
+func (service *AdminUserActionService) Delete() error {
+	if strings.TrimSpace(service.Reason) == "" {
+		return code.ErrParam
+	}
+	return service.run(AdminOperationDelete, "删除用户", true, func(user *models.User) error {
+		err := models.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(user).Updates(map[string]interface{}{
+				"deleted_by":     service.Operator.UserName,
+				"deleted_reason": service.Reason,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(user).Error
+		})
+		if err != nil {
+			logger.Logger.Errorf("delete user failed: %v", err)
+			return code.ErrDatabase
+		}
+		return revokeUserTokens(user)
+	})
+}
+
+// run 查询用户、执行操作并记录审计日志
+// protectSelf为true时不允许管理员对自己执行该操作，避免误操作后无法登录
+func (service *AdminUserActionService) run(operationType, operationDesc string, protectSelf bool, action func(user *models.User) error) error {
+	user, err := findUserForAdmin(service.ID, false)
+	if err == nil && protectSelf && user.ID == service.Operator.ID {
+		err = code.ErrAdminSelfOperation
+	}
+	if err == nil {
+		err = action(user)
+	}
+	auditAdminOperation(service.Operator, service.ID, user, operationType, operationDesc, map[string]string{"reason": service.Reason}, err)
+	return err
+}
+
+// AdminChangeRoleService 管理员修改用户角色服务结构体
+type AdminChangeRoleService struct {
+	ID       string        `uri:"id" json:"-" binding:"required,uuid"` // 用户ID，从URL路径获取
+	Role     string        `json:"role" binding:"required"`            // 新角色名称
+	Operator AdminOperator `uri:"-" json:"-"`                          // 执行操作的管理员
+}
+
+// ChangeRole 修改用户角色，不能修改自己的角色
+func (service *AdminChangeRoleService) ChangeRole() error {
+	assign := AssignRoleService{Name: service.Role, UserID: service.ID, Operator: service.Operator}
+	return assign.Assign()
+}
+
+// findUserForAdmin 根据ID获取用户，unscoped为true时包括已删除的用户
+func findUserForAdmin(id string, unscoped bool) (*models.User, error) {
+	query := models.DB
+	if unscoped {
+		query = query.Unscoped()
+	}
+	var user models.User
+	if err := query.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrUserNotFound
+		}
+		logger.Logger.Errorf("get user failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &user, nil
+}
+
+// auditAdminOperation 记录管理员操作用户的审计日志，记录失败只写错误日志
+func auditAdminOperation(operator AdminOperator, targetID string, target *models.User, operationType, operationDesc string, data map[string]string, opErr error) {
+	targetName := targetID
+	if target != nil {
+		targetName = target.UserName
+	}
+	requestData, _ := json.Marshal(data)
+	logService := &CreateOperationLogService{
+		UserID:        operator.ID,
+		UserName:      operator.UserName,
+		OperationType: operationType,
+		OperationDesc: fmt.Sprintf("%s: %s", operationDesc, targetName),
+		TargetID:      targetID,
+		RequestIP:     operator.IP,
+		UserAgent:     operator.UserAgent,
+		RequestData:   string(requestData),
+		Status:        "success",
+	}
+	if opErr != nil {
+		logService.Status = "failed"
+		_, logService.ErrorMessage = code.DecodeErr(opErr)
+	}
+	if err := logService.Create(); err != nil {
+		logger.Logger.Errorf("create admin audit log failed: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func TestNewAdminUser(t *testing.T) {
+	newTestRedis(t)
+	user := &models.User{}
+	user.ID = uuid.New()
+	if result := newAdminUser(user); result.IsLocked || result.FailedLoginCount != 0 {
+		t.Errorf("IsLocked = %v, FailedLoginCount = %d for a user without failures", result.IsLocked, result.FailedLoginCount)
+	}
+
+	// 等待时间已过的锁定
+	addTestLoginFailures(t, user, defaultThrottleAccountFreeFailures+1, time.Now().Add(-time.Minute))
+	expired := newAdminUser(user)
+	if expired.IsLocked {
+		t.Errorf("IsLocked = true for an expired lock, want false")
+	}
+	if expired.FailedLoginCount != defaultThrottleAccountFreeFailures+1 {
+		t.Errorf("FailedLoginCount = %d, want %d", expired.FailedLoginCount, defaultThrottleAccountFreeFailures+1)
+	}
+
+	// 失败次数超过允许的次数后锁定
+	addTestLoginFailures(t, user, 1, time.Now())
+	locked := newAdminUser(user)
+	if !locked.IsLocked || !locked.LockUntil.After(time.Now()) {
+		t.Errorf("IsLocked = %v, LockUntil = %v for an active lock, want locked", locked.IsLocked, locked.LockUntil)
+	}
+
+	if newAdminUser(&models.User{}).DeletedAt != nil {
+		t.Errorf("DeletedAt is set for a user that is not deleted")
+	}
+	deletedAt := time.Now()
+	deleted := &models.User{DeletedBy: "admin", DeletedReason: "spam"}
+	deleted.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+	result := newAdminUser(deleted)
+	if result.DeletedAt == nil || !result.DeletedAt.Equal(deletedAt) {
+		t.Errorf("DeletedAt = %v, want %v", result.DeletedAt, deletedAt)
+	}
+	if result.DeletedBy != "admin" || result.DeletedReason != "spam" {
+		t.Errorf("deleted by = %q, reason = %q", result.DeletedBy, result.DeletedReason)
+	}
+}
+
+// addTestLoginFailures 在Redis中为用户记录count次发生在at的失败登录
+func addTestLoginFailures(t *testing.T, user *models.User, count int, at time.Time) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		member := goredis.Z{Score: float64(at.UnixMilli()), Member: randomHex(8)}
+		if err := redis.GetRedisClient().ZAdd(context.Background(), userThrottleKey(user), member).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAdminListLockedUsersAndUnlock(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.OperationLog{})
+	newTestRedis(t)
+	alice := createTestUser(t, "alice")
+	createTestUser(t, "bob")
+	carol := createTestUser(t, "carol")
+	addTestLoginFailures(t, alice, defaultThrottleAccountFreeFailures+1, time.Now())
+	addTestLoginFailures(t, carol, defaultThrottleAccountFreeFailures+1, time.Now().Add(-time.Minute))
+
+	list := &AdminListUsersService{Locked: true}
+	users, total, err := list.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 1 || len(users) != 1 || users[0].ID != alice.ID || !users[0].IsLocked {
+		t.Fatalf("locked users = %+v, total = %d, want alice", users, total)
+	}
+
+	unlock := &AdminUserActionService{ID: alice.ID.String()}
+	if err := unlock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if status, err := userThrottleStatus(alice); err != nil || status.Locked() || status.FailedLoginCount != 0 {
+		t.Errorf("throttle status after unlock = %+v, %v, want cleared", status, err)
+	}
+	if _, total, err := list.List(); err != nil || total != 0 {
+		t.Errorf("locked users after unlock = %d, %v, want 0", total, err)
+	}
+}
+
+func TestAdminListLogins(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.UserSession{}, &models.LoginRecord{})
+	newTestRedis(t)
+	user := createTestUser(t, "alice")
+
+	session := &models.UserSession{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour), LastSeenAt: time.Now()}
+	if err := models.DB.Create(session).Error; err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	records := []models.LoginRecord{
+		{UserID: user.ID, SessionID: &session.ID, Method: models.LoginMethodPassword, Success: true, LoggedAt: now.Add(-time.Hour)},
+		{UserID: user.ID, Method: models.LoginMethodPassword, FailureReason: "password incorrect", LoggedAt: now},
+	}
+	if err := models.DB.Create(&records).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	logins, total, err := (&AdminListLoginsService{ID: user.ID.String()}).List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 2 || len(logins) != 2 {
+		t.Fatalf("logins = %+v, total = %d, want 2", logins, total)
+	}
+	// 失败的登录也会返回，按登录时间倒序
+	if logins[0].Success || logins[0].FailureReason != "password incorrect" || logins[0].SessionActive {
+		t.Errorf("first login = %+v, want the failed login", logins[0])
+	}
+	if !logins[1].Success || !logins[1].SessionActive || logins[1].SessionRevokedAt != nil {
+		t.Errorf("second login = %+v, want the successful login with an active session", logins[1])
+	}
+
+	// 退出登录后会话不再有效
+	if err := revokeSession(session); err != nil {
+		t.Fatal(err)
+	}
+	logins, _, err = (&AdminListLoginsService{ID: user.ID.String()}).List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if logins[1].SessionActive || logins[1].SessionRevokedAt == nil {
+		t.Errorf("login after logout = %+v, want a revoked session", logins[1])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"blog-server/internal/code"
 	"blog-server/internal/models"
 	"blog-server/internal/oss"
+	"blog-server/internal/rbac"
 	"blog-server/internal/redis"
 	"blog-server/internal/logger"
 	"bytes"
@@ -262,6 +263,9 @@ func (s *CreateArticleService) Create(c *gin.Context) (*models.Article, error) {
 		return nil, code.ErrArticleCreateFailed
 	}
 
+	// 同步文章中的@提及
+	syncArticleMentions(article)
+
 	// 记录操作日志 - 创建成功
 	if c != nil {
 		go func() {
@@ -283,6 +287,7 @@ type UpdateArticleService struct {
 	Tags       []string             `json:"tags"`                        // 文章标签数组，可选
 	CoverImage string               `json:"cover_image"`                 // 文章封面图片URL，可选
 	UserID     string               `json:"user_id"`                     // 作者用户ID，用于权限验证
+	Role       string               `json:"-"`                           // 当前用户角色，从JWT中获取
 }
 
 // Update 更新现有文章
@@ -330,8 +335,12 @@ func (s *UpdateArticleService) Update(c *gin.Context) (*models.Article, error) {
 		return nil, code.ErrArticleGetFailed
 	}
 
-	// 检查权限：只有文章作者可以更新文章
-	if article.UserID != userID {
+	// 检查权限：文章作者或拥有文章管理权限的用户可以更新文章
+	allowed, err := rbac.CanManage(s.Role, userID, article.UserID, rbac.PermArticlesWrite, rbac.PermArticlesModerate)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
 		// 记录操作日志 - 权限不足
 		if c != nil {
 			go func() {
@@ -415,6 +424,9 @@ func (s *UpdateArticleService) Update(c *gin.Context) (*models.Article, error) {
 		return nil, code.ErrArticleUpdateFailed
 	}
 
+	// 同步文章中的@提及
+	syncArticleMentions(&article)
+
 	// 记录操作日志 - 更新成功
 	if c != nil {
 		go func() {
@@ -430,6 +442,7 @@ func (s *UpdateArticleService) Update(c *gin.Context) (*models.Article, error) {
 type DeleteArticleService struct {
 	ID     string    `uri:"id" binding:"required"` // 文章ID，从URL路径获取，必填
 	UserID uuid.UUID `json:"user_id"`              // 作者用户ID，用于权限验证
+	Role   string    `json:"-"`                    // 当前用户角色，从JWT中获取
 }
 
 // Delete 删除指定文章
@@ -465,8 +478,12 @@ func (s *DeleteArticleService) Delete(c *gin.Context) error {
 		return code.ErrArticleGetFailed
 	}
 
-	// 检查权限：只有文章作者可以删除文章
-	if article.UserID != s.UserID {
+	// 检查权限：文章作者或拥有文章管理权限的用户可以删除文章
+	allowed, err := rbac.CanManage(s.Role, s.UserID, article.UserID, rbac.PermArticlesWrite, rbac.PermArticlesModerate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		// 记录操作日志 - 权限不足
 		if c != nil {
 			go func() {
@@ -556,7 +573,7 @@ func (service *GetArticlesByRoleService) GetSortOrder() string {
 }
 
 // GetArticlesByRole 根据用户角色获取文章
-// 管理员返回所有用户的全部文章，非管理员返回用户自己创建的文章
+// 拥有文章管理权限的角色返回所有用户的全部文章，其他角色返回用户自己创建的文章
 // 支持分页查询、筛选和自定义排序
 // 
 // 排序参数使用说明：
@@ -576,8 +593,12 @@ func (service *GetArticlesByRoleService) GetArticlesByRole(userID string, userRo
 	query := postgreDB.Model(&models.Article{})
 
 	// 根据用户角色添加筛选条件
-	if userRole != "admin" {
-		// 非管理员只能查看自己的文章
+	canModerate, err := rbac.HasPermission(userRole, rbac.PermArticlesModerate)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !canModerate {
+		// 没有文章管理权限的用户只能查看自己的文章
 		query = query.Where("user_id = ?", userID)
 	}
 
@@ -715,6 +736,9 @@ func (s *GetArticleService) Get(c *gin.Context) (*models.Article, error) {
 		logger.Logger.Errorf("记录文章访问尝试失败: %v", err)
 	}
 
+	// 渲染@提及链接
+	article.RenderedContent = RenderMentionLinks(article.Content)[0]
+
 	return &article, nil
 }
 
@@ -936,6 +960,7 @@ type UpdateArticleStatusService struct {
 	ID     string               `uri:"id" json:"id" binding:"required"`      // 文章ID，从URL路径获取，必填
 	Status models.ArticleStatus `json:"status" binding:"required"` // 新的文章状态，必填
 	UserID string               `json:"user_id"`                   // 作者用户ID，用于权限验证
+	Role   string               `json:"-"`                         // 当前用户角色，从JWT中获取
 }
 
 // UpdateStatus 更新文章状态
@@ -999,7 +1024,11 @@ func (s *UpdateArticleStatusService) UpdateStatus(c *gin.Context) error {
 	oldStatus := article.Status
 
 	// 检查用户是否有权限更新文章状态
-	if article.UserID != userID {
+	allowed, err := rbac.CanManage(s.Role, userID, article.UserID, rbac.PermArticlesWrite, rbac.PermArticlesModerate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		// 记录操作日志 - 权限不足
 		if c != nil {
 			go func() {
@@ -1031,6 +1060,10 @@ func (s *UpdateArticleStatusService) UpdateStatus(c *gin.Context) error {
 		return code.ErrArticleUpdateFailed
 	}
 
+	// 文章发布后补发之前未发送的@提及通知
+	article.Status = s.Status
+	syncArticleMentions(&article)
+
 	// 记录操作日志 - 更新成功
 	if c != nil {
 		go func() {
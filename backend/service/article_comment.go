@@ -1,17 +1,30 @@
 package service
 
 import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
 	"blog-server/internal/models"
+	"blog-server/internal/rbac"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	// "blog-server/internal/logger"
+	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
+// defaultCommentEditWindow 未配置时评论发布后允许编辑的时间
+const defaultCommentEditWindow = 15 * time.Minute
+
 // AddCommentService 添加评论服务结构体
 // 用于处理添加文章评论的请求和业务逻辑
 type AddCommentService struct {
-	Comment      string `json:"comment" form:"comment" binding:"required"`        // 评论内容，必填
-	ArticleTitle string `json:"article_title" form:"article_title" binding:"required"` // 文章标题，必填
+	Comment      string    `json:"comment" form:"comment" binding:"required"`             // 评论内容，必填
+	ArticleTitle string    `json:"article_title" form:"article_title" binding:"required"` // 文章标题，必填
+	ArticleID    string    `json:"article_id" form:"article_id"`                          // 文章ID，可选，用于实时推送
+	ParentID     string    `json:"parent_id" form:"parent_id"`                            // 父评论ID，回复评论时填写
+	UserID       uuid.UUID `json:"-" form:"-"`                                            // 评论者ID，登录用户从JWT中获取
 }
 
 // AddComment 添加新评论
@@ -23,14 +36,44 @@ func (ac *AddCommentService) AddComment() error {
 		Content:      ac.Comment,
 		ArticleTitle: ac.ArticleTitle,
 	}
-	
+	if ac.ArticleID != "" {
+		articleID, err := uuid.Parse(ac.ArticleID)
+		if err != nil {
+			return fmt.Errorf("无效的文章ID格式: %v", err)
+		}
+		comment.ArticleID = articleID
+	}
+
+	if ac.UserID != uuid.Nil {
+		comment.UserID = &ac.UserID
+	}
+	if ac.ParentID != "" {
+		parent, err := findComment(ac.ParentID)
+		if err != nil {
+			return fmt.Errorf("父评论不存在: %v", err)
+		}
+		if parent.IsDeleted {
+			return fmt.Errorf("无法回复已删除的评论")
+		}
+		// 回复与父评论属于同一篇文章
+		if comment.ArticleID == uuid.Nil {
+			comment.ArticleID = parent.ArticleID
+		} else if parent.ArticleID != uuid.Nil && parent.ArticleID != comment.ArticleID {
+			return fmt.Errorf("父评论不属于该文章")
+		}
+		comment.ParentID = &parent.ID
+	}
+
 	// logger.Logger.Infof("comment: %v, title: %v, comment: %v", ac.Comment, ac.ArticleTitle, comment)
 	// return nil
-	
+
 	// 保存评论到数据库
 	if err := models.DB.Create(&comment).Error; err != nil {
 		return err
 	}
+
+	// 同步评论中的@提及
+	syncCommentMentions(&comment, ac.UserID)
 	return nil
 }
 
@@ -39,6 +82,8 @@ func (ac *AddCommentService) AddComment() error {
 type ListCommentService struct {
 	// 评论ID
 	CommentID string `json:"comment_id" form:"comment_id"` // 评论ID，用于筛选特定评论
+	// 文章ID
+	ArticleID string `json:"article_id" form:"article_id"` // 文章ID，用于筛选特定文章的评论
 	// 文章标题
 	ArticleTitle string `json:"article_title" form:"article_title"` // 文章标题，用于筛选特定文章的评论
 	// 相关内容
@@ -63,12 +108,21 @@ func (lc *ListCommentService) ListComment() ([]models.ArticleComment, error) {
 	db := models.DB
 
 	fmt.Println(lc)
-	
+
 	// 如果提供了文章标题，添加标题过滤条件
 	if lc.ArticleTitle != "" {
 		db = db.Where("article_title LIKE ?", "%"+lc.ArticleTitle+"%")
 	}
 
+	// 如果提供了文章ID，添加文章ID过滤条件
+	if lc.ArticleID != "" {
+		articleID, err := uuid.Parse(lc.ArticleID)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文章ID格式: %v", err)
+		}
+		db = db.Where("article_id = ?", articleID)
+	}
+
 	// 如果提供了评论ID，添加ID过滤条件
 	if lc.CommentID != "" {
 		// 将字符串类型的CommentID转换为UUID类型
@@ -115,5 +169,292 @@ func (lc *ListCommentService) ListComment() ([]models.ArticleComment, error) {
 		return nil, err
 	}
 
+	// 渲染@提及链接
+	contents := make([]string, len(comments))
+	for i := range comments {
+		contents[i] = comments[i].Content
+	}
+	for i, rendered := range RenderMentionLinks(contents...) {
+		comments[i].RenderedContent = rendered
+	}
+
 	return comments, nil
 }
+
+// ApproveCommentService 审核评论服务结构体
+// 拥有评论管理权限的用户或评论所属文章的作者可以审核评论
+type ApproveCommentService struct {
+	ID     string    `uri:"id" binding:"required"` // 评论ID，从URL路径获取
+	UserID uuid.UUID `json:"-"`                    // 当前用户ID，从JWT中获取
+	Role   string    `json:"-"`                    // 当前用户角色，从JWT中获取
+}
+
+// Approve 审核通过评论，通知评论中提及的用户，并向正在阅读该文章的客户端广播
+func (service *ApproveCommentService) Approve() (*models.ArticleComment, error) {
+	comment, err := findComment(service.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := canModerateComment(comment, service.UserID, service.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, code.ErrArticleCommentPermission
+	}
+
+	if comment.IsPass {
+		return comment, nil
+	}
+	if err := models.DB.Model(comment).Update("is_pass", true).Error; err != nil {
+		logger.Logger.Errorf("approve comment failed: %v", err)
+		return nil, code.ErrArticleCommentUpdateFailed
+	}
+	comment.IsPass = true
+
+	// 审核通过后才通知评论中提及的用户
+	var mentionerID uuid.UUID
+	if comment.UserID != nil {
+		mentionerID = *comment.UserID
+	}
+	syncCommentMentions(comment, mentionerID)
+	publishCommentEvent(CommentEventApproved, comment)
+	return comment, nil
+}
+
+// EditCommentService 编辑评论服务结构体
+// 评论者可以在发布后的一段时间内编辑自己的评论
+type EditCommentService struct {
+	ID      string    `uri:"id" binding:"required"`                               // 评论ID，从URL路径获取
+	Content string    `json:"content" form:"content" binding:"required,max=5000"` // 新的评论内容
+	UserID  uuid.UUID `json:"-" form:"-"`                                         // 当前用户ID，从JWT中获取
+}
+
+// Edit 编辑评论，编辑前的内容保存为历史版本
+func (service *EditCommentService) Edit() (*models.ArticleComment, error) {
+	comment, err := findComment(service.ID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID == nil || *comment.UserID != service.UserID {
+		return nil, code.ErrArticleCommentPermission
+	}
+	if comment.IsDeleted {
+		return nil, code.ErrArticleCommentDeleted
+	}
+	if time.Since(comment.CreatedAt) > commentEditWindow() {
+		return nil, code.ErrArticleCommentEditExpired
+	}
+
+	content := strings.TrimSpace(service.Content)
+	if content == "" {
+		return nil, code.ErrParam
+	}
+	if content == comment.Content {
+		return comment, nil
+	}
+
+	now := time.Now()
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		revision := models.CommentRevision{
+			CommentID: comment.ID,
+			EditorID:  service.UserID,
+			Content:   comment.Content,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(comment).Updates(map[string]interface{}{
+			"content":   content,
+			"is_edited": true,
+			"edited_at": now,
+		}).Error
+	})
+	if err != nil {
+		logger.Logger.Errorf("edit comment failed: %v", err)
+		return nil, code.ErrArticleCommentUpdateFailed
+	}
+	comment.Content = content
+	comment.IsEdited = true
+	comment.EditedAt = &now
+
+	syncCommentMentions(comment, service.UserID)
+	publishCommentEvent(CommentEventUpdated, comment)
+	return comment, nil
+}
+
+// DeleteCommentService 删除评论服务结构体
+// 评论者可以删除自己的评论，拥有评论管理权限的用户和文章作者可以删除文章下的任意评论
+type DeleteCommentService struct {
+	ID     string    `uri:"id" binding:"required"` // 评论ID，从URL路径获取
+	UserID uuid.UUID `json:"-"`                    // 当前用户ID，从JWT中获取
+	Role   string    `json:"-"`                    // 当前用户角色，从JWT中获取
+}
+
+// Delete 删除评论
+// 有回复的评论保留为占位，清空内容并标记为已删除；没有回复的评论直接删除，
+// 删除后如果父评论是没有其它回复的占位，也一并删除
+func (service *DeleteCommentService) Delete() error {
+	comment, err := findComment(service.ID)
+	if err != nil {
+		return err
+	}
+	if comment.IsDeleted {
+		return code.ErrArticleCommentDeleted
+	}
+
+	isOwner := comment.UserID != nil && *comment.UserID == service.UserID
+	if !isOwner {
+		allowed, err := canModerateComment(comment, service.UserID, service.Role)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return code.ErrArticleCommentPermission
+		}
+	}
+
+	var removed []*models.ArticleComment
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		var replies int64
+		if err := tx.Model(&models.ArticleComment{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+			return err
+		}
+		if replies > 0 {
+			removed = append(removed, comment)
+			return tombstoneComment(tx, comment)
+		}
+
+		// 删除评论，并向上清理已经没有回复的占位评论
+		current := comment
+		for {
+			if err := tx.Where("comment_id = ?", current.ID).Delete(&models.CommentRevision{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(current).Error; err != nil {
+				return err
+			}
+			removed = append(removed, current)
+			if current.ParentID == nil {
+				return nil
+			}
+
+			var parent models.ArticleComment
+			if err := tx.Where("id = ?", *current.ParentID).First(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			if !parent.IsDeleted {
+				return nil
+			}
+			if err := tx.Model(&models.ArticleComment{}).Where("parent_id = ?", parent.ID).Count(&replies).Error; err != nil {
+				return err
+			}
+			if replies > 0 {
+				return nil
+			}
+			current = &parent
+		}
+	})
+	if err != nil {
+		logger.Logger.Errorf("delete comment failed: %v", err)
+		return code.ErrArticleCommentDeleteFailed
+	}
+
+	for _, c := range removed {
+		c.Content = ""
+		c.IsDeleted = true
+		syncCommentMentions(c, uuid.Nil)
+		publishCommentEvent(CommentEventDeleted, c)
+	}
+	return nil
+}
+
+// ListCommentRevisionsService 评论历史版本服务结构体
+type ListCommentRevisionsService struct {
+	ID     string    `uri:"id" binding:"required"` // 评论ID，从URL路径获取
+	UserID uuid.UUID `json:"-"`                    // 当前用户ID，从JWT中获取
+	Role   string    `json:"-"`                    // 当前用户角色，从JWT中获取
+}
+
+// List 获取评论的历史版本，按编辑时间倒序
+// 评论者本人、拥有评论管理权限的用户和文章作者可以查看
+func (service *ListCommentRevisionsService) List() ([]models.CommentRevision, error) {
+	comment, err := findComment(service.ID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID == nil || *comment.UserID != service.UserID {
+		allowed, err := canModerateComment(comment, service.UserID, service.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, code.ErrArticleCommentPermission
+		}
+	}
+
+	var revisions []models.CommentRevision
+	if err := models.DB.Where("comment_id = ?", comment.ID).Order("created_at DESC").Find(&revisions).Error; err != nil {
+		logger.Logger.Errorf("list comment revisions failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return revisions, nil
+}
+
+// findComment 根据ID获取评论
+func findComment(id string) (*models.ArticleComment, error) {
+	commentID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, code.ErrParam
+	}
+
+	var comment models.ArticleComment
+	if err := models.DB.Where("id = ?", commentID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrArticleCommentNotFound
+		}
+		logger.Logger.Errorf("get comment failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &comment, nil
+}
+
+// canModerateComment 判断用户能否管理评论，拥有评论管理权限的角色可以管理所有评论，文章作者可以管理自己文章下的评论
+func canModerateComment(comment *models.ArticleComment, userID uuid.UUID, role string) (bool, error) {
+	allowed, err := rbac.HasPermission(role, rbac.PermCommentsModerate)
+	if err != nil || allowed {
+		return allowed, err
+	}
+	if comment.ArticleID == uuid.Nil {
+		return false, nil
+	}
+	var count int64
+	if err := models.DB.Model(&models.Article{}).Where("id = ? AND user_id = ?", comment.ArticleID, userID).Count(&count).Error; err != nil {
+		logger.Logger.Errorf("check article owner failed: %v", err)
+		return false, code.ErrDatabase
+	}
+	return count > 0, nil
+}
+
+// tombstoneComment 将评论保留为占位，清空内容和历史版本
+func tombstoneComment(tx *gorm.DB, comment *models.ArticleComment) error {
+	if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentRevision{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(comment).Updates(map[string]interface{}{
+		"content":    "",
+		"is_deleted": true,
+	}).Error
+}
+
+// commentEditWindow 评论发布后允许编辑的时间
+func commentEditWindow() time.Duration {
+	if config.Conf.Comment.EditWindow > 0 {
+		return time.Duration(config.Conf.Comment.EditWindow) * time.Minute
+	}
+	return defaultCommentEditWindow
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"blog-server/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCommentMentionNotifiedAfterApproval(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.ArticleComment{}, &models.Mention{}, &models.Notification{})
+	newTestRedis(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+
+	add := &AddCommentService{
+		Comment:      "@bob 看看这个",
+		ArticleTitle: `"title"`,
+		ArticleID:    uuid.NewString(),
+		UserID:       alice.ID,
+	}
+	if err := add.AddComment(); err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+
+	var comment models.ArticleComment
+	if err := models.DB.First(&comment).Error; err != nil {
+		t.Fatal(err)
+	}
+	var mention models.Mention
+	if err := models.DB.Where("source_id = ? AND mentioned_user_id = ?", comment.ID, bob.ID).First(&mention).Error; err != nil {
+		t.Fatalf("mention should be recorded before approval: %v", err)
+	}
+	if mention.Notified {
+		t.Error("mention should not be notified before approval")
+	}
+	if count := countTestNotifications(t, bob.ID); count != 0 {
+		t.Fatalf("notifications before approval = %d, want 0", count)
+	}
+
+	approve := &ApproveCommentService{ID: comment.ID.String(), UserID: alice.ID, Role: "admin"}
+	if _, err := approve.Approve(); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if count := countTestNotifications(t, bob.ID); count != 1 {
+		t.Fatalf("notifications after approval = %d, want 1", count)
+	}
+	var notification models.Notification
+	if err := models.DB.Where("user_id = ?", bob.ID).First(&notification).Error; err != nil {
+		t.Fatal(err)
+	}
+	if notification.Type != models.NotificationTypeMention || notification.ActorID != alice.ID || notification.SourceID != comment.ID {
+		t.Errorf("notification = %+v", notification)
+	}
+
+	// 再次审核不会重复通知
+	if _, err := approve.Approve(); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if count := countTestNotifications(t, bob.ID); count != 1 {
+		t.Errorf("notifications after second approval = %d, want 1", count)
+	}
+}
+
+// countTestNotifications 统计用户收到的通知数量
+func countTestNotifications(t *testing.T, userID uuid.UUID) int64 {
+	t.Helper()
+	var count int64
+	if err := models.DB.Model(&models.Notification{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
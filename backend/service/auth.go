@@ -5,95 +5,194 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
 	"blog-server/internal/code"
 	"blog-server/internal/models"
 	"blog-server/internal/utils"
-	"blog-server/internal/rsa"
-	"blog-server/internal/config"
-	"blog-server/internal/redis"
 	"blog-server/internal/logger"
+	"blog-server/internal/tokenstore"
 )
 
 // RefreshTokenService 刷新token服务结构体
 // 用于处理刷新访问令牌的请求和业务逻辑
 type RefreshTokenService struct {
-	Token string `json:"refresh_token" form:"refresh_token" binding:"required"` // 刷新令牌，必填
+	Token     string `json:"refresh_token" form:"refresh_token" binding:"required"` // 刷新令牌，必填
+	IP        string `json:"-" form:"-"`                                            // 请求IP，用于更新会话
+	UserAgent string `json:"-" form:"-"`                                            // 用户代理，用于更新会话
 }
 
 // RefreshToken 刷新访问令牌
-// 验证刷新令牌的有效性，检查用户状态，并生成新的访问令牌
-// 返回用户信息、新的访问令牌和可能的错误
-func (service *RefreshTokenService) RefreshToken() (*models.User, string, error) {
+// 验证刷新令牌的有效性，检查用户状态，生成新的访问令牌并轮换刷新令牌。
+// 一个会话的所有刷新令牌属于同一个令牌家族，已经轮换掉的刷新令牌再次使用时吊销整个家族
+// 返回用户信息、新的访问令牌、新的刷新令牌和可能的错误
+func (service *RefreshTokenService) RefreshToken() (*models.User, string, string, error) {
 	// 验证refresh token
-	claims, err := utils.ValidateJWT(service.Token, rsa.PublicKey)
+	claims, err := utils.ValidateJWT(service.Token)
 	if err != nil {
-		return nil, "", code.ErrRefreshTokenInvalid
+		return nil, "", "", code.ErrRefreshTokenInvalid
 	}
 
 	// 检查token类型是否为refresh
 	tokenType, ok := claims["type"].(string)
 	if !ok || tokenType != "refresh" {
-		return nil, "", code.ErrRefreshTokenInvalid
+		return nil, "", "", code.ErrRefreshTokenInvalid
 	}
 
 	// 获取用户ID
 	userID, ok := claims["sub"].(string)
 	if !ok {
-		return nil, "", code.ErrRefreshTokenInvalid
+		return nil, "", "", code.ErrRefreshTokenInvalid
 	}
 
 	// 查询用户
 	postgreDB := models.DB
 	var user models.User
 	if err = postgreDB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, "", code.ErrUserNotFound
+		return nil, "", "", code.ErrUserNotFound
 	}
 
 	// 验证用户状态
 	if user.Status == "suspended" {
-		return nil, "", code.ErrUserSuspended
+		return nil, "", "", code.ErrUserSuspended
 	}
 	if !user.IsActive {
-		return nil, "", code.ErrUserInactive
+		return nil, "", "", code.ErrUserInactive
+	}
+
+	// 检查refresh token是否已被吊销
+	if err := tokenstore.Check(context.Background(), claims); err != nil {
+		return nil, "", "", err
 	}
 
-	redisClient := redis.GetRedisClient()
+	// 检查会话(令牌家族)是否有效
+	sessionID, _ := claims[tokenstore.ClaimSession].(string)
+	session, err := findActiveSession(sessionID, userID)
+	if err != nil {
+		return nil, "", "", code.ErrRefreshTokenInvalid
+	}
+	version, _ := claims[tokenstore.ClaimVersion].(float64)
+	jti, _ := claims[tokenstore.ClaimJTI].(string)
 
-	// 检查refresh token是否在Redis中存在且匹配
-	storedRefreshToken, err := redisClient.Get(context.Background(), config.Conf.Redis.KeyPrefix+":refresh_token:"+user.Email).Result()
-	// 如果不存在，或者不匹配，返回错误
-	if err == redis.Nil || storedRefreshToken != service.Token {
-		return nil, "", code.ErrRefreshTokenInvalid
+	// 同一个refresh token只能轮换一次，并发的重复请求在宽限期内得到相同的结果
+	rotation, claimed, err := claimRefreshRotation(jti)
+	if err == code.ErrRefreshTokenInvalid {
+		return nil, "", "", err
 	}
 	if err != nil {
-		logger.Logger.Errorf("redis get refresh token failed: %v", err)
-		return nil, "", code.ErrDatabase
-	}
-
-	// 生成新的Access Token
-	accessClaims := jwt.MapClaims{
-		"iss":  "moity", // 签发者
-		"sub":  user.ID.String(),  // 主题（用户ID）- 将UUID转换为字符串
-		"type": "access", // 令牌类型
-		"role": user.Role, // 用户角色
-		"exp":  time.Now().Add(time.Duration(config.Conf.App.JwtExpirationTime) * time.Minute).Unix(), // 过期时间
-		"nbf":  time.Now().Unix(), // 生效时间
-		"iat":  time.Now().Unix(), // 签发时间
-		"username": user.UserName, // 用户名
-	}
-	accessJwtToken, err := utils.GenerateJWT(accessClaims, rsa.PrivateKey)
+		return nil, "", "", code.ErrDatabase
+	}
+	if !claimed {
+		return &user, rotation.AccessToken, rotation.RefreshToken, nil
+	}
+	// 已经轮换掉的refresh token再次出现，吊销整个令牌家族
+	if jti != session.RefreshJTI {
+		releaseRefreshRotation(jti)
+		return nil, "", "", service.handleRefreshTokenReuse(&user, session)
+	}
+
+	// 签发新的令牌
+	accessToken, err := issueAccessToken(&user, sessionID, int64(version))
 	if err != nil {
-		return nil, "", code.ErrGenerateJWT
+		releaseRefreshRotation(jti)
+		return nil, "", "", err
+	}
+	refreshToken, refreshJTI, err := issueRefreshToken(&user, sessionID, int64(version))
+	if err != nil {
+		releaseRefreshRotation(jti)
+		return nil, "", "", err
+	}
+
+	// 只有会话当前的refresh token仍是本次提交的令牌时才更新，避免并发轮换
+	updates := map[string]interface{}{
+		"refresh_jti":  refreshJTI,
+		"last_seen_at": time.Now(),
+		"expires_at":   time.Now().Add(refreshTokenTTL()),
+	}
+	if service.IP != "" {
+		updates["ip"] = service.IP
+	}
+	result := models.DB.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_jti = ?", session.ID, jti).
+		Updates(updates)
+	if result.Error != nil {
+		releaseRefreshRotation(jti)
+		logger.Logger.Errorf("rotate refresh token failed: %v", result.Error)
+		return nil, "", "", code.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		releaseRefreshRotation(jti)
+		return nil, "", "", code.ErrRefreshTokenInvalid
+	}
+
+	saveRefreshRotation(jti, &refreshRotation{AccessToken: accessToken, RefreshToken: refreshToken})
+	return &user, accessToken, refreshToken, nil
+}
+
+// handleRefreshTokenReuse 处理刷新令牌重复使用：吊销会话并记录操作日志
+func (service *RefreshTokenService) handleRefreshTokenReuse(user *models.User, session *models.UserSession) error {
+	logger.Logger.Warnf("refresh token reuse detected: user=%s, session=%s, ip=%s", user.ID, session.ID, service.IP)
+	if err := revokeSession(session); err != nil {
+		return err
+	}
+	go func() {
+		if err := LogRefreshTokenReuse(user.ID, user.UserName, session.ID.String(), service.IP, service.UserAgent); err != nil {
+			logger.Logger.Errorf("log refresh token reuse failed: %v", err)
+		}
+	}()
+	return code.ErrRefreshTokenReused
+}
+
+// LogoutService 退出登录服务结构体
+// 吊销当前的访问令牌和所在的会话
+type LogoutService struct {
+	Claims jwt.MapClaims `json:"-" form:"-"` // 当前访问令牌的Claims，从JWT中获取
+}
+
+// Logout 退出登录
+func (service *LogoutService) Logout() error {
+	if err := tokenstore.RevokeClaims(context.Background(), service.Claims); err != nil {
+		logger.Logger.Errorf("revoke access token failed: %v", err)
+		return code.ErrDatabase
 	}
 
-	// 更新Redis中的token
-	if err := redisClient.Set(context.Background(), 
-				config.Conf.Redis.KeyPrefix+":access_token:"+user.Email,
-				accessJwtToken,
-			time.Duration(config.Conf.App.JwtExpirationTime)*time.Minute).Err(); err != nil {
-		logger.Logger.Errorf("redis set access token failed: %v", err)
-		return nil, "", code.ErrDatabase
+	userID, _ := service.Claims["sub"].(string)
+	var user models.User
+	if err := models.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return code.ErrUserNotFound
 	}
 
-	return &user, accessJwtToken, nil
-}
\ No newline at end of file
+	// 吊销当前会话，会话的刷新令牌随之失效
+	sessionID, _ := service.Claims[tokenstore.ClaimSession].(string)
+	if session, err := findActiveSession(sessionID, userID); err == nil {
+		if err := revokeSession(session); err != nil {
+			return err
+		}
+	}
+
+	if err := models.DB.Model(&user).Update("last_logout", time.Now()).Error; err != nil {
+		logger.Logger.Errorf("update last logout failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// LogoutAllService 退出所有设备服务结构体
+type LogoutAllService struct {
+	UserID string `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// LogoutAll 退出所有设备，吊销用户的所有令牌
+func (service *LogoutAllService) LogoutAll() error {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return code.ErrUserNotFound
+	}
+	if err := revokeUserTokens(&user); err != nil {
+		return err
+	}
+	if err := models.DB.Model(&user).Update("last_logout", time.Now()).Error; err != nil {
+		logger.Logger.Errorf("update last logout failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
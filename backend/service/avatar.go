@@ -0,0 +1,231 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/oss"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// avatarBucket 头像与照片保存在同一个公开的存储桶中
+	avatarBucket = "moity-blog"
+	// avatarMaxSize 头像文件的最大字节数
+	avatarMaxSize = 5 << 20
+	// avatarMaxDimension 头像图片的最大边长，避免解码过大的图片
+	avatarMaxDimension = 8192
+	// avatarMaxPixels 头像图片的最大像素数
+	avatarMaxPixels = 24000000
+)
+
+// avatarSizes 生成的头像尺寸，最大的尺寸同时作为用户的默认头像
+var avatarSizes = []int{64, 128, 256}
+
+// avatarFormats 允许上传的图片格式
+var avatarFormats = map[string]bool{"jpeg": true, "png": true, "gif": true, "webp": true}
+
+// 上传和删除头像文件，测试时替换为不依赖对象存储的实现
+var (
+	uploadAvatarObject = oss.UploadFileMinio
+	deleteAvatarObject = oss.DeleteFileFromBucketMinio
+)
+
+// UploadAvatarService 上传头像服务结构体
+// 头像可以通过multipart上传，也可以是Base64编码的图片，两者都提供时使用上传的文件
+type UploadAvatarService struct {
+	Avatar       *multipart.FileHeader `json:"-" form:"avatar"`                    // 头像文件
+	AvatarBase64 string                `json:"avatar_base64" form:"avatar_base64"` // Base64编码的头像，可以带data:image/...;base64,前缀
+	ID           uuid.UUID             `json:"-" form:"-"`                         // 用户ID，从JWT中获取
+}
+
+// UploadAvatar 上传头像
+// 校验图片后裁剪为正方形，生成不同尺寸的头像上传到对象存储，并删除之前上传的头像
+// 返回不同尺寸头像的URL
+func (service *UploadAvatarService) UploadAvatar() (map[string]string, error) {
+	data, err := service.readAvatar()
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeAvatarImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := models.DB.First(&user, service.ID).Error; err != nil {
+		logger.Logger.Errorf("get user profile failed: %v", err)
+		return nil, code.ErrUserNotFound
+	}
+
+	// 每次上传使用新的对象名，避免浏览器和CDN缓存旧头像
+	prefix := avatarObjectPrefix(user.ID)
+	version := uuid.New().String()
+	variants := make(map[string]string, len(avatarSizes))
+	uploaded := make([]string, 0, len(avatarSizes))
+	for size, encoded := range renderAvatarVariants(img) {
+		objectName := fmt.Sprintf("%s%s-%d.png", prefix, version, size)
+		if err := uploadAvatarObject(avatarBucket, objectName, bytes.NewReader(encoded), "image/png"); err != nil {
+			logger.Logger.Errorf("upload avatar failed: %v", err)
+			removeAvatarObjects(user.ID, uploaded)
+			return nil, code.ErrAvatarUpload
+		}
+		url := oss.GeneratePublicURLMinio(avatarBucket, objectName)
+		variants[strconv.Itoa(size)] = url
+		uploaded = append(uploaded, url)
+	}
+
+	previous := make([]string, 0, len(user.AvatarVariants)+1)
+	previous = append(previous, user.Avatar)
+	for _, url := range user.AvatarVariants {
+		previous = append(previous, url)
+	}
+
+	user.Avatar = variants[strconv.Itoa(avatarSizes[len(avatarSizes)-1])]
+	user.AvatarVariants = variants
+	if err := models.DB.Model(&user).Select("avatar", "avatar_variants").Updates(&user).Error; err != nil {
+		logger.Logger.Errorf("update user avatar failed: %v", err)
+		removeAvatarObjects(user.ID, uploaded)
+		return nil, code.ErrDatabase
+	}
+	removeAvatarObjects(user.ID, previous)
+	return variants, nil
+}
+
+// readAvatar 读取上传的头像，超过大小限制时返回错误
+func (service *UploadAvatarService) readAvatar() ([]byte, error) {
+	if service.Avatar != nil {
+		if service.Avatar.Size > avatarMaxSize {
+			return nil, code.ErrAvatarTooLarge
+		}
+		file, err := service.Avatar.Open()
+		if err != nil {
+			logger.Logger.Errorf("open avatar failed: %v", err)
+			return nil, code.ErrAvatarUpload
+		}
+		defer file.Close()
+		return readAvatarData(file)
+	}
+	if service.AvatarBase64 != "" {
+		return decodeAvatarBase64(service.AvatarBase64)
+	}
+	return nil, code.ErrBind
+}
+
+// readAvatarData 最多读取avatarMaxSize个字节，超过时返回错误
+func readAvatarData(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, avatarMaxSize+1))
+	if err != nil {
+		return nil, code.ErrAvatarUpload
+	}
+	if len(data) > avatarMaxSize {
+		return nil, code.ErrAvatarTooLarge
+	}
+	return data, nil
+}
+
+// decodeAvatarBase64 解码Base64编码的头像，支持data URL格式
+func decodeAvatarBase64(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "data:") {
+		header, payload, ok := strings.Cut(value, ",")
+		if !ok || !strings.HasPrefix(header, "data:image/") || !strings.HasSuffix(header, ";base64") {
+			return nil, code.ErrAvatarInvalid
+		}
+		value = payload
+	}
+	if base64.StdEncoding.DecodedLen(len(value)) > avatarMaxSize+2 {
+		return nil, code.ErrAvatarTooLarge
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, code.ErrAvatarInvalid
+	}
+	if len(data) > avatarMaxSize {
+		return nil, code.ErrAvatarTooLarge
+	}
+	return data, nil
+}
+
+// decodeAvatarImage 校验图片格式和尺寸后解码图片
+// 按文件内容判断格式，不信任客户端提供的Content-Type；GIF只使用第一帧
+func decodeAvatarImage(data []byte) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || !avatarFormats[format] {
+		return nil, code.ErrAvatarInvalid
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, code.ErrAvatarInvalid
+	}
+	if config.Width > avatarMaxDimension || config.Height > avatarMaxDimension ||
+		config.Width*config.Height > avatarMaxPixels {
+		return nil, code.ErrAvatarTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, code.ErrAvatarInvalid
+	}
+	return img, nil
+}
+
+// cropAvatarSquare 从图片中心裁剪出最大的正方形
+func cropAvatarSquare(img image.Image) image.Rectangle {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// resizeAvatar 将图片中心的正方形区域缩放为size×size
+func resizeAvatar(img image.Image, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, cropAvatarSquare(img), draw.Src, nil)
+	return dst
+}
+
+// renderAvatarVariants 生成各个尺寸的PNG头像，PNG可以保留透明背景
+func renderAvatarVariants(img image.Image) map[int][]byte {
+	variants := make(map[int][]byte, len(avatarSizes))
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		// 写入内存不会失败
+		_ = png.Encode(&buf, resizeAvatar(img, size))
+		variants[size] = buf.Bytes()
+	}
+	return variants
+}
+
+// avatarObjectPrefix 用户头像对象名的前缀
+func avatarObjectPrefix(userID uuid.UUID) string {
+	return "avatars/" + userID.String() + "/"
+}
+
+// removeAvatarObjects 删除用户上传过的头像文件
+// 只删除本系统为该用户生成的头像，第三方登录的头像地址和旧的本地头像地址会被忽略
+func removeAvatarObjects(userID uuid.UUID, urls []string) {
+	prefix := avatarObjectPrefix(userID)
+	for _, url := range urls {
+		bucket, object, ok := oss.ParsePublicURLMinio(url)
+		if !ok || bucket != avatarBucket || !strings.HasPrefix(object, prefix) {
+			continue
+		}
+		if err := deleteAvatarObject(bucket, object); err != nil {
+			logger.Logger.Errorf("delete avatar %s failed: %v", object, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/models"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	// 左右两侧涂成红色，中间的正方形涂成蓝色，裁剪后应该只剩蓝色
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= (width-height)/2 && x < (width+height)/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeAvatarImage(t *testing.T) {
+	if _, err := decodeAvatarImage([]byte("not an image")); err != code.ErrAvatarInvalid {
+		t.Errorf("decode text: got %v, want %v", err, code.ErrAvatarInvalid)
+	}
+	if _, err := decodeAvatarImage(encodeTestPNG(t, avatarMaxDimension+1, 1)); err != code.ErrAvatarTooLarge {
+		t.Errorf("decode wide image: got %v, want %v", err, code.ErrAvatarTooLarge)
+	}
+	img, err := decodeAvatarImage(encodeTestPNG(t, 300, 100))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if got := cropAvatarSquare(img); got != image.Rect(100, 0, 200, 100) {
+		t.Errorf("crop = %v, want %v", got, image.Rect(100, 0, 200, 100))
+	}
+}
+
+func TestRenderAvatarVariants(t *testing.T) {
+	img, err := decodeAvatarImage(encodeTestPNG(t, 300, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := renderAvatarVariants(img)
+	if len(variants) != len(avatarSizes) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(avatarSizes))
+	}
+	for _, size := range avatarSizes {
+		variant, err := png.Decode(bytes.NewReader(variants[size]))
+		if err != nil {
+			t.Fatalf("decode %d variant: %v", size, err)
+		}
+		if variant.Bounds() != image.Rect(0, 0, size, size) {
+			t.Errorf("variant %d bounds = %v", size, variant.Bounds())
+		}
+		r, _, b, _ := variant.At(0, 0).RGBA()
+		if r != 0 || b == 0 {
+			t.Errorf("variant %d corner is not from the center square", size)
+		}
+	}
+}
+
+func TestDecodeAvatarBase64(t *testing.T) {
+	data := encodeTestPNG(t, 10, 10)
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for _, value := range []string{encoded, "data:image/png;base64," + encoded} {
+		got, err := decodeAvatarBase64(value)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("decodeAvatarBase64(%.30q) failed: %v", value, err)
+		}
+	}
+	if _, err := decodeAvatarBase64("data:text/plain;base64," + encoded); err != code.ErrAvatarInvalid {
+		t.Errorf("non-image data URL: got %v, want %v", err, code.ErrAvatarInvalid)
+	}
+	if _, err := decodeAvatarBase64("!!!"); err != code.ErrAvatarInvalid {
+		t.Errorf("invalid base64: got %v, want %v", err, code.ErrAvatarInvalid)
+	}
+	tooLarge := base64.StdEncoding.EncodeToString(make([]byte, avatarMaxSize+1))
+	if _, err := decodeAvatarBase64(tooLarge); err != code.ErrAvatarTooLarge {
+		t.Errorf("too large: got %v, want %v", err, code.ErrAvatarTooLarge)
+	}
+}
+
+// testAvatarStorage 记录上传和删除的头像文件，代替对象存储
+type testAvatarStorage struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	deleted []string
+}
+
+// stubAvatarStorage 替换头像的上传和删除，测试结束后恢复
+func stubAvatarStorage(t *testing.T) *testAvatarStorage {
+	t.Helper()
+	storage := &testAvatarStorage{objects: make(map[string][]byte)}
+	upload, remove := uploadAvatarObject, deleteAvatarObject
+	t.Cleanup(func() { uploadAvatarObject, deleteAvatarObject = upload, remove })
+	uploadAvatarObject = func(bucketName, objectName string, src io.Reader, contentType string) error {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		storage.mutex.Lock()
+		defer storage.mutex.Unlock()
+		storage.objects[bucketName+"/"+objectName] = data
+		return nil
+	}
+	deleteAvatarObject = func(bucketName, objectName string) error {
+		storage.mutex.Lock()
+		defer storage.mutex.Unlock()
+		storage.deleted = append(storage.deleted, bucketName+"/"+objectName)
+		delete(storage.objects, bucketName+"/"+objectName)
+		return nil
+	}
+	return storage
+}
+
+func TestUploadAvatarSuccess(t *testing.T) {
+	newTestDB(t, &models.User{})
+	storage := stubAvatarStorage(t)
+	user := createTestUser(t, "alice")
+
+	service := &UploadAvatarService{
+		ID:           user.ID,
+		AvatarBase64: "data:image/png;base64," + base64.StdEncoding.EncodeToString(encodeTestPNG(t, 300, 200)),
+	}
+	first, err := service.UploadAvatar()
+	if err != nil {
+		t.Fatalf("UploadAvatar() error = %v", err)
+	}
+	if len(first) != len(avatarSizes) || len(storage.objects) != len(avatarSizes) {
+		t.Fatalf("variants = %v, uploaded %d objects, want %d", first, len(storage.objects), len(avatarSizes))
+	}
+	for _, size := range avatarSizes {
+		url := first[strconv.Itoa(size)]
+		data, ok := storage.objects[strings.TrimPrefix(url, "/")]
+		if !ok {
+			t.Fatalf("variant %d %q was not uploaded", size, url)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil || img.Bounds() != image.Rect(0, 0, size, size) {
+			t.Errorf("variant %d = %v, %v", size, img.Bounds(), err)
+		}
+	}
+
+	if err := models.DB.First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	largest := strconv.Itoa(avatarSizes[len(avatarSizes)-1])
+	if user.Avatar != first[largest] || len(user.AvatarVariants) != len(avatarSizes) {
+		t.Errorf("user avatar = %q, variants = %v", user.Avatar, user.AvatarVariants)
+	}
+
+	// 再次上传后删除之前的头像
+	if _, err := service.UploadAvatar(); err != nil {
+		t.Fatalf("second UploadAvatar() error = %v", err)
+	}
+	if len(storage.objects) != len(avatarSizes) {
+		t.Errorf("objects after second upload = %d, want %d", len(storage.objects), len(avatarSizes))
+	}
+	for _, url := range first {
+		if _, ok := storage.objects[strings.TrimPrefix(url, "/")]; ok {
+			t.Errorf("previous avatar %q should be deleted", url)
+		}
+	}
+}
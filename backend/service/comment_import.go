@@ -0,0 +1,210 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/importer"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"encoding/json"
+	"io"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImportCommentsService 导入评论服务结构体
+// 用于导入Disqus、Twikoo和Valine导出的评论
+type ImportCommentsService struct {
+	Source string `json:"source" form:"source" binding:"required,oneof=disqus twikoo valine"` // 评论来源
+	DryRun bool   `json:"dry_run" form:"dry_run"`                                             // 只检查匹配情况，不写入数据库
+}
+
+// CommentImportReport 评论导入报告
+type CommentImportReport struct {
+	Source         string                 `json:"source"`          // 评论来源
+	DryRun         bool                   `json:"dry_run"`         // 是否为试运行
+	Threads        int                    `json:"threads"`         // 评论串总数
+	MatchedThreads int                    `json:"matched_threads"` // 匹配到文章的评论串数
+	Comments       int                    `json:"comments"`        // 评论总数
+	Imported       int                    `json:"imported"`        // 导入(试运行时为将要导入)的评论数
+	Skipped        int                    `json:"skipped"`         // 已经导入过而跳过的评论数
+	Unmatched      int                    `json:"unmatched"`       // 因评论串未匹配而无法导入的评论数
+	OrphanReplies  int                    `json:"orphan_replies"`  // 找不到父评论、作为顶层评论导入的回复数
+	Matches        []CommentThreadMatch   `json:"matches"`         // 匹配成功的评论串
+	Mismatches     []CommentThreadMissing `json:"mismatches"`      // 未匹配的评论串
+}
+
+// CommentThreadMatch 匹配成功的评论串
+type CommentThreadMatch struct {
+	ThreadURL    string    `json:"thread_url"`    // 原文章链接
+	ThreadTitle  string    `json:"thread_title"`  // 原文章标题
+	ArticleID    uuid.UUID `json:"article_id"`    // 匹配到的文章ID
+	ArticleTitle string    `json:"article_title"` // 匹配到的文章标题
+	MatchedBy    string    `json:"matched_by"`    // 匹配方式(url/slug/title)
+	Comments     int       `json:"comments"`      // 评论数
+}
+
+// CommentThreadMissing 未匹配的评论串
+type CommentThreadMissing struct {
+	ThreadURL   string `json:"thread_url"`   // 原文章链接
+	ThreadTitle string `json:"thread_title"` // 原文章标题
+	Comments    int    `json:"comments"`     // 评论数
+}
+
+// Import 解析导出文件并导入评论
+// 评论串依次按链接中的文章ID、链接slug和标题匹配文章；导入的评论保留原时间、昵称和回复关系，并标记为审核通过。
+// 同一来源的评论重复导入时会跳过，因此可以在补充文章后再次导入
+func (service *ImportCommentsService) Import(r io.Reader) (*CommentImportReport, error) {
+	export, err := importer.Parse(service.Source, r)
+	if err != nil {
+		logger.Logger.Warnf("parse comment export failed: %v", err)
+		return nil, code.ErrArticleCommentImportParse
+	}
+
+	var articles []models.Article
+	if err := models.DB.Select("id", "title").Find(&articles).Error; err != nil {
+		logger.Logger.Errorf("list articles failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	candidates := make([]importer.Article, len(articles))
+	for i, article := range articles {
+		candidates[i] = importer.Article{ID: article.ID.String(), Title: article.Title}
+	}
+	matcher := importer.NewMatcher(candidates)
+
+	report := &CommentImportReport{
+		Source:     service.Source,
+		DryRun:     service.DryRun,
+		Threads:    len(export.Threads),
+		Comments:   len(export.Comments),
+		Matches:    []CommentThreadMatch{},
+		Mismatches: []CommentThreadMissing{},
+	}
+
+	counts := make(map[string]int)
+	for _, comment := range export.Comments {
+		counts[comment.ThreadID]++
+	}
+
+	// 评论串ID -> 文章
+	matched := make(map[string]importer.Article)
+	for _, thread := range export.Threads {
+		article, matchedBy, ok := matcher.Match(thread)
+		if !ok {
+			if counts[thread.ID] > 0 {
+				report.Mismatches = append(report.Mismatches, CommentThreadMissing{
+					ThreadURL:   thread.URL,
+					ThreadTitle: thread.Title,
+					Comments:    counts[thread.ID],
+				})
+			}
+			continue
+		}
+		matched[thread.ID] = article
+		report.MatchedThreads++
+		report.Matches = append(report.Matches, CommentThreadMatch{
+			ThreadURL:    thread.URL,
+			ThreadTitle:  thread.Title,
+			ArticleID:    uuid.MustParse(article.ID),
+			ArticleTitle: article.Title,
+			MatchedBy:    matchedBy,
+			Comments:     counts[thread.ID],
+		})
+	}
+
+	// 之前已经导入过的评论
+	existing, err := importedCommentIDs(service.Source, export.Comments)
+	if err != nil {
+		logger.Logger.Errorf("list imported comments failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	// 原评论ID -> 新评论ID，先分配ID，以便回复能找到父评论
+	ids := make(map[string]uuid.UUID, len(export.Comments))
+	for _, comment := range export.Comments {
+		if _, ok := matched[comment.ThreadID]; !ok {
+			continue
+		}
+		if id, ok := existing[comment.ID]; ok {
+			ids[comment.ID] = id
+		} else {
+			ids[comment.ID] = uuid.New()
+		}
+	}
+
+	var comments []models.ArticleComment
+	for _, comment := range export.Comments {
+		article, ok := matched[comment.ThreadID]
+		if !ok {
+			report.Unmatched++
+			continue
+		}
+		if _, ok := existing[comment.ID]; ok {
+			report.Skipped++
+			continue
+		}
+
+		// 文章标题列是jsonb类型，与发表评论时一样保存为JSON字符串
+		articleTitle, _ := json.Marshal(article.Title)
+		imported := models.ArticleComment{
+			ArticleID:    uuid.MustParse(article.ID),
+			ArticleTitle: string(articleTitle),
+			GuestName:    comment.AuthorName,
+			Content:      comment.Content,
+			IsPass:       true,
+			ImportSource: service.Source,
+			ImportID:     comment.ID,
+		}
+		imported.ID = ids[comment.ID]
+		imported.CreatedAt = comment.CreatedAt
+		imported.UpdatedAt = comment.CreatedAt
+		if comment.ParentID != "" {
+			if parentID, ok := ids[comment.ParentID]; ok {
+				imported.ParentID = &parentID
+			} else {
+				report.OrphanReplies++
+			}
+		}
+		comments = append(comments, imported)
+	}
+	report.Imported = len(comments)
+
+	if service.DryRun || len(comments) == 0 {
+		return report, nil
+	}
+	if err := models.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(comments, 100).Error
+	}); err != nil {
+		logger.Logger.Errorf("import comments failed: %v", err)
+		return nil, code.ErrArticleCommentImportFailed
+	}
+	return report, nil
+}
+
+// importedCommentIDs 查询已经导入过的评论，返回原评论ID到评论ID的映射
+func importedCommentIDs(source string, comments []importer.Comment) (map[string]uuid.UUID, error) {
+	result := make(map[string]uuid.UUID)
+	importIDs := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		importIDs = append(importIDs, comment.ID)
+	}
+
+	// 分批查询，避免参数过多
+	const batchSize = 500
+	for start := 0; start < len(importIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(importIDs) {
+			end = len(importIDs)
+		}
+		var rows []models.ArticleComment
+		if err := models.DB.Select("id", "import_id").
+			Where("import_source = ? AND import_id IN ?", source, importIDs[start:end]).
+			Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			result[row.ImportID] = row.ID
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"blog-server/internal/models"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestImportCommentsArticleTitle(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.Article{}, &models.ArticleComment{})
+	user := createTestUser(t, "alice")
+	article := &models.Article{Title: `Hello "World"`, Content: "content", Status: models.ArticleStatusPublished, UserID: user.ID}
+	if err := models.DB.Create(article).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	export := `[{"_id":"a1","nick":"Alice","comment":"hi","url":"/posts/hello-world/","created":1556699400000}]`
+	report, err := (&ImportCommentsService{Source: "twikoo"}).Import(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.Imported != 1 {
+		t.Fatalf("imported = %d, want 1", report.Imported)
+	}
+
+	var comment models.ArticleComment
+	if err := models.DB.Where("import_id = ?", "a1").First(&comment).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 文章标题以JSON字符串保存
+	var title string
+	if err := json.Unmarshal([]byte(comment.ArticleTitle), &title); err != nil {
+		t.Fatalf("article title %q is not valid json: %v", comment.ArticleTitle, err)
+	}
+	if title != article.Title {
+		t.Errorf("article title = %q, want %q", title, article.Title)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/pubsub"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 评论实时推送事件类型
+const (
+	CommentEventApproved = "comment.approved" // 评论审核通过
+	CommentEventUpdated  = "comment.updated"  // 评论被编辑
+	CommentEventDeleted  = "comment.deleted"  // 评论被删除
+)
+
+// CommentEvent 推送给正在阅读文章的客户端的评论事件
+type CommentEvent struct {
+	Type string                 `json:"type"` // 事件类型
+	Data *models.ArticleComment `json:"data"` // 评论
+}
+
+// CommentTopic 返回文章评论的发布订阅主题
+func CommentTopic(articleID uuid.UUID) string {
+	return "comment:" + articleID.String()
+}
+
+// publishCommentEvent 向所有正在阅读该文章的客户端广播评论事件
+// 只广播已审核通过且关联了文章的评论，推送失败只记录日志
+func publishCommentEvent(eventType string, comment *models.ArticleComment) {
+	if comment.ArticleID == uuid.Nil || !comment.IsPass {
+		return
+	}
+	payload, err := json.Marshal(CommentEvent{Type: eventType, Data: comment})
+	if err != nil {
+		logger.Logger.Errorf("marshal comment event failed: %v", err)
+		return
+	}
+	if err := pubsub.Publish(context.Background(), CommentTopic(comment.ArticleID), payload); err != nil {
+		logger.Logger.Errorf("publish comment event failed: %v", err)
+	}
+}
+
+// WatchArticleCommentsService 订阅文章评论服务结构体
+// 用于WebSocket实时推送文章的评论变化
+type WatchArticleCommentsService struct {
+	ArticleID string `uri:"article_id" binding:"required"` // 文章ID，从URL路径获取
+}
+
+// Watch 订阅文章的评论事件，使用完毕后需要调用pubsub.Unsubscribe
+// 只能订阅已发布的文章
+func (service *WatchArticleCommentsService) Watch() (*pubsub.Subscriber, error) {
+	articleID, err := uuid.Parse(service.ArticleID)
+	if err != nil {
+		return nil, code.ErrInvalidArticleID
+	}
+
+	var article models.Article
+	if err := models.DB.Where("id = ? AND status = ?", articleID, models.ArticleStatusPublished).First(&article).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrArticleNotFound
+		}
+		logger.Logger.Errorf("get article failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	subscriber, err := pubsub.Subscribe(CommentTopic(articleID))
+	if err != nil {
+		logger.Logger.Errorf("subscribe article comments failed: %v", err)
+		return nil, code.ErrArticleCommentWatchFailed
+	}
+	return subscriber, nil
+}
@@ -5,6 +5,7 @@ import (
 	"blog-server/internal/logger"
 	"blog-server/internal/models"
 	"blog-server/internal/oss"
+	"blog-server/internal/rbac"
 	"mime/multipart"
 	"time"
 
@@ -263,6 +264,7 @@ func (service *GetDailyPhotographService) GetDailyPhotograph() (*models.DailyPho
 type UpdateDailyPhotographService struct {
 	PhotoID      string    `form:"photo_id" binding:"required"` // 照片ID，必填
 	UserID       uuid.UUID // 用户ID，必填，用于权限验证
+	Role         string    `form:"-" json:"-"`    // 当前用户角色，从JWT中获取
 	Title        string    `form:"title"`         // 照片标题
 	Description  string    `form:"description"`   // 照片描述
 	Tags         string    `form:"tags"`          // 照片标签，多个标签用逗号分隔
@@ -288,7 +290,11 @@ func (service *UpdateDailyPhotographService) UpdateDailyPhotograph() error {
 	}
 
 	// 检查用户是否有权限修改这张照片
-	if photo.UserID != service.UserID {
+	allowed, err := rbac.CanManage(service.Role, service.UserID, photo.UserID, rbac.PermPhotosWrite, rbac.PermPhotosModerate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return code.ErrDailyPhotographPermission
 	}
 
@@ -352,6 +358,7 @@ func (service *UpdateDailyPhotographService) UpdateDailyPhotograph() error {
 type DeleteDailyPhotographService struct {
 	PhotoID string    `uri:"photo_id" binding:"required"` // 照片ID，必填
 	UserID  uuid.UUID // 用户ID，必填，用于权限验证
+	Role    string    `uri:"-" json:"-"` // 当前用户角色，从JWT中获取
 }
 
 // DeleteDailyPhotograph 删除日常照片
@@ -365,7 +372,11 @@ func (service *DeleteDailyPhotographService) DeleteDailyPhotograph() error {
 	}
 
 	// 检查用户是否有权限删除这张照片
-	if photo.UserID != service.UserID {
+	allowed, err := rbac.CanManage(service.Role, service.UserID, photo.UserID, rbac.PermPhotosWrite, rbac.PermPhotosModerate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return code.ErrDailyPhotographPermission
 	}
 
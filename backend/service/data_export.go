@@ -0,0 +1,373 @@
+package service
+
+import (
+	"archive/zip"
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/dto"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/oss"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// dataExportBucket 保存导出文件的桶，不设置公开访问策略，只能通过下载接口获取
+	dataExportBucket = "moity-blog-exports"
+	// defaultDataExportExpire 导出文件默认的保留时间
+	defaultDataExportExpire = 7 * 24 * time.Hour
+	// dataExportInterval 两次导出之间的最短间隔
+	dataExportInterval = 24 * time.Hour
+	// dataExportStaleAfter 超过该时间仍未完成的导出视为失败，例如打包过程中服务重启
+	dataExportStaleAfter = time.Hour
+	// privacySettingsPath 前端隐私设置页面的路径，用于下载导出文件和撤销注销
+	privacySettingsPath = "/settings/privacy"
+)
+
+// RequestDataExportService 申请导出个人数据服务结构体
+type RequestDataExportService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// Request 创建导出任务并在后台打包，完成后发送邮件通知
+// 同一时间只能有一个进行中的导出，24小时内只能申请一次
+func (service *RequestDataExportService) Request() (*models.DataExport, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+
+	var exports []models.DataExport
+	if err := models.DB.Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-dataExportInterval)).
+		Find(&exports).Error; err != nil {
+		logger.Logger.Errorf("list data exports failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	for _, export := range exports {
+		switch export.Status {
+		case models.DataExportStatusPending, models.DataExportStatusProcessing:
+			return nil, code.ErrDataExportInProgress
+		case models.DataExportStatusCompleted:
+			return nil, code.ErrDataExportTooMany
+		}
+	}
+
+	export := models.DataExport{
+		UserID: user.ID,
+		Status: models.DataExportStatusPending,
+	}
+	if err := models.DB.Create(&export).Error; err != nil {
+		logger.Logger.Errorf("create data export failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	go buildDataExport(export.ID, &user)
+	return &export, nil
+}
+
+// ListDataExportsService 获取个人数据导出列表服务结构体
+type ListDataExportsService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// List 获取当前用户的导出记录，按申请时间倒序
+func (service *ListDataExportsService) List() ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := models.DB.Where("user_id = ?", service.UserID).Order("created_at DESC").Find(&exports).Error; err != nil {
+		logger.Logger.Errorf("list data exports failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return exports, nil
+}
+
+// DownloadDataExportService 下载个人数据服务结构体
+type DownloadDataExportService struct {
+	ID     string    `uri:"id" binding:"required,uuid"` // 导出ID，从URL路径获取
+	UserID uuid.UUID `uri:"-" json:"-"`                 // 用户ID，从JWT中获取
+}
+
+// Download 获取已完成且未过期的导出文件，调用方负责关闭返回的文件
+func (service *DownloadDataExportService) Download() (io.ReadCloser, int64, string, error) {
+	var export models.DataExport
+	if err := models.DB.Where("id = ? AND user_id = ?", service.ID, service.UserID).First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, "", code.ErrDataExportNotFound
+		}
+		logger.Logger.Errorf("get data export failed: %v", err)
+		return nil, 0, "", code.ErrDatabase
+	}
+	if export.Status != models.DataExportStatusCompleted || export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now()) {
+		return nil, 0, "", code.ErrDataExportNotReady
+	}
+
+	file, size, err := oss.GetFileMinio(dataExportBucket, export.ObjectName)
+	if err != nil {
+		return nil, 0, "", code.ErrDataExportNotReady
+	}
+	fileName := fmt.Sprintf("personal-data-%s.zip", export.CreatedAt.Format("20060102"))
+	return file, size, fileName, nil
+}
+
+// buildDataExport 在后台打包个人数据并上传到对象存储，完成后发送邮件通知
+func buildDataExport(exportID uuid.UUID, user *models.User) {
+	result := models.DB.Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", exportID, models.DataExportStatusPending).
+		Update("status", models.DataExportStatusProcessing)
+	if result.Error != nil || result.RowsAffected == 0 {
+		logger.Logger.Errorf("start data export %s failed: %v", exportID, result.Error)
+		return
+	}
+
+	objectName := user.ID.String() + "/" + exportID.String() + ".zip"
+	size, err := uploadDataExport(user, objectName)
+	if err != nil {
+		logger.Logger.Errorf("build data export %s failed: %v", exportID, err)
+		if err := models.DB.Model(&models.DataExport{}).Where("id = ?", exportID).
+			Update("status", models.DataExportStatusFailed).Error; err != nil {
+			logger.Logger.Errorf("update data export status failed: %v", err)
+		}
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(dataExportExpire())
+	if err := models.DB.Model(&models.DataExport{}).Where("id = ?", exportID).Updates(map[string]interface{}{
+		"status":       models.DataExportStatusCompleted,
+		"object_name":  objectName,
+		"file_size":    size,
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}).Error; err != nil {
+		logger.Logger.Errorf("update data export status failed: %v", err)
+		return
+	}
+
+	message := dto.DataExportMessage{
+		Email:       user.Email,
+		UserName:    user.UserName,
+		DownloadURL: siteURL(privacySettingsPath),
+		ExpireDays:  int(dataExportExpire() / (24 * time.Hour)),
+	}
+	if err := publishEmailMessage(context.Background(), "data_export", user.Email, message); err != nil {
+		logger.Logger.Errorf("send data export email failed: %v", err)
+	}
+}
+
+// uploadDataExport 将个人数据打包到临时文件后上传，返回文件大小
+func uploadDataExport(user *models.User, objectName string) (int64, error) {
+	data, err := collectPersonalData(user)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		if err := os.Remove(tmp.Name()); err != nil {
+			logger.Logger.Errorf("remove temp file failed: %v", err)
+		}
+	}()
+
+	if err := writePersonalDataArchive(tmp, data, openPhotoFile); err != nil {
+		return 0, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if err := oss.MakeBucketMinio(dataExportBucket); err != nil {
+		return 0, err
+	}
+	if err := oss.UploadFileMinio(dataExportBucket, objectName, tmp, "application/zip"); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// exportProfile 导出的个人资料，包含普通接口不返回的字段
+type exportProfile struct {
+	ID               uuid.UUID `json:"id"`
+	UserName         string    `json:"user_name"`
+	Nickname         string    `json:"nickname"`
+	Email            string    `json:"email"`
+	Phone            string    `json:"phone"`
+	Avatar           string    `json:"avatar"`
+	Bio              string    `json:"bio"`
+	Website          string    `json:"website"`
+	Location         string    `json:"location"`
+	Birthday         string    `json:"birthday"`
+	Gender           string    `json:"gender"`
+	Role             string    `json:"role"`
+	Status           string    `json:"status"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	LoginCount       int       `json:"login_count"`
+	LastLogin        time.Time `json:"last_login"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// personalData 导出的全部个人数据
+type personalData struct {
+	Profile       exportProfile
+	Articles      []models.Article
+	Photos        []models.DailyPhotograph
+	Comments      []models.ArticleComment
+	Likes         []models.OperationLog
+	Sessions      []models.UserSession
+	LoginHistory  []models.LoginRecord
+	OperationLogs []models.OperationLog
+}
+
+// collectPersonalData 查询用户的个人数据
+// 点赞没有单独的记录，从操作日志中的点赞记录导出
+func collectPersonalData(user *models.User) (*personalData, error) {
+	data := &personalData{
+		Profile: exportProfile{
+			ID:               user.ID,
+			UserName:         user.UserName,
+			Nickname:         user.Nickname,
+			Email:            user.Email,
+			Phone:            user.Phone,
+			Avatar:           user.Avatar,
+			Bio:              user.Bio,
+			Website:          user.Website,
+			Location:         user.Location,
+			Birthday:         user.Birthday,
+			Gender:           user.Gender,
+			Role:             user.Role,
+			Status:           user.Status,
+			TwoFactorEnabled: user.TwoFactorEnabled,
+			LoginCount:       user.LoginCount,
+			LastLogin:        user.LastLogin,
+			CreatedAt:        user.CreatedAt,
+		},
+	}
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&data.Articles, models.DB.Where("user_id = ?", user.ID)},
+		{&data.Photos, models.DB.Where("user_id = ?", user.ID)},
+		{&data.Comments, models.DB.Where("user_id = ?", user.ID)},
+		{&data.Likes, models.DB.Where("user_id = ? AND operation_type = ? AND status = ?", user.ID, "article_like", "success")},
+		{&data.Sessions, models.DB.Where("user_id = ?", user.ID)},
+		{&data.LoginHistory, models.DB.Where("user_id = ?", user.ID)},
+		{&data.OperationLogs, models.DB.Where("user_id = ?", user.ID)},
+	}
+	for _, q := range queries {
+		if err := q.query.Order("created_at ASC").Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// writePersonalDataArchive 将个人数据写入zip，每类数据一个JSON文件，照片原图放在photos目录下
+// 照片读取失败时跳过该文件，photos.json中仍保留照片信息
+func writePersonalDataArchive(w io.Writer, data *personalData, openPhoto func(url string) (io.ReadCloser, error)) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"articles.json", data.Articles},
+		{"photos.json", data.Photos},
+		{"comments.json", data.Comments},
+		{"likes.json", data.Likes},
+		{"sessions.json", data.Sessions},
+		{"login_history.json", data.LoginHistory},
+		{"operation_logs.json", data.OperationLogs},
+	}
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return err
+		}
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(content); err != nil {
+			return err
+		}
+	}
+
+	for _, photo := range data.Photos {
+		src, err := openPhoto(photo.ImageUrl)
+		if err != nil {
+			logger.Logger.Warnf("open photo %s for data export failed: %v", photo.ID, err)
+			continue
+		}
+		writer, err := archive.Create("photos/" + photo.ID.String() + path.Ext(photo.ImageUrl))
+		if err == nil {
+			_, err = io.Copy(writer, src)
+		}
+		_ = src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// openPhotoFile 从对象存储读取照片原图
+func openPhotoFile(url string) (io.ReadCloser, error) {
+	bucketName, objectName, ok := oss.ParsePublicURLMinio(url)
+	if !ok {
+		return nil, fmt.Errorf("unsupported photo url: %s", url)
+	}
+	file, _, err := oss.GetFileMinio(bucketName, objectName)
+	return file, err
+}
+
+// expireDataExports 删除过期的导出文件，并将长时间未完成的导出标记为失败
+func expireDataExports() {
+	var exports []models.DataExport
+	if err := models.DB.Where("status = ? AND expires_at <= ?", models.DataExportStatusCompleted, time.Now()).
+		Find(&exports).Error; err != nil {
+		logger.Logger.Errorf("list expired data exports failed: %v", err)
+		return
+	}
+	for _, export := range exports {
+		if err := oss.DeleteFileFromBucketMinio(dataExportBucket, export.ObjectName); err != nil {
+			continue
+		}
+		if err := models.DB.Model(&export).Updates(map[string]interface{}{
+			"status":      models.DataExportStatusExpired,
+			"object_name": "",
+		}).Error; err != nil {
+			logger.Logger.Errorf("update data export status failed: %v", err)
+		}
+	}
+
+	if err := models.DB.Model(&models.DataExport{}).
+		Where("status IN ? AND created_at <= ?", []string{models.DataExportStatusPending, models.DataExportStatusProcessing}, time.Now().Add(-dataExportStaleAfter)).
+		Update("status", models.DataExportStatusFailed).Error; err != nil {
+		logger.Logger.Errorf("fail stale data exports failed: %v", err)
+	}
+}
+
+// dataExportExpire 导出文件的保留时间
+func dataExportExpire() time.Duration {
+	if config.Conf.Account.DataExportExpireDays > 0 {
+		return time.Duration(config.Conf.Account.DataExportExpireDays) * 24 * time.Hour
+	}
+	return defaultDataExportExpire
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"archive/zip"
+	"blog-server/internal/models"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWritePersonalDataArchive(t *testing.T) {
+	photo := models.DailyPhotograph{ImageUrl: "/moity-blog/photo.jpg", Title: "sunset"}
+	photo.ID = uuid.New()
+	data := &personalData{
+		Profile: exportProfile{UserName: "alice", Email: "alice@example.com"},
+		Photos:  []models.DailyPhotograph{photo},
+	}
+	openPhoto := func(url string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("image:" + url)), nil
+	}
+
+	var buf bytes.Buffer
+	if err := writePersonalDataArchive(&buf, data, openPhoto); err != nil {
+		t.Fatalf("writePersonalDataArchive() error = %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s error = %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		files[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"profile.json", "articles.json", "photos.json", "comments.json", "likes.json", "sessions.json", "login_history.json", "operation_logs.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	var profile exportProfile
+	if err := json.Unmarshal([]byte(files["profile.json"]), &profile); err != nil || profile.Email != "alice@example.com" {
+		t.Errorf("profile.json = %s, err = %v", files["profile.json"], err)
+	}
+	if got := files["photos/"+photo.ID.String()+".jpg"]; got != "image:/moity-blog/photo.jpg" {
+		t.Errorf("photo file = %q, want the original image", got)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/dto"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"blog-server/internal/utils"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// emailChangeTTL 修改邮箱验证码的有效期
+	emailChangeTTL = 15 * time.Minute
+	// emailChangeMaxAttempts 验证码允许输错的次数，超过后需要重新获取
+	emailChangeMaxAttempts = 5
+)
+
+// pendingEmailChange 等待验证的修改邮箱请求，保存在Redis中
+type pendingEmailChange struct {
+	Email    string `json:"email"`     // 新邮箱
+	CodeHash string `json:"code_hash"` // 验证码的哈希
+}
+
+// RequestEmailChangeService 申请修改邮箱服务结构体
+type RequestEmailChangeService struct {
+	NewEmail string    `json:"new_email" form:"new_email" binding:"required"` // 新邮箱，必填
+	Password string    `json:"password" form:"password" binding:"required"`   // 当前密码，必填
+	Code     string    `json:"code" form:"code"`                              // 验证码或恢复码，开启两步验证时必填
+	UserID   uuid.UUID `json:"-" form:"-"`                                    // 用户ID，从JWT中获取
+}
+
+// Request 验证密码后向新邮箱发送验证码
+// 同一用户只保留最新的一个请求，新邮箱不能已被其他账号使用
+func (service *RequestEmailChangeService) Request() error {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return code.ErrUserNotFound
+	}
+	newEmail := strings.TrimSpace(service.NewEmail)
+	if !utils.ValidateEmail(newEmail) {
+		return code.ErrEmailValidation
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return code.ErrEmailUnchanged
+	}
+	if !user.ComparePassword(service.Password) {
+		return code.ErrPasswordIncorrect
+	}
+	if user.TwoFactorEnabled {
+		if err := verifySecondFactor(&user, service.Code); err != nil {
+			return err
+		}
+	}
+	if err := checkEmailAvailable(models.DB, newEmail, user.ID); err != nil {
+		return err
+	}
+
+	verificationCode := utils.GenerateVerificationCode()
+	pending, err := json.Marshal(pendingEmailChange{
+		Email:    newEmail,
+		CodeHash: hashEmailChangeCode(verificationCode),
+	})
+	if err != nil {
+		logger.Logger.Errorf("marshal email change failed: %v", err)
+		return code.ErrSendEmailVerificationCode
+	}
+	ctx := context.Background()
+	pipe := redis.GetRedisClient().TxPipeline()
+	pipe.Set(ctx, emailChangeKey(user.ID.String()), pending, emailChangeTTL)
+	pipe.Del(ctx, emailChangeAttemptsKey(user.ID.String()))
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Logger.Errorf("redis set email change failed: %v", err)
+		return code.ErrSendEmailVerificationCode
+	}
+	return sendVerificationCodeEmail(newEmail, verificationCode)
+}
+
+// ConfirmEmailChangeService 确认修改邮箱服务结构体
+type ConfirmEmailChangeService struct {
+	VerificationCode string    `json:"verification_code" form:"verification_code" binding:"required"` // 新邮箱收到的验证码，必填
+	UserID           uuid.UUID `json:"-" form:"-"`                                                    // 用户ID，从JWT中获取
+	IP               string    `json:"-" form:"-"`                                                    // 请求IP，用于记录操作日志
+	UserAgent        string    `json:"-" form:"-"`                                                    // 用户代理，用于记录操作日志
+}
+
+// Confirm 校验验证码后修改邮箱，并通知旧邮箱
+// 会话和令牌按用户ID保存，修改邮箱后仍然有效；按邮箱保存的Redis数据迁移到新邮箱，
+// 发送到旧邮箱的重置密码链接失效
+func (service *ConfirmEmailChangeService) Confirm() (string, error) {
+	ctx := context.Background()
+	redisClient := redis.GetRedisClient()
+	userID := service.UserID.String()
+
+	value, err := redisClient.Get(ctx, emailChangeKey(userID)).Result()
+	if err == redis.Nil {
+		return "", code.ErrEmailChangeExpired
+	}
+	if err != nil {
+		logger.Logger.Errorf("redis get email change failed: %v", err)
+		return "", code.ErrDatabase
+	}
+	var pending pendingEmailChange
+	if err := json.Unmarshal([]byte(value), &pending); err != nil {
+		redisClient.Del(ctx, emailChangeKey(userID))
+		return "", code.ErrEmailChangeExpired
+	}
+
+	attempts, err := redisClient.Incr(ctx, emailChangeAttemptsKey(userID)).Result()
+	if err != nil {
+		logger.Logger.Errorf("redis incr email change attempts failed: %v", err)
+		return "", code.ErrDatabase
+	}
+	if attempts == 1 {
+		redisClient.Expire(ctx, emailChangeAttemptsKey(userID), emailChangeTTL)
+	}
+	if attempts > emailChangeMaxAttempts {
+		redisClient.Del(ctx, emailChangeKey(userID), emailChangeAttemptsKey(userID))
+		return "", code.ErrEmailChangeExpired
+	}
+	codeHash := hashEmailChangeCode(strings.TrimSpace(service.VerificationCode))
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(pending.CodeHash)) != 1 {
+		return "", code.ErrVerificationCodeInvalid
+	}
+
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return "", code.ErrUserNotFound
+	}
+	oldEmail := user.Email
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkEmailAvailable(tx, pending.Email, user.ID); err != nil {
+			return err
+		}
+		return tx.Model(&user).Update("email", pending.Email).Error
+	})
+	if err != nil {
+		if _, ok := err.(*code.Errno); ok {
+			return "", err
+		}
+		// 并发修改为同一个邮箱时由唯一索引拦截
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return "", code.ErrEmailExistBefore
+		}
+		logger.Logger.Errorf("update user email failed: %v", err)
+		return "", code.ErrDatabase
+	}
+	redisClient.Del(ctx, emailChangeKey(userID), emailChangeAttemptsKey(userID))
+	migrateEmailKeys(ctx, userID, oldEmail, pending.Email)
+
+	logService := CreateOperationLogService{
+		UserID:        user.ID,
+		UserName:      user.UserName,
+		OperationType: "email_change",
+		OperationDesc: "修改邮箱",
+		RequestIP:     service.IP,
+		UserAgent:     service.UserAgent,
+		RequestData:   `{"new_email":"` + utils.MaskEmail(pending.Email) + `"}`,
+		Status:        "success",
+	}
+	if err := logService.Create(); err != nil {
+		logger.Logger.Errorf("log email change failed: %v", err)
+	}
+
+	if oldEmail != "" {
+		message := dto.EmailChangedMessage{
+			Email:     oldEmail,
+			UserName:  user.UserName,
+			NewEmail:  utils.MaskEmail(pending.Email),
+			ChangedAt: time.Now().Format("2006-01-02 15:04"),
+		}
+		if err := publishEmailMessage(ctx, "email_changed", oldEmail, message); err != nil {
+			logger.Logger.Errorf("send email changed notice failed: %v", err)
+		}
+	}
+	return pending.Email, nil
+}
+
+// checkEmailAvailable 检查邮箱是否已被其他账号使用，不区分大小写
+func checkEmailAvailable(db *gorm.DB, email string, userID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.User{}).Where("LOWER(email) = ? AND id <> ?", strings.ToLower(email), userID).
+		Count(&count).Error; err != nil {
+		logger.Logger.Errorf("check user email failed: %v", err)
+		return code.ErrDatabase
+	}
+	if count > 0 {
+		return code.ErrEmailExistBefore
+	}
+	return nil
+}
+
+// migrateEmailKeys 迁移按邮箱保存的Redis数据
+// 重置密码的限流计数迁移到新邮箱，避免修改邮箱后绕过限流；发送到旧邮箱的重置密码链接失效
+func migrateEmailKeys(ctx context.Context, userID, oldEmail, newEmail string) {
+	redisClient := redis.GetRedisClient()
+	prefix := config.Conf.Redis.KeyPrefix
+
+	oldLimitKey := prefix + ":password_reset_limit:email:" + strings.ToLower(oldEmail)
+	newLimitKey := prefix + ":password_reset_limit:email:" + strings.ToLower(newEmail)
+	if err := redisClient.Rename(ctx, oldLimitKey, newLimitKey).Err(); err != nil && !strings.Contains(err.Error(), "no such key") {
+		logger.Logger.Errorf("redis rename %s failed: %v", oldLimitKey, err)
+	}
+
+	userKey := prefix + ":password_reset_user:" + userID
+	if tokenHash, err := redisClient.GetDel(ctx, userKey).Result(); err == nil {
+		redisClient.Del(ctx, prefix+":password_reset:"+tokenHash)
+	}
+}
+
+// hashEmailChangeCode Redis中只保存验证码的哈希
+func hashEmailChangeCode(verificationCode string) string {
+	sum := sha256.Sum256([]byte(verificationCode))
+	return hex.EncodeToString(sum[:])
+}
+
+func emailChangeKey(userID string) string {
+	return config.Conf.Redis.KeyPrefix + ":email_change:" + userID
+}
+
+func emailChangeAttemptsKey(userID string) string {
+	return config.Conf.Redis.KeyPrefix + ":email_change_attempts:" + userID
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestUserEmailUniqueIndex(t *testing.T) {
+	newTestDB(t, &models.User{})
+	alice := createTestUser(t, "alice")
+
+	duplicate := &models.User{UserName: "alice2", Email: "ALICE@example.com", Role: "user", Status: "active"}
+	if err := models.DB.Create(duplicate).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("create user with duplicate email error = %v, want ErrDuplicatedKey", err)
+	}
+
+	// 删除的账号不占用邮箱
+	if err := models.DB.Delete(alice).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := models.DB.Create(duplicate).Error; err != nil {
+		t.Errorf("create user with email of deleted user error = %v", err)
+	}
+
+	// 没有邮箱的账号不受限制
+	for _, userName := range []string{"empty1", "empty2"} {
+		if err := models.DB.Create(&models.User{UserName: userName, Role: "user", Status: "active"}).Error; err != nil {
+			t.Errorf("create user without email error = %v", err)
+		}
+	}
+}
+
+func TestConfirmEmailChangeDuplicateEmail(t *testing.T) {
+	db := newTestDB(t, &models.User{})
+	newTestRedis(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+
+	pending, _ := json.Marshal(pendingEmailChange{Email: "new@example.com", CodeHash: hashEmailChangeCode("123456")})
+	if err := redis.GetRedisClient().Set(context.Background(), emailChangeKey(alice.ID.String()), pending, time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 检查邮箱之后、更新邮箱之前，另一个账号改成了同一个邮箱
+	err := db.Callback().Update().Before("gorm:update").Register("test:concurrent_email_change", func(tx *gorm.DB) {
+		if user, ok := tx.Statement.Model.(*models.User); !ok || user.ID != alice.ID {
+			return
+		}
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE users SET email = ? WHERE id = ?", "NEW@example.com", bob.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &ConfirmEmailChangeService{VerificationCode: "123456", UserID: alice.ID}
+	if _, err := service.Confirm(); err != code.ErrEmailExistBefore {
+		t.Fatalf("Confirm() error = %v, want ErrEmailExistBefore", err)
+	}
+	if err := models.DB.First(alice, "id = ?", alice.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if alice.Email != "alice@example.com" {
+		t.Errorf("email = %q, want unchanged", alice.Email)
+	}
+}
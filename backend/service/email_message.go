@@ -0,0 +1,35 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/mq"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// publishEmailMessage 将邮件消息发送到Kafka，由邮件消费者异步发送
+// 使用邮箱作为key，使同一个邮箱的邮件都到同一个分区中
+func publishEmailMessage(ctx context.Context, topic, email string, message interface{}) error {
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		logger.Logger.Errorf("marshal %s message failed: %v", topic, err)
+		return err
+	}
+	producer, err := mq.NewKafkaAsyncProducer()
+	if err != nil {
+		logger.Logger.Errorf("get kafka producer failed: %v", err)
+		return err
+	}
+	if err := producer.SendMessage(ctx, topic, email, messageJSON); err != nil {
+		logger.Logger.Errorf("send %s message failed: %v", topic, err)
+		return err
+	}
+	return nil
+}
+
+// siteURL 生成前端页面的完整地址，用于邮件中的链接
+func siteURL(path string) string {
+	return strings.TrimRight(config.Conf.App.SiteURL, "/") + path
+}
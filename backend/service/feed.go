@@ -0,0 +1,194 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"bytes"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// articlePublishedAt 文章的发布时间，旧数据没有发布时间时使用创建时间
+	articlePublishedAt = "COALESCE(articles.published_at, articles.created_at)"
+	// defaultFeedLimit 动态每页的默认数量
+	defaultFeedLimit = 20
+)
+
+// FeedItemType 动态类型
+type FeedItemType string
+
+const (
+	FeedItemArticle FeedItemType = "article" // 发布文章
+	FeedItemPhoto   FeedItemType = "photo"   // 上传照片
+)
+
+// FeedItem 动态中的一项，Article和Photo只有一个不为空
+type FeedItem struct {
+	Type        FeedItemType            `json:"type"`              // 动态类型
+	ID          uuid.UUID               `json:"id"`                // 文章或照片的ID
+	PublishedAt time.Time               `json:"published_at"`      // 发布时间，动态按该时间倒序排列
+	Author      PublicUserSummary       `json:"author"`            // 作者
+	Article     *models.Article         `json:"article,omitempty"` // 文章，不包含正文
+	Photo       *models.DailyPhotograph `json:"photo,omitempty"`   // 照片
+}
+
+// feedCursor 动态的分页游标，表示上一页最后一项的位置
+type feedCursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// encodeFeedCursor 将游标编码为不透明的字符串
+func encodeFeedCursor(cursor feedCursor) string {
+	raw := cursor.Time.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeFeedCursor 解析客户端传回的游标
+func decodeFeedCursor(value string) (*feedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, code.ErrFeedCursorInvalid
+	}
+	timePart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, code.ErrFeedCursorInvalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, timePart)
+	if err != nil {
+		return nil, code.ErrFeedCursorInvalid
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return nil, code.ErrFeedCursorInvalid
+	}
+	return &feedCursor{Time: t, ID: id}, nil
+}
+
+// feedItemBefore 动态的排序规则：发布时间倒序，时间相同时按ID倒序，与数据库中的排序一致
+func feedItemBefore(a, b FeedItem) bool {
+	if !a.PublishedAt.Equal(b.PublishedAt) {
+		return a.PublishedAt.After(b.PublishedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
+
+// mergeFeedItems 合并文章和照片，返回前limit项和下一页的游标，没有下一页时游标为空
+// 每种来源最多取limit+1项，合并后的前limit项一定在其中
+func mergeFeedItems(articles, photos []FeedItem, limit int) ([]FeedItem, string) {
+	items := make([]FeedItem, 0, len(articles)+len(photos))
+	items = append(items, articles...)
+	items = append(items, photos...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return feedItemBefore(items[i], items[j])
+	})
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	last := items[len(items)-1]
+	return items, encodeFeedCursor(feedCursor{Time: last.PublishedAt, ID: last.ID})
+}
+
+// FeedService 关注动态服务结构体
+type FeedService struct {
+	Cursor string    `form:"cursor"`                                 // 上一页返回的游标，为空时从最新的动态开始
+	Limit  int       `form:"limit" binding:"omitempty,min=1,max=50"` // 每页数量，默认为20，最大为50
+	UserID uuid.UUID `json:"-" form:"-"`                             // 当前用户ID，从JWT中获取
+}
+
+// Feed 获取关注的作者发布的文章和公开的照片，按发布时间倒序合并
+// 返回动态列表和下一页的游标
+func (service *FeedService) Feed() ([]FeedItem, string, error) {
+	limit := service.Limit
+	if limit == 0 {
+		limit = defaultFeedLimit
+	}
+	var cursor *feedCursor
+	if service.Cursor != "" {
+		var err error
+		if cursor, err = decodeFeedCursor(service.Cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// 已删除和被封禁的作者的内容不出现在动态中
+	followees := models.DB.Model(&models.Follow{}).Select("follows.followee_id").
+		Joins(activeFollowJoin("follows.followee_id")).
+		Where("follows.follower_id = ?", service.UserID)
+
+	articleQuery := models.DB.Model(&models.Article{}).Omit("content").
+		Where("status = ? AND user_id IN (?)", models.ArticleStatusPublished, followees)
+	if cursor != nil {
+		articleQuery = articleQuery.Where("("+articlePublishedAt+", articles.id) < (?, ?)", cursor.Time, cursor.ID)
+	}
+	var articles []models.Article
+	if err := articleQuery.Order(articlePublishedAt + " DESC").Order("articles.id DESC").
+		Limit(limit + 1).Find(&articles).Error; err != nil {
+		logger.Logger.Errorf("list feed articles failed: %v", err)
+		return nil, "", code.ErrDatabase
+	}
+
+	photoQuery := models.DB.Model(&models.DailyPhotograph{}).
+		Where("is_public = ? AND user_id IN (?)", true, followees)
+	if cursor != nil {
+		photoQuery = photoQuery.Where("(created_at, id) < (?, ?)", cursor.Time, cursor.ID)
+	}
+	var photos []models.DailyPhotograph
+	if err := photoQuery.Order("created_at DESC").Order("id DESC").
+		Limit(limit + 1).Find(&photos).Error; err != nil {
+		logger.Logger.Errorf("list feed photos failed: %v", err)
+		return nil, "", code.ErrDatabase
+	}
+
+	articleItems := make([]FeedItem, 0, len(articles))
+	for i := range articles {
+		publishedAt := articles[i].CreatedAt
+		if articles[i].PublishedAt != nil {
+			publishedAt = *articles[i].PublishedAt
+		}
+		articleItems = append(articleItems, FeedItem{
+			Type:        FeedItemArticle,
+			ID:          articles[i].ID,
+			PublishedAt: publishedAt,
+			Article:     &articles[i],
+		})
+	}
+	photoItems := make([]FeedItem, 0, len(photos))
+	for i := range photos {
+		photoItems = append(photoItems, FeedItem{
+			Type:        FeedItemPhoto,
+			ID:          photos[i].ID,
+			PublishedAt: photos[i].CreatedAt,
+			Photo:       &photos[i],
+		})
+	}
+	items, next := mergeFeedItems(articleItems, photoItems, limit)
+
+	authorIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		if item.Article != nil {
+			authorIDs = append(authorIDs, item.Article.UserID)
+		} else {
+			authorIDs = append(authorIDs, item.Photo.UserID)
+		}
+	}
+	authors, err := loadPublicUserSummaries(authorIDs)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range items {
+		if items[i].Article != nil {
+			items[i].Author = authors[items[i].Article.UserID]
+		} else {
+			items[i].Author = authors[items[i].Photo.UserID]
+		}
+	}
+	return items, next, nil
+}
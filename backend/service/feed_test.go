@@ -0,0 +1,68 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFeedCursor(t *testing.T) {
+	cursor := feedCursor{Time: time.Date(2026, 10, 1, 8, 30, 0, 123456000, time.UTC), ID: uuid.New()}
+	got, err := decodeFeedCursor(encodeFeedCursor(cursor))
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	if !got.Time.Equal(cursor.Time) || got.ID != cursor.ID {
+		t.Errorf("decodeFeedCursor() = %+v, want %+v", got, cursor)
+	}
+	for _, value := range []string{"not base64!", "bm8gc2VwYXJhdG9y", "YWJjfGRlZg"} {
+		if _, err := decodeFeedCursor(value); err != code.ErrFeedCursorInvalid {
+			t.Errorf("decodeFeedCursor(%q) error = %v, want %v", value, err, code.ErrFeedCursorInvalid)
+		}
+	}
+}
+
+func TestMergeFeedItems(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	item := func(itemType FeedItemType, hours int, id string) FeedItem {
+		return FeedItem{Type: itemType, ID: uuid.MustParse(id), PublishedAt: base.Add(time.Duration(hours) * time.Hour)}
+	}
+	articles := []FeedItem{
+		item(FeedItemArticle, 5, "00000000-0000-0000-0000-000000000005"),
+		item(FeedItemArticle, 3, "00000000-0000-0000-0000-000000000003"),
+		item(FeedItemArticle, 1, "00000000-0000-0000-0000-000000000001"),
+	}
+	photos := []FeedItem{
+		item(FeedItemPhoto, 4, "00000000-0000-0000-0000-000000000004"),
+		// 与文章的发布时间相同时按ID倒序
+		item(FeedItemPhoto, 3, "00000000-0000-0000-0000-000000000009"),
+	}
+
+	items, next := mergeFeedItems(articles, photos, 3)
+	want := []string{
+		"00000000-0000-0000-0000-000000000005",
+		"00000000-0000-0000-0000-000000000004",
+		"00000000-0000-0000-0000-000000000009",
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, id := range want {
+		if items[i].ID.String() != id {
+			t.Errorf("items[%d] = %s, want %s", i, items[i].ID, id)
+		}
+	}
+	cursor, err := decodeFeedCursor(next)
+	if err != nil {
+		t.Fatalf("decode next cursor: %v", err)
+	}
+	if cursor.ID != items[2].ID || !cursor.Time.Equal(items[2].PublishedAt) {
+		t.Errorf("next cursor = %+v, want last item", cursor)
+	}
+
+	if items, next := mergeFeedItems(articles, photos, 5); len(items) != 5 || next != "" {
+		t.Errorf("last page: got %d items, next %q", len(items), next)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// PublicUserSummary 用户的公开摘要，用于关注列表和动态中展示作者
+type PublicUserSummary struct {
+	UserName       string            `json:"user_name"`       // 用户名
+	Nickname       string            `json:"nickname"`        // 昵称
+	Avatar         string            `json:"avatar"`          // 头像
+	AvatarVariants map[string]string `json:"avatar_variants"` // 不同尺寸头像的URL
+	Bio            string            `json:"bio"`             // 个人简介
+}
+
+// newPublicUserSummary 从用户信息生成公开摘要
+func newPublicUserSummary(user *models.User) PublicUserSummary {
+	return PublicUserSummary{
+		UserName:       user.UserName,
+		Nickname:       user.Nickname,
+		Avatar:         user.Avatar,
+		AvatarVariants: user.AvatarVariants,
+		Bio:            user.Bio,
+	}
+}
+
+// FollowListItem 关注列表中的一项
+type FollowListItem struct {
+	PublicUserSummary
+	FollowedAt time.Time `json:"followed_at"` // 关注时间
+}
+
+// FollowUserService 关注用户服务结构体
+type FollowUserService struct {
+	UserName string    `uri:"username" binding:"required"` // 被关注的用户名，从URL路径获取
+	UserID   uuid.UUID `uri:"-" json:"-" form:"-"`         // 当前用户ID，从JWT中获取
+}
+
+// Follow 关注用户，已经关注时直接返回成功
+func (service *FollowUserService) Follow() error {
+	followee, err := findPublicUser(service.UserName)
+	if err != nil {
+		return err
+	}
+	if followee.ID == service.UserID {
+		return code.ErrFollowSelf
+	}
+	follow := models.Follow{FollowerID: service.UserID, FolloweeID: followee.ID}
+	if err := models.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		logger.Logger.Errorf("follow user failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// Unfollow 取消关注用户，没有关注时直接返回成功
+// 用户已被封禁或删除时也可以取消关注
+func (service *FollowUserService) Unfollow() error {
+	var followee models.User
+	if err := models.DB.Unscoped().Where("user_name = ?", service.UserName).First(&followee).Error; err != nil {
+		return code.ErrUserNotFound
+	}
+	if err := models.DB.Unscoped().Where("follower_id = ? AND followee_id = ?", service.UserID, followee.ID).
+		Delete(&models.Follow{}).Error; err != nil {
+		logger.Logger.Errorf("unfollow user failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// IsFollowing 当前用户是否关注了该用户
+func (service *FollowUserService) IsFollowing() (bool, error) {
+	followee, err := findPublicUser(service.UserName)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	if err := models.DB.Model(&models.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", service.UserID, followee.ID).
+		Count(&count).Error; err != nil {
+		logger.Logger.Errorf("check follow failed: %v", err)
+		return false, code.ErrDatabase
+	}
+	return count > 0, nil
+}
+
+// ListFollowsService 获取关注列表或粉丝列表服务结构体
+type ListFollowsService struct {
+	UserName string `uri:"username" form:"-" binding:"required"`    // 用户名，从URL路径获取
+	Page     int    `form:"page" binding:"omitempty,min=1"`         // 页码，默认为1
+	Size     int    `form:"size" binding:"omitempty,min=1,max=100"` // 每页数量，默认为20，最大为100
+}
+
+// Followers 分页获取关注该用户的用户，按关注时间倒序
+func (service *ListFollowsService) Followers() ([]FollowListItem, int64, error) {
+	return service.list("follows.followee_id = ?", "follows.follower_id")
+}
+
+// Following 分页获取该用户关注的用户，按关注时间倒序
+func (service *ListFollowsService) Following() ([]FollowListItem, int64, error) {
+	return service.list("follows.follower_id = ?", "follows.followee_id")
+}
+
+// list 查询关注关系，where为该用户所在的一侧，joinColumn为另一侧的用户
+// 已删除和被封禁的用户不会出现在列表中，也不计入总数
+func (service *ListFollowsService) list(where, joinColumn string) ([]FollowListItem, int64, error) {
+	user, err := findPublicUser(service.UserName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if service.Page == 0 {
+		service.Page = 1
+	}
+	if service.Size == 0 {
+		service.Size = 20
+	}
+
+	query := models.DB.Model(&models.Follow{}).
+		Joins(activeFollowJoin(joinColumn)).
+		Where(where, user.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count follows failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var follows []struct {
+		UserID    uuid.UUID
+		CreatedAt time.Time
+	}
+	if err := query.Select(joinColumn + " AS user_id, follows.created_at").
+		Order("follows.created_at DESC").
+		Offset((service.Page - 1) * service.Size).Limit(service.Size).
+		Scan(&follows).Error; err != nil {
+		logger.Logger.Errorf("list follows failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+
+	ids := make([]uuid.UUID, 0, len(follows))
+	for _, follow := range follows {
+		ids = append(ids, follow.UserID)
+	}
+	users, err := loadPublicUserSummaries(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]FollowListItem, 0, len(follows))
+	for _, follow := range follows {
+		items = append(items, FollowListItem{PublicUserSummary: users[follow.UserID], FollowedAt: follow.CreatedAt})
+	}
+	return items, total, nil
+}
+
+// loadPublicUserSummaries 批量查询用户的公开摘要
+func loadPublicUserSummaries(ids []uuid.UUID) (map[uuid.UUID]PublicUserSummary, error) {
+	summaries := make(map[uuid.UUID]PublicUserSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	var users []models.User
+	if err := models.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		logger.Logger.Errorf("get users failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	for i := range users {
+		summaries[users[i].ID] = newPublicUserSummary(&users[i])
+	}
+	return summaries, nil
+}
+
+// countFollows 统计用户的粉丝数和关注数，已删除和被封禁的用户不计入
+func countFollows(userID uuid.UUID) (followers, following int64, err error) {
+	if err = models.DB.Model(&models.Follow{}).
+		Joins(activeFollowJoin("follows.follower_id")).
+		Where("follows.followee_id = ?", userID).Count(&followers).Error; err != nil {
+		logger.Logger.Errorf("count followers failed: %v", err)
+		return 0, 0, code.ErrDatabase
+	}
+	if err = models.DB.Model(&models.Follow{}).
+		Joins(activeFollowJoin("follows.followee_id")).
+		Where("follows.follower_id = ?", userID).Count(&following).Error; err != nil {
+		logger.Logger.Errorf("count following failed: %v", err)
+		return 0, 0, code.ErrDatabase
+	}
+	return followers, following, nil
+}
+
+// activeFollowJoin 关联关注关系另一侧的用户，过滤已删除和被封禁的用户
+func activeFollowJoin(column string) string {
+	return "JOIN users ON users.id = " + column + " AND users.deleted_at IS NULL AND users.status <> 'suspended'"
+}
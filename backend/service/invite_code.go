@@ -0,0 +1,285 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/rbac"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 注册方式
+const (
+	RegistrationModeOpen       = "open"        // 开放注册
+	RegistrationModeInviteOnly = "invite_only" // 需要邀请码
+	RegistrationModeClosed     = "closed"      // 关闭注册
+)
+
+const (
+	// inviteCodeAlphabet 邀请码使用的字符，去掉了容易混淆的0、O、1、I
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// inviteCodeLength 邀请码的长度
+	inviteCodeLength = 12
+	// defaultInviteRole 邀请码默认的角色
+	defaultInviteRole = "user"
+	// userInviteMaxUses 没有users:manage权限的用户创建的邀请码最多可以使用的次数
+	userInviteMaxUses = 10
+	// userInviteMaxDays 没有users:manage权限的用户创建的邀请码的最长有效期（天）
+	userInviteMaxDays = 30
+)
+
+// registrationMode 获取配置的注册方式，为空时开放注册，无法识别的值按关闭注册处理
+func registrationMode() string {
+	switch mode := strings.TrimSpace(config.Conf.Account.RegistrationMode); mode {
+	case "", RegistrationModeOpen:
+		return RegistrationModeOpen
+	case RegistrationModeInviteOnly:
+		return RegistrationModeInviteOnly
+	default:
+		return RegistrationModeClosed
+	}
+}
+
+// checkRegistrationAllowed 检查当前注册方式是否允许注册
+func checkRegistrationAllowed(inviteCode string) error {
+	switch registrationMode() {
+	case RegistrationModeOpen:
+		return nil
+	case RegistrationModeInviteOnly:
+		if normalizeInviteCode(inviteCode) == "" {
+			return code.ErrInviteCodeRequired
+		}
+		return nil
+	default:
+		return code.ErrRegistrationClosed
+	}
+}
+
+// normalizeInviteCode 邀请码不区分大小写，忽略空格和连字符
+func normalizeInviteCode(inviteCode string) string {
+	inviteCode = strings.ToUpper(strings.TrimSpace(inviteCode))
+	return strings.NewReplacer("-", "", " ", "").Replace(inviteCode)
+}
+
+// generateInviteCode 生成随机邀请码
+func generateInviteCode() (string, error) {
+	var builder strings.Builder
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := 0; i < inviteCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(inviteCodeAlphabet[n.Int64()])
+	}
+	return builder.String(), nil
+}
+
+// checkInviteCodeUsable 在消耗验证码之前检查邀请码是否可用，没有填写邀请码时不检查
+// 注册时在事务中还会再次检查
+func checkInviteCodeUsable(inviteCode string) error {
+	inviteCode = normalizeInviteCode(inviteCode)
+	if inviteCode == "" {
+		return nil
+	}
+	var invite models.InviteCode
+	if err := models.DB.Where("code = ?", inviteCode).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.ErrInviteCodeInvalid
+		}
+		logger.Logger.Errorf("get invite code failed: %v", err)
+		return code.ErrDatabase
+	}
+	if !invite.IsUsable(time.Now()) {
+		return code.ErrInviteCodeInvalid
+	}
+	return nil
+}
+
+// redeemInviteCode 在注册的事务中使用邀请码，锁定邀请码记录后增加使用次数
+// 返回邀请码，注册的用户使用邀请码的角色并记录邀请人
+func redeemInviteCode(tx *gorm.DB, inviteCode string) (*models.InviteCode, error) {
+	var invite models.InviteCode
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", normalizeInviteCode(inviteCode)).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, code.ErrInviteCodeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !invite.IsUsable(time.Now()) {
+		return nil, code.ErrInviteCodeInvalid
+	}
+	if err := tx.Model(&invite).UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// RegistrationModeResult 当前的注册方式
+type RegistrationModeResult struct {
+	Mode string `json:"mode"` // 注册方式：open, invite_only, closed
+}
+
+// GetRegistrationMode 获取当前的注册方式，前端据此决定是否显示注册入口和邀请码输入框
+func GetRegistrationMode() RegistrationModeResult {
+	return RegistrationModeResult{Mode: registrationMode()}
+}
+
+// CreateInviteCodeService 创建邀请码服务结构体
+// 所有登录用户都可以创建邀请码；没有users:manage权限的用户只能创建默认角色的邀请码，
+// 并且使用次数和有效期有上限；设置其他角色需要roles:manage权限
+type CreateInviteCodeService struct {
+	Role          string    `json:"role" form:"role"`                                                         // 注册用户的角色，默认为user
+	MaxUses       int       `json:"max_uses" form:"max_uses" binding:"omitempty,min=1,max=1000"`              // 最多可以使用的次数，默认为1
+	ExpiresInDays int       `json:"expires_in_days" form:"expires_in_days" binding:"omitempty,min=1,max=365"` // 有效期（天），默认为7天
+	Note          string    `json:"note" form:"note" binding:"max=255"`                                       // 备注
+	UserID        uuid.UUID `json:"-" form:"-"`                                                               // 当前用户ID，从JWT中获取
+	UserRole      string    `json:"-" form:"-"`                                                               // 当前用户角色，从JWT中获取
+}
+
+// Create 创建邀请码
+func (service *CreateInviteCodeService) Create() (*models.InviteCode, error) {
+	role := strings.TrimSpace(service.Role)
+	if role == "" {
+		role = defaultInviteRole
+	}
+	maxUses := service.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	days := service.ExpiresInDays
+	if days == 0 {
+		days = 7
+	}
+
+	canManageUsers, err := rbac.HasPermission(service.UserRole, rbac.PermUsersManage)
+	if err != nil {
+		logger.Logger.Errorf("check permission failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if !canManageUsers && (maxUses > userInviteMaxUses || days > userInviteMaxDays) {
+		return nil, code.ErrPermissionDenied
+	}
+	if role != defaultInviteRole {
+		canManageRoles, err := rbac.HasPermission(service.UserRole, rbac.PermRolesManage)
+		if err != nil {
+			logger.Logger.Errorf("check permission failed: %v", err)
+			return nil, code.ErrDatabase
+		}
+		if !canManageRoles {
+			return nil, code.ErrPermissionDenied
+		}
+	}
+	var roleCount int64
+	if err := models.DB.Model(&models.Role{}).Where("name = ?", role).Count(&roleCount).Error; err != nil {
+		logger.Logger.Errorf("check role failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if roleCount == 0 {
+		return nil, code.ErrRoleNotFound
+	}
+
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		logger.Logger.Errorf("generate invite code failed: %v", err)
+		return nil, code.InternalServerError
+	}
+	invite := models.InviteCode{
+		Code:      inviteCode,
+		CreatedBy: service.UserID,
+		Role:      role,
+		MaxUses:   maxUses,
+		Note:      strings.TrimSpace(service.Note),
+		ExpiresAt: time.Now().AddDate(0, 0, days),
+	}
+	if err := models.DB.Create(&invite).Error; err != nil {
+		logger.Logger.Errorf("create invite code failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &invite, nil
+}
+
+// InviteCodeWithInvitees 邀请码及使用该邀请码注册的用户
+type InviteCodeWithInvitees struct {
+	models.InviteCode
+	Invitees []PublicUserSummary `json:"invitees"` // 使用该邀请码注册的用户
+}
+
+// ListInviteCodesService 获取自己创建的邀请码服务结构体
+type ListInviteCodesService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 当前用户ID，从JWT中获取
+}
+
+// List 获取自己创建的邀请码和通过邀请码注册的用户，按创建时间倒序
+func (service *ListInviteCodesService) List() ([]InviteCodeWithInvitees, error) {
+	var invites []models.InviteCode
+	if err := models.DB.Where("created_by = ?", service.UserID).Order("created_at DESC").Find(&invites).Error; err != nil {
+		logger.Logger.Errorf("list invite codes failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	var invitees []models.User
+	if err := models.DB.Where("invited_by = ?", service.UserID).Order("created_at ASC").Find(&invitees).Error; err != nil {
+		logger.Logger.Errorf("list invitees failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	byInvite := make(map[uuid.UUID][]PublicUserSummary)
+	for i := range invitees {
+		if invitees[i].InviteCodeID != nil {
+			byInvite[*invitees[i].InviteCodeID] = append(byInvite[*invitees[i].InviteCodeID], newPublicUserSummary(&invitees[i]))
+		}
+	}
+	result := make([]InviteCodeWithInvitees, 0, len(invites))
+	for _, invite := range invites {
+		users := byInvite[invite.ID]
+		if users == nil {
+			users = []PublicUserSummary{}
+		}
+		result = append(result, InviteCodeWithInvitees{InviteCode: invite, Invitees: users})
+	}
+	return result, nil
+}
+
+// RevokeInviteCodeService 作废邀请码服务结构体
+type RevokeInviteCodeService struct {
+	ID       string    `uri:"id" binding:"required,uuid"` // 邀请码ID，从URL路径获取
+	UserID   uuid.UUID `uri:"-" json:"-" form:"-"`        // 当前用户ID，从JWT中获取
+	UserRole string    `uri:"-" json:"-" form:"-"`        // 当前用户角色，从JWT中获取
+}
+
+// Revoke 作废邀请码，已经注册的用户不受影响
+// 只能作废自己创建的邀请码，拥有users:manage权限的用户可以作废任何邀请码
+func (service *RevokeInviteCodeService) Revoke() error {
+	var invite models.InviteCode
+	if err := models.DB.Where("id = ?", service.ID).First(&invite).Error; err != nil {
+		return code.ErrInviteCodeNotFound
+	}
+	if invite.CreatedBy != service.UserID {
+		allowed, err := rbac.HasPermission(service.UserRole, rbac.PermUsersManage)
+		if err != nil {
+			logger.Logger.Errorf("check permission failed: %v", err)
+			return code.ErrDatabase
+		}
+		if !allowed {
+			return code.ErrInviteCodeNotFound
+		}
+	}
+	if invite.RevokedAt != nil {
+		return nil
+	}
+	if err := models.DB.Model(&invite).Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Logger.Errorf("revoke invite code failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
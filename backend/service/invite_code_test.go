@@ -0,0 +1,147 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/models"
+	"blog-server/internal/rbac"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateInviteCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		inviteCode, err := generateInviteCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inviteCode) != inviteCodeLength {
+			t.Fatalf("unexpected length: %q", inviteCode)
+		}
+		for _, r := range inviteCode {
+			if !strings.ContainsRune(inviteCodeAlphabet, r) {
+				t.Fatalf("unexpected character %q in %q", r, inviteCode)
+			}
+		}
+		if normalizeInviteCode(strings.ToLower(inviteCode[:4])+"-"+inviteCode[4:]) != inviteCode {
+			t.Fatalf("normalize failed for %q", inviteCode)
+		}
+		if seen[inviteCode] {
+			t.Fatalf("duplicate invite code %q", inviteCode)
+		}
+		seen[inviteCode] = true
+	}
+}
+
+func TestInviteCodeIsUsable(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+	tests := []struct {
+		name   string
+		invite models.InviteCode
+		want   bool
+	}{
+		{"unused", models.InviteCode{MaxUses: 1, ExpiresAt: now.Add(time.Hour)}, true},
+		{"multi use", models.InviteCode{MaxUses: 3, UsedCount: 2, ExpiresAt: now.Add(time.Hour)}, true},
+		{"used up", models.InviteCode{MaxUses: 3, UsedCount: 3, ExpiresAt: now.Add(time.Hour)}, false},
+		{"expired", models.InviteCode{MaxUses: 1, ExpiresAt: now.Add(-time.Hour)}, false},
+		{"revoked", models.InviteCode{MaxUses: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invite.IsUsable(now); got != tt.want {
+				t.Errorf("IsUsable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRegistrationAllowed(t *testing.T) {
+	defer func(mode string) { config.Conf.Account.RegistrationMode = mode }(config.Conf.Account.RegistrationMode)
+
+	tests := []struct {
+		mode       string
+		inviteCode string
+		want       error
+	}{
+		{"", "", nil},
+		{RegistrationModeOpen, "", nil},
+		{RegistrationModeOpen, "ABCD", nil},
+		{RegistrationModeInviteOnly, "", code.ErrInviteCodeRequired},
+		{RegistrationModeInviteOnly, " - ", code.ErrInviteCodeRequired},
+		{RegistrationModeInviteOnly, "ABCD", nil},
+		{RegistrationModeClosed, "ABCD", code.ErrRegistrationClosed},
+		{"unknown", "ABCD", code.ErrRegistrationClosed},
+	}
+	for _, tt := range tests {
+		config.Conf.Account.RegistrationMode = tt.mode
+		if got := checkRegistrationAllowed(tt.inviteCode); got != tt.want {
+			t.Errorf("mode %q, invite code %q: got %v, want %v", tt.mode, tt.inviteCode, got, tt.want)
+		}
+	}
+}
+
+func TestRevokeInviteCode(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.Role{}, &models.InviteCode{})
+	rbac.Invalidate()
+	t.Cleanup(rbac.Invalidate)
+	for _, role := range []models.Role{
+		{Name: "user", Permissions: []string{rbac.PermArticlesWrite}},
+		{Name: "manager", Permissions: []string{rbac.PermUsersManage}},
+	} {
+		if err := models.DB.Create(&role).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	creator := createTestUser(t, "alice")
+	other := createTestUser(t, "bob")
+	manager := createTestUser(t, "carol")
+
+	newInvite := func() *models.InviteCode {
+		inviteCode, err := generateInviteCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		invite := &models.InviteCode{Code: inviteCode, CreatedBy: creator.ID, Role: "user", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := models.DB.Create(invite).Error; err != nil {
+			t.Fatal(err)
+		}
+		return invite
+	}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		role   string
+		want   error
+	}{
+		{"creator without users:manage", creator.ID, "user", nil},
+		{"other user", other.ID, "user", code.ErrInviteCodeNotFound},
+		{"users:manage", manager.ID, "manager", nil},
+		{"admin", other.ID, rbac.RoleAdmin, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite := newInvite()
+			service := &RevokeInviteCodeService{ID: invite.ID.String(), UserID: tt.userID, UserRole: tt.role}
+			if err := service.Revoke(); err != tt.want {
+				t.Fatalf("Revoke() error = %v, want %v", err, tt.want)
+			}
+			if err := models.DB.First(invite, "id = ?", invite.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if revoked := invite.RevokedAt != nil; revoked != (tt.want == nil) {
+				t.Errorf("revoked = %v, want %v", revoked, tt.want == nil)
+			}
+		})
+	}
+
+	service := &RevokeInviteCodeService{ID: uuid.NewString(), UserID: creator.ID, UserRole: "user"}
+	if err := service.Revoke(); err != code.ErrInviteCodeNotFound {
+		t.Errorf("Revoke() unknown invite error = %v, want ErrInviteCodeNotFound", err)
+	}
+}
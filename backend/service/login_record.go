@@ -0,0 +1,255 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/dto"
+	"blog-server/internal/geoip"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"blog-server/internal/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// loginAlertTTL 登录提醒邮件中"不是我本人登录"链接的有效期
+	loginAlertTTL = 7 * 24 * time.Hour
+	// defaultLoginHistoryDays 登录记录默认保留的天数
+	defaultLoginHistoryDays = 180
+)
+
+// newLoginRecord 根据登录设备的信息生成登录记录，设备和位置分别根据用户代理和IP识别
+func newLoginRecord(user *models.User, meta SessionMeta) *models.LoginRecord {
+	location := geoip.Lookup(meta.IP)
+	return &models.LoginRecord{
+		UserID:     user.ID,
+		Method:     meta.Method,
+		IP:         meta.IP,
+		UserAgent:  truncateRunes(meta.UserAgent, 500),
+		DeviceName: truncateRunes(utils.DeviceNameFromUserAgent(meta.UserAgent), 100),
+		Country:    truncateRunes(location.Country, 64),
+		Region:     truncateRunes(location.Region, 100),
+		City:       truncateRunes(location.City, 100),
+		LoggedAt:   time.Now(),
+	}
+}
+
+// recordLoginFailure 记录失败的登录，记录失败不影响登录结果
+func recordLoginFailure(user *models.User, meta SessionMeta, reason string) {
+	record := newLoginRecord(user, meta)
+	record.FailureReason = reason
+	if err := models.DB.Create(record).Error; err != nil {
+		logger.Logger.Errorf("create login record failed: %v", err)
+	}
+}
+
+// recordLoginSuccess 记录成功的登录，从新设备或新位置登录时发送提醒邮件
+// 记录或发送邮件失败不影响登录结果
+func recordLoginSuccess(user *models.User, meta SessionMeta, session *models.UserSession) {
+	record := newLoginRecord(user, meta)
+	record.Success = true
+	record.SessionID = &session.ID
+
+	// 用户确认不是本人的登录不作为已知的设备和位置
+	var previous []models.LoginRecord
+	if err := models.DB.Model(&models.LoginRecord{}).
+		Distinct("device_name", "country", "region").
+		Where("user_id = ? AND success = ? AND reported_at IS NULL", user.ID, true).
+		Find(&previous).Error; err != nil {
+		logger.Logger.Errorf("list login records failed: %v", err)
+		return
+	}
+	record.NewDevice, record.NewLocation = detectNewLogin(previous, record)
+
+	if err := models.DB.Create(record).Error; err != nil {
+		logger.Logger.Errorf("create login record failed: %v", err)
+		return
+	}
+	if record.NewDevice || record.NewLocation {
+		if err := sendLoginAlert(user, record); err != nil {
+			logger.Logger.Errorf("send login alert failed: %v", err)
+		}
+	}
+}
+
+// detectNewLogin 与之前成功的登录比较，判断是否为新设备或新位置
+// 第一次登录不算新设备；查不到位置时不判断位置，之前的登录都没有位置时也不判断
+func detectNewLogin(previous []models.LoginRecord, record *models.LoginRecord) (newDevice, newLocation bool) {
+	if len(previous) == 0 {
+		return false, false
+	}
+	newDevice = true
+	knownLocation := false
+	newLocation = record.Country != ""
+	for _, p := range previous {
+		if p.DeviceName == record.DeviceName {
+			newDevice = false
+		}
+		if p.Country == "" {
+			continue
+		}
+		knownLocation = true
+		if p.Country == record.Country && p.Region == record.Region {
+			newLocation = false
+		}
+	}
+	return newDevice, newLocation && knownLocation
+}
+
+// loginAlertKey 登录提醒令牌在Redis中的key，只保存令牌的哈希
+func loginAlertKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return config.Conf.Redis.KeyPrefix + ":login_alert:" + hex.EncodeToString(sum[:])
+}
+
+// sendLoginAlert 发送新设备或新位置登录的提醒邮件
+// 邮件中的链接可以吊销这次登录的会话并要求重置密码
+func sendLoginAlert(user *models.User, record *models.LoginRecord) error {
+	if user.Email == "" {
+		return nil
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(buf)
+	ctx := context.Background()
+	if err := redis.GetRedisClient().Set(ctx, loginAlertKey(token), record.ID.String(), loginAlertTTL).Err(); err != nil {
+		return err
+	}
+
+	location := geoip.Location{Country: record.Country, Region: record.Region, City: record.City}.String()
+	if location == "" {
+		location = "未知"
+	}
+	message := dto.LoginAlertMessage{
+		Email:      user.Email,
+		UserName:   user.UserName,
+		DeviceName: record.DeviceName,
+		IP:         record.IP,
+		Location:   location,
+		LoginAt:    record.LoggedAt.Format("2006-01-02 15:04"),
+		DenyURL:    siteURL("/login-alert?token=" + token),
+		ExpireDays: int(loginAlertTTL / (24 * time.Hour)),
+	}
+	return publishEmailMessage(ctx, "login_alert", user.Email, message)
+}
+
+// ListLoginHistoryService 获取登录记录服务结构体
+type ListLoginHistoryService struct {
+	Page   int       `form:"page" binding:"omitempty,min=1"`         // 页码，默认为1
+	Size   int       `form:"size" binding:"omitempty,min=1,max=100"` // 每页数量，默认为20，最大为100
+	UserID uuid.UUID `json:"-" form:"-"`                             // 当前用户ID，从JWT中获取
+}
+
+// List 分页获取自己的登录记录，按登录时间倒序
+func (service *ListLoginHistoryService) List() ([]models.LoginRecord, int64, error) {
+	if service.Page == 0 {
+		service.Page = 1
+	}
+	if service.Size == 0 {
+		service.Size = 20
+	}
+	query := models.DB.Model(&models.LoginRecord{}).Where("user_id = ?", service.UserID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count login records failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var records []models.LoginRecord
+	if err := query.Order("logged_at DESC").
+		Offset((service.Page - 1) * service.Size).Limit(service.Size).
+		Find(&records).Error; err != nil {
+		logger.Logger.Errorf("list login records failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	return records, total, nil
+}
+
+// DenyLoginService 确认不是本人登录服务结构体
+type DenyLoginService struct {
+	Token     string `json:"token" form:"token" binding:"required"` // 登录提醒邮件中的令牌
+	IP        string `json:"-" form:"-"`                            // 请求IP
+	UserAgent string `json:"-" form:"-"`                            // 用户代理
+}
+
+// Deny 用户通过提醒邮件确认不是本人登录：吊销这次登录的会话，并要求重置密码
+// 要求重置密码时会吊销用户所有的会话，重置密码前所有登录方式都会被拒绝
+func (service *DenyLoginService) Deny() error {
+	ctx := context.Background()
+	recordID, err := redis.GetRedisClient().GetDel(ctx, loginAlertKey(strings.TrimSpace(service.Token))).Result()
+	if err == redis.Nil {
+		return code.ErrLoginAlertInvalid
+	}
+	if err != nil {
+		logger.Logger.Errorf("get login alert failed: %v", err)
+		return code.ErrDatabase
+	}
+
+	var record models.LoginRecord
+	if err := models.DB.Where("id = ?", recordID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.ErrLoginAlertInvalid
+		}
+		logger.Logger.Errorf("get login record failed: %v", err)
+		return code.ErrDatabase
+	}
+	var user models.User
+	if err := models.DB.Where("id = ?", record.UserID).First(&user).Error; err != nil {
+		return code.ErrLoginAlertInvalid
+	}
+
+	if record.SessionID != nil {
+		var session models.UserSession
+		if err := models.DB.Where("id = ?", *record.SessionID).First(&session).Error; err == nil && session.IsActive() {
+			if err := revokeSession(&session); err != nil {
+				return err
+			}
+		}
+	}
+	if err := models.DB.Model(&record).Update("reported_at", time.Now()).Error; err != nil {
+		logger.Logger.Errorf("update login record failed: %v", err)
+	}
+
+	logService := CreateOperationLogService{
+		UserID:        user.ID,
+		UserName:      user.UserName,
+		OperationType: "login_denied",
+		OperationDesc: "确认不是本人登录",
+		TargetID:      record.ID.String(),
+		RequestIP:     service.IP,
+		UserAgent:     service.UserAgent,
+		Status:        "success",
+	}
+	if err := logService.Create(); err != nil {
+		logger.Logger.Errorf("log login denied failed: %v", err)
+	}
+	return forcePasswordReset(&user)
+}
+
+// loginHistoryRetention 登录记录的保留时间
+func loginHistoryRetention() time.Duration {
+	days := config.Conf.Account.LoginHistoryDays
+	if days <= 0 {
+		days = defaultLoginHistoryDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// purgeLoginRecords 删除超过保留时间的登录记录
+func purgeLoginRecords() {
+	if err := models.DB.Unscoped().Where("logged_at < ?", time.Now().Add(-loginHistoryRetention())).
+		Delete(&models.LoginRecord{}).Error; err != nil {
+		logger.Logger.Errorf("purge login records failed: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"blog-server/internal/accesstoken"
+	"blog-server/internal/code"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDetectNewLogin(t *testing.T) {
+	chromeShanghai := models.LoginRecord{DeviceName: "Chrome on Windows", Country: "CN", Region: "Shanghai"}
+	safariUnknown := models.LoginRecord{DeviceName: "Safari on iOS"}
+
+	tests := []struct {
+		name         string
+		previous     []models.LoginRecord
+		record       models.LoginRecord
+		wantDevice   bool
+		wantLocation bool
+	}{
+		{
+			name:   "first login",
+			record: models.LoginRecord{DeviceName: "Chrome on Windows", Country: "US", Region: "California"},
+		},
+		{
+			name:     "known device and location",
+			previous: []models.LoginRecord{chromeShanghai},
+			record:   models.LoginRecord{DeviceName: "Chrome on Windows", Country: "CN", Region: "Shanghai", City: "Shanghai"},
+		},
+		{
+			name:       "new device",
+			previous:   []models.LoginRecord{chromeShanghai},
+			record:     models.LoginRecord{DeviceName: "Firefox on Linux", Country: "CN", Region: "Shanghai"},
+			wantDevice: true,
+		},
+		{
+			name:         "new region",
+			previous:     []models.LoginRecord{chromeShanghai},
+			record:       models.LoginRecord{DeviceName: "Chrome on Windows", Country: "CN", Region: "Beijing"},
+			wantLocation: true,
+		},
+		{
+			name:         "new device and country",
+			previous:     []models.LoginRecord{chromeShanghai, safariUnknown},
+			record:       models.LoginRecord{DeviceName: "Firefox on Linux", Country: "US", Region: "California"},
+			wantDevice:   true,
+			wantLocation: true,
+		},
+		{
+			name:     "unknown location is not new",
+			previous: []models.LoginRecord{chromeShanghai},
+			record:   models.LoginRecord{DeviceName: "Chrome on Windows"},
+		},
+		{
+			name:     "no previous location",
+			previous: []models.LoginRecord{safariUnknown},
+			record:   models.LoginRecord{DeviceName: "Safari on iOS", Country: "CN", Region: "Shanghai"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := tt.record
+			newDevice, newLocation := detectNewLogin(tt.previous, &record)
+			if newDevice != tt.wantDevice || newLocation != tt.wantLocation {
+				t.Errorf("detectNewLogin() = %v, %v, want %v, %v", newDevice, newLocation, tt.wantDevice, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestDenyLoginRevokesAccessTokens(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.UserSession{}, &models.LoginRecord{}, &models.PersonalAccessToken{}, &models.OperationLog{})
+	newTestRedis(t)
+	user := createTestUser(t, "alice")
+	token := createTestAccessToken(t, user)
+	session, err := createSession(user, SessionMeta{UserAgent: "curl/8.0", IP: "203.0.113.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := newLoginRecord(user, SessionMeta{UserAgent: "curl/8.0", IP: "203.0.113.7", Method: "password"})
+	record.Success = true
+	record.SessionID = &session.ID
+	if err := models.DB.Create(record).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), loginAlertKey("alert-token"), record.ID.String(), time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 测试环境没有Kafka，重置密码邮件可能发送失败，吊销在发送邮件之前完成
+	service := &DenyLoginService{Token: "alert-token", IP: "198.51.100.1"}
+	if err := service.Deny(); err != nil && err != code.ErrSendPasswordResetEmail {
+		t.Fatalf("Deny() error = %v", err)
+	}
+
+	if err := models.DB.First(session, "id = ?", session.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if session.RevokedAt == nil {
+		t.Error("session of the denied login should be revoked")
+	}
+	if err := models.DB.First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !user.PasswordResetRequired {
+		t.Error("password reset should be required")
+	}
+	if _, _, err := accesstoken.Authenticate(context.Background(), token, "127.0.0.1"); err != code.ErrTokenRevoked {
+		t.Errorf("Authenticate() error = %v, want ErrTokenRevoked", err)
+	}
+
+	// 提醒令牌只能使用一次
+	if err := service.Deny(); err != code.ErrLoginAlertInvalid {
+		t.Errorf("second Deny() error = %v, want ErrLoginAlertInvalid", err)
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 登录限流的默认值，配置为0时使用
+const (
+	defaultThrottleWindow              = 15 * time.Minute
+	defaultThrottleAccountFreeFailures = 5
+	defaultThrottleIPFreeFailures      = 20
+	defaultThrottleAccountChallenge    = 3
+	defaultThrottleIPChallenge         = 10
+	defaultThrottleBaseDelay           = 30 * time.Second
+	defaultThrottleMaxDelay            = 15 * time.Minute
+	defaultChallengeDifficulty         = 18
+	// powChallengeTTL 工作量证明挑战的有效期
+	powChallengeTTL = 5 * time.Minute
+)
+
+// throttlePolicy 一种限流对象（账号或IP）的限流策略
+type throttlePolicy struct {
+	Window         time.Duration // 统计失败次数的滑动窗口
+	FreeFailures   int           // 窗口内允许失败的次数，超过后需要等待
+	ChallengeAfter int           // 窗口内失败多少次后需要完成工作量证明
+	BaseDelay      time.Duration // 第一次需要等待的时间
+	MaxDelay       time.Duration // 最长等待时间
+}
+
+// backoff 窗口内失败failures次后需要等待的时间，超过允许的次数后每次失败翻倍
+func (p throttlePolicy) backoff(failures int) time.Duration {
+	over := failures - p.FreeFailures
+	if over <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < over && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// evaluate 根据窗口内失败的次数和最近一次失败的时间，返回还需要等待的时间和是否需要完成工作量证明
+func (p throttlePolicy) evaluate(failures int, lastFailure, now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	if delay := p.backoff(failures); delay > 0 {
+		if until := lastFailure.Add(delay); until.After(now) {
+			wait = until.Sub(now)
+		}
+	}
+	return wait, p.ChallengeAfter > 0 && failures >= p.ChallengeAfter
+}
+
+// durationOrDefault 配置值大于0时使用配置值，否则使用默认值
+func durationOrDefault(value int, unit, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return time.Duration(value) * unit
+	}
+	return fallback
+}
+
+// intOrDefault 配置值大于0时使用配置值，否则使用默认值
+func intOrDefault(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// accountThrottlePolicy 按账号限流的策略
+func accountThrottlePolicy() throttlePolicy {
+	conf := config.Conf.Auth.LoginThrottle
+	return throttlePolicy{
+		Window:         durationOrDefault(conf.WindowMinutes, time.Minute, defaultThrottleWindow),
+		FreeFailures:   intOrDefault(conf.AccountFreeFailures, defaultThrottleAccountFreeFailures),
+		ChallengeAfter: intOrDefault(conf.AccountChallengeAfter, defaultThrottleAccountChallenge),
+		BaseDelay:      durationOrDefault(conf.BaseDelaySeconds, time.Second, defaultThrottleBaseDelay),
+		MaxDelay:       durationOrDefault(conf.MaxDelayMinutes, time.Minute, defaultThrottleMaxDelay),
+	}
+}
+
+// ipThrottlePolicy 按IP限流的策略，防止同一个IP尝试大量账号
+func ipThrottlePolicy() throttlePolicy {
+	policy := accountThrottlePolicy()
+	conf := config.Conf.Auth.LoginThrottle
+	policy.FreeFailures = intOrDefault(conf.IPFreeFailures, defaultThrottleIPFreeFailures)
+	policy.ChallengeAfter = intOrDefault(conf.IPChallengeAfter, defaultThrottleIPChallenge)
+	return policy
+}
+
+// challengeDifficulty 工作量证明的难度
+func challengeDifficulty() int {
+	return intOrDefault(config.Conf.Auth.LoginThrottle.ChallengeDifficulty, defaultChallengeDifficulty)
+}
+
+// LoginThrottleError 登录被限流时返回的错误，包含还需要等待的时间或需要完成的工作量证明
+type LoginThrottleError struct {
+	Errno      *code.Errno   // 错误码
+	RetryAfter time.Duration // 还需要等待的时间，为0时不需要等待
+	Challenge  *PowChallenge // 需要完成的工作量证明，为空时不需要
+}
+
+func (e *LoginThrottleError) Error() string {
+	return e.Errno.Error()
+}
+
+// RetryAfterSeconds 还需要等待的秒数，向上取整
+func (e *LoginThrottleError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// loginThrottle 一次登录请求涉及的限流对象
+type loginThrottle struct {
+	accountKey string
+	ipKey      string
+}
+
+// newLoginThrottle 账号存在时按用户ID限流，使用用户名、邮箱或手机号登录都计入同一个账号；
+// 账号不存在时按输入的账号限流
+func newLoginThrottle(account string, user *models.User, ip string) *loginThrottle {
+	prefix := config.Conf.Redis.KeyPrefix + ":login_throttle:"
+	accountKey := prefix + "account:" + strings.ToLower(strings.TrimSpace(account))
+	if user != nil {
+		accountKey = userThrottleKey(user)
+	}
+	return &loginThrottle{accountKey: accountKey, ipKey: prefix + "ip:" + ip}
+}
+
+// userThrottleKey 按用户ID限流的key
+func userThrottleKey(user *models.User) string {
+	return config.Conf.Redis.KeyPrefix + ":login_throttle:user:" + user.ID.String()
+}
+
+// throttleFailures 清理窗口外的失败记录，返回窗口内失败的次数和最近一次失败的时间
+func throttleFailures(ctx context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	pipe := redis.GetRedisClient().Pipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+	count := pipe.ZCard(ctx, key)
+	last := pipe.ZRevRangeWithScores(ctx, key, 0, 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, time.Time{}, err
+	}
+	var lastFailure time.Time
+	if values := last.Val(); len(values) > 0 {
+		lastFailure = time.UnixMilli(int64(values[0].Score))
+	}
+	return int(count.Val()), lastFailure, nil
+}
+
+// Check 检查是否允许本次登录：需要等待时返回还需要等待的时间，
+// 失败次数达到阈值后需要提交有效的工作量证明
+// Redis不可用时不限流，避免影响正常登录
+func (t *loginThrottle) Check(challenge, nonce string) error {
+	ctx := context.Background()
+	now := time.Now()
+	var wait time.Duration
+	needChallenge := false
+	for _, scope := range []struct {
+		key    string
+		policy throttlePolicy
+	}{
+		{t.accountKey, accountThrottlePolicy()},
+		{t.ipKey, ipThrottlePolicy()},
+	} {
+		count, last, err := throttleFailures(ctx, scope.key, scope.policy.Window, now)
+		if err != nil {
+			logger.Logger.Errorf("get login failures failed: %v", err)
+			return nil
+		}
+		scopeWait, scopeChallenge := scope.policy.evaluate(count, last, now)
+		if scopeWait > wait {
+			wait = scopeWait
+		}
+		needChallenge = needChallenge || scopeChallenge
+	}
+	if wait > 0 {
+		return &LoginThrottleError{Errno: code.ErrLoginThrottled, RetryAfter: wait}
+	}
+	if !needChallenge {
+		return nil
+	}
+	if challenge == "" {
+		return newChallengeError(code.ErrLoginChallengeRequired)
+	}
+	if !verifyPowChallenge(ctx, challenge, nonce) {
+		return newChallengeError(code.ErrLoginChallengeInvalid)
+	}
+	return nil
+}
+
+// newChallengeError 返回需要完成工作量证明的错误，并附带新的挑战
+func newChallengeError(errno *code.Errno) error {
+	challenge, err := IssuePowChallenge()
+	if err != nil {
+		return err
+	}
+	return &LoginThrottleError{Errno: errno, Challenge: challenge}
+}
+
+// RecordFailure 记录一次失败的登录，账号和IP各记一次
+func (t *loginThrottle) RecordFailure() {
+	ctx := context.Background()
+	now := time.Now()
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + randomHex(4)
+	policy := accountThrottlePolicy()
+	ttl := policy.Window
+	if policy.MaxDelay > ttl {
+		ttl = policy.MaxDelay
+	}
+	pipe := redis.GetRedisClient().Pipeline()
+	for _, key := range []string{t.accountKey, t.ipKey} {
+		pipe.ZAdd(ctx, key, goredis.Z{Score: float64(now.UnixMilli()), Member: member})
+		pipe.Expire(ctx, key, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Logger.Errorf("record login failure failed: %v", err)
+	}
+}
+
+// Reset 登录成功后清空账号的失败记录，IP的失败记录保留
+func (t *loginThrottle) Reset() {
+	if err := redis.GetRedisClient().Del(context.Background(), t.accountKey).Err(); err != nil {
+		logger.Logger.Errorf("reset login failures failed: %v", err)
+	}
+}
+
+// clearUserThrottle 清空用户的失败登录记录，管理员解除锁定和用户重置密码时使用
+func clearUserThrottle(user *models.User) error {
+	return redis.GetRedisClient().Del(context.Background(), userThrottleKey(user)).Err()
+}
+
+// accountThrottleStatus 账号在Redis中的失败登录记录和由此计算的锁定状态
+type accountThrottleStatus struct {
+	FailedLoginCount int       // 窗口内失败登录的次数
+	LastFailedLogin  time.Time // 最近一次失败登录的时间
+	LockUntil        time.Time // 需要等待到的时间，没有锁定时为零值
+}
+
+// Locked 账号当前是否因失败次数过多被锁定
+func (s accountThrottleStatus) Locked() bool {
+	return s.LockUntil.After(time.Now())
+}
+
+// userThrottleStatus 获取用户的失败登录记录和锁定状态
+func userThrottleStatus(user *models.User) (accountThrottleStatus, error) {
+	return throttleStatus(context.Background(), userThrottleKey(user))
+}
+
+// throttleStatus 按账号限流的策略计算key对应账号的锁定状态
+func throttleStatus(ctx context.Context, key string) (accountThrottleStatus, error) {
+	policy := accountThrottlePolicy()
+	count, last, err := throttleFailures(ctx, key, policy.Window, time.Now())
+	if err != nil {
+		return accountThrottleStatus{}, err
+	}
+	status := accountThrottleStatus{FailedLoginCount: count, LastFailedLogin: last}
+	if delay := policy.backoff(count); delay > 0 {
+		status.LockUntil = last.Add(delay)
+	}
+	return status, nil
+}
+
+// lockedUserIDs 扫描所有账号的失败登录记录，返回当前被锁定的用户ID
+func lockedUserIDs() ([]uuid.UUID, error) {
+	ctx := context.Background()
+	prefix := config.Conf.Redis.KeyPrefix + ":login_throttle:user:"
+	var ids []uuid.UUID
+	iter := redis.GetRedisClient().Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		id, err := uuid.Parse(strings.TrimPrefix(iter.Val(), prefix))
+		if err != nil {
+			continue
+		}
+		status, err := throttleStatus(ctx, iter.Val())
+		if err != nil {
+			return nil, err
+		}
+		if status.Locked() {
+			ids = append(ids, id)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// PowChallenge 工作量证明挑战
+// 客户端需要找到nonce，使sha256(challenge + nonce)的前difficulty位为0
+type PowChallenge struct {
+	Challenge  string `json:"challenge"`  // 挑战字符串
+	Difficulty int    `json:"difficulty"` // 哈希需要的前导零位数
+	ExpiresIn  int    `json:"expires_in"` // 有效期（秒）
+}
+
+// powChallengeKey 工作量证明挑战在Redis中的key
+func powChallengeKey(challenge string) string {
+	return config.Conf.Redis.KeyPrefix + ":login_pow:" + challenge
+}
+
+// IssuePowChallenge 生成工作量证明挑战，挑战只能使用一次
+func IssuePowChallenge() (*PowChallenge, error) {
+	challenge := &PowChallenge{
+		Challenge:  randomHex(16),
+		Difficulty: challengeDifficulty(),
+		ExpiresIn:  int(powChallengeTTL / time.Second),
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), powChallengeKey(challenge.Challenge),
+		challenge.Difficulty, powChallengeTTL).Err(); err != nil {
+		logger.Logger.Errorf("save pow challenge failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return challenge, nil
+}
+
+// verifyPowChallenge 校验并消耗工作量证明挑战，使用生成挑战时的难度
+func verifyPowChallenge(ctx context.Context, challenge, nonce string) bool {
+	difficulty, err := redis.GetRedisClient().GetDel(ctx, powChallengeKey(challenge)).Int()
+	if err != nil {
+		return false
+	}
+	return powSolved(challenge, nonce, difficulty)
+}
+
+// powSolved sha256(challenge + nonce)的前导零位数是否达到difficulty
+func powSolved(challenge, nonce string, difficulty int) bool {
+	if nonce == "" || len(nonce) > 64 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			zeros += bits.LeadingZeros8(b)
+			break
+		}
+		zeros += 8
+	}
+	return zeros >= difficulty
+}
+
+// randomHex 生成n字节的随机数，返回十六进制字符串
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
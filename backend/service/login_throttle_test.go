@@ -0,0 +1,137 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestThrottlePolicyBackoff(t *testing.T) {
+	policy := throttlePolicy{
+		Window:         15 * time.Minute,
+		FreeFailures:   5,
+		ChallengeAfter: 3,
+		BaseDelay:      30 * time.Second,
+		MaxDelay:       5 * time.Minute,
+	}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{5, 0},
+		{6, 30 * time.Second},
+		{7, time.Minute},
+		{8, 2 * time.Minute},
+		{9, 4 * time.Minute},
+		{10, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestThrottlePolicyEvaluate(t *testing.T) {
+	policy := throttlePolicy{
+		Window:         15 * time.Minute,
+		FreeFailures:   5,
+		ChallengeAfter: 3,
+		BaseDelay:      30 * time.Second,
+		MaxDelay:       15 * time.Minute,
+	}
+	now := time.Now()
+	tests := []struct {
+		failures      int
+		lastFailure   time.Time
+		wantWait      time.Duration
+		wantChallenge bool
+	}{
+		{0, time.Time{}, 0, false},
+		{2, now, 0, false},
+		{3, now, 0, true},
+		{6, now.Add(-10 * time.Second), 20 * time.Second, true},
+		{6, now.Add(-time.Minute), 0, true},
+		{7, now.Add(-30 * time.Second), 30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		wait, challenge := policy.evaluate(tt.failures, tt.lastFailure, now)
+		if wait != tt.wantWait || challenge != tt.wantChallenge {
+			t.Errorf("evaluate(%d) = %v, %v, want %v, %v", tt.failures, wait, challenge, tt.wantWait, tt.wantChallenge)
+		}
+	}
+}
+
+func TestLoginThrottleErrorRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		err := &LoginThrottleError{RetryAfter: tt.wait}
+		if got := err.RetryAfterSeconds(); got != tt.want {
+			t.Errorf("RetryAfterSeconds(%v) = %d, want %d", tt.wait, got, tt.want)
+		}
+	}
+}
+
+func TestNewLoginThrottle(t *testing.T) {
+	defer func(prefix string) { config.Conf.Redis.KeyPrefix = prefix }(config.Conf.Redis.KeyPrefix)
+	config.Conf.Redis.KeyPrefix = "blog"
+
+	// 账号不存在时按输入的账号限流，不区分大小写
+	throttle := newLoginThrottle(" Alice@Example.com ", nil, "1.2.3.4")
+	if throttle.accountKey != "blog:login_throttle:account:alice@example.com" {
+		t.Errorf("unexpected account key: %q", throttle.accountKey)
+	}
+	if throttle.ipKey != "blog:login_throttle:ip:1.2.3.4" {
+		t.Errorf("unexpected ip key: %q", throttle.ipKey)
+	}
+
+	// 账号存在时使用用户名、邮箱登录都计入同一个用户
+	user := &models.User{}
+	user.ID = uuid.New()
+	byName := newLoginThrottle("alice", user, "1.2.3.4")
+	byEmail := newLoginThrottle("alice@example.com", user, "5.6.7.8")
+	if byName.accountKey != byEmail.accountKey || byName.accountKey != userThrottleKey(user) {
+		t.Errorf("unexpected account keys: %q, %q", byName.accountKey, byEmail.accountKey)
+	}
+}
+
+func TestPowSolved(t *testing.T) {
+	challenge := "0123456789abcdef"
+	difficulty := 8
+	nonce := ""
+	for i := 0; i < 1<<16; i++ {
+		if powSolved(challenge, strconv.Itoa(i), difficulty) {
+			nonce = strconv.Itoa(i)
+			break
+		}
+	}
+	if nonce == "" {
+		t.Fatal("no nonce found")
+	}
+	if !powSolved(challenge, nonce, 0) {
+		t.Error("difficulty 0 should always be solved")
+	}
+	if powSolved(challenge, "", 0) {
+		t.Error("empty nonce should be rejected")
+	}
+	if powSolved(challenge, string(make([]byte, 65)), 0) {
+		t.Error("long nonce should be rejected")
+	}
+	if powSolved(challenge, nonce, 256) {
+		t.Error("difficulty 256 should not be solved")
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/utils"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// mentionSource 描述一段包含@提及的内容
+type mentionSource struct {
+	Type        models.MentionSourceType // 来源类型
+	ID          uuid.UUID                // 来源ID
+	MentionerID uuid.UUID                // 提及者用户ID，游客为空
+	Content     string                   // 内容
+	Summary     string                   // 通知中展示的内容描述
+	Notify      bool                     // 是否发送通知，例如草稿状态的文章不发送
+}
+
+// syncMentions 同步内容中的@提及
+// 解析内容中的用户名并与users.user_name匹配，新增或删除提及记录
+// 当Notify为true时，向尚未通知过且未屏蔽提及的用户发送站内通知
+func (source *mentionSource) syncMentions() error {
+	names := utils.ParseMentions(source.Content)
+
+	// 根据用户名查找被提及的用户
+	var users []models.User
+	if len(names) > 0 {
+		if err := models.DB.Where("user_name IN ?", names).Find(&users).Error; err != nil {
+			logger.Logger.Errorf("find mentioned users failed: %v", err)
+			return err
+		}
+	}
+
+	var notifications []*models.Notification
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		// 删除内容中已经不存在的提及
+		query := tx.Where("source_type = ? AND source_id = ?", source.Type, source.ID)
+		if len(users) > 0 {
+			userIDs := make([]uuid.UUID, 0, len(users))
+			for _, user := range users {
+				userIDs = append(userIDs, user.ID)
+			}
+			query = query.Where("mentioned_user_id NOT IN ?", userIDs)
+		}
+		if err := query.Delete(&models.Mention{}).Error; err != nil {
+			logger.Logger.Errorf("delete stale mentions failed: %v", err)
+			return err
+		}
+
+		for _, user := range users {
+			// 自己提及自己不记录
+			if user.ID == source.MentionerID {
+				continue
+			}
+			var mention models.Mention
+			err := tx.Where(models.Mention{
+				SourceType:      source.Type,
+				SourceID:        source.ID,
+				MentionedUserID: user.ID,
+			}).Attrs(models.Mention{MentionerUserID: source.MentionerID}).FirstOrCreate(&mention).Error
+			if err != nil {
+				logger.Logger.Errorf("create mention failed: %v", err)
+				return err
+			}
+
+			if !source.Notify || mention.Notified {
+				continue
+			}
+			// 用户屏蔽了提及通知时只记录，不发送通知
+			if !user.MuteMentions {
+				notifications = append(notifications, &models.Notification{
+					UserID:     user.ID,
+					ActorID:    source.MentionerID,
+					Type:       models.NotificationTypeMention,
+					SourceType: string(source.Type),
+					SourceID:   source.ID,
+					Content:    source.Summary,
+				})
+			}
+			if err := tx.Model(&mention).Update("notified", true).Error; err != nil {
+				logger.Logger.Errorf("update mention notified failed: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 事务提交后再发送通知
+	for _, notification := range notifications {
+		if err := createNotification(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncArticleMentions 同步文章正文中的@提及，只有已发布的文章才会发送通知
+func syncArticleMentions(article *models.Article) {
+	source := &mentionSource{
+		Type:        models.MentionSourceArticle,
+		ID:          article.ID,
+		MentionerID: article.UserID,
+		Content:     article.Content,
+		Summary:     truncateRunes(fmt.Sprintf("在文章《%s》中提到了你", article.Title), 500),
+		Notify:      article.Status == models.ArticleStatusPublished,
+	}
+	if err := source.syncMentions(); err != nil {
+		logger.Logger.Errorf("sync article mentions failed: %v", err)
+	}
+}
+
+// syncCommentMentions 同步评论中的@提及，只有审核通过的评论才会发送通知
+func syncCommentMentions(comment *models.ArticleComment, mentionerID uuid.UUID) {
+	source := &mentionSource{
+		Type:        models.MentionSourceComment,
+		ID:          comment.ID,
+		MentionerID: mentionerID,
+		Content:     comment.Content,
+		Summary:     truncateRunes(fmt.Sprintf("在评论中提到了你: %s", comment.Content), 500),
+		Notify:      comment.IsPass,
+	}
+	if err := source.syncMentions(); err != nil {
+		logger.Logger.Errorf("sync comment mentions failed: %v", err)
+	}
+}
+
+// RenderMentionLinks 将多段内容中的@提及渲染为用户主页链接
+// 只渲染真实存在的用户名，所有内容共用一次数据库查询
+func RenderMentionLinks(contents ...string) []string {
+	var names []string
+	for _, content := range contents {
+		names = append(names, utils.ParseMentions(content)...)
+	}
+
+	exists := make(map[string]bool)
+	if len(names) > 0 {
+		var userNames []string
+		if err := models.DB.Model(&models.User{}).Where("user_name IN ?", names).Pluck("user_name", &userNames).Error; err != nil {
+			logger.Logger.Errorf("find mentioned users failed: %v", err)
+		}
+		for _, name := range userNames {
+			exists[name] = true
+		}
+	}
+
+	rendered := make([]string, len(contents))
+	for i, content := range contents {
+		rendered[i] = utils.RenderMentions(content, func(userName string) bool {
+			return exists[userName]
+		})
+	}
+	return rendered
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/pubsub"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 通知推送事件名
+const (
+	NotificationEventCreated     = "notification" // 新通知
+	NotificationEventUnreadCount = "unread_count" // 未读数量变化
+)
+
+// NotificationEvent 推送给客户端的通知事件
+type NotificationEvent struct {
+	Event string      `json:"event"` // 事件名
+	Data  interface{} `json:"data"`  // 事件数据
+}
+
+// NotificationTopic 返回用户通知的发布订阅主题
+func NotificationTopic(userID uuid.UUID) string {
+	return "notification:" + userID.String()
+}
+
+// createNotification 创建一条站内通知，并推送给在线的用户
+func createNotification(notification *models.Notification) error {
+	if err := models.DB.Create(notification).Error; err != nil {
+		logger.Logger.Errorf("create notification failed: %v", err)
+		return err
+	}
+	publishNotificationEvent(notification.UserID, NotificationEventCreated, notification)
+	publishUnreadCount(notification.UserID)
+	return nil
+}
+
+// publishNotificationEvent 通过发布订阅推送通知事件，推送失败只记录日志
+func publishNotificationEvent(userID uuid.UUID, event string, data interface{}) {
+	payload, err := json.Marshal(NotificationEvent{Event: event, Data: data})
+	if err != nil {
+		logger.Logger.Errorf("marshal notification event failed: %v", err)
+		return
+	}
+	if err := pubsub.Publish(context.Background(), NotificationTopic(userID), payload); err != nil {
+		logger.Logger.Errorf("publish notification event failed: %v", err)
+	}
+}
+
+// publishUnreadCount 推送最新的未读通知数量
+func publishUnreadCount(userID uuid.UUID) {
+	count, err := countUnreadNotifications(userID)
+	if err != nil {
+		return
+	}
+	publishNotificationEvent(userID, NotificationEventUnreadCount, map[string]int64{"count": count})
+}
+
+// countUnreadNotifications 统计用户的未读通知数量
+func countUnreadNotifications(userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := models.DB.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error; err != nil {
+		logger.Logger.Errorf("count unread notifications failed: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListNotificationsService 通知列表服务结构体
+// 用于处理获取当前用户通知列表的请求和业务逻辑
+type ListNotificationsService struct {
+	UserID     uuid.UUID `json:"-"`                              // 用户ID，从JWT中获取
+	Page       int       `json:"page" form:"page"`               // 页码，默认为1
+	Size       int       `json:"size" form:"size"`               // 每页数量，默认为10，最大为100
+	UnreadOnly bool      `json:"unread_only" form:"unread_only"` // 是否只返回未读通知
+	Type       string    `json:"type" form:"type"`               // 通知类型筛选
+}
+
+// List 获取通知列表
+// 按创建时间倒序返回当前用户的通知
+// 返回通知列表、总数和可能的错误
+func (service *ListNotificationsService) List() ([]models.Notification, int64, error) {
+	// 设置默认分页参数
+	if service.Page <= 0 {
+		service.Page = 1
+	}
+	if service.Size <= 0 {
+		service.Size = 10
+	}
+	if service.Size > 100 {
+		service.Size = 100
+	}
+
+	query := models.DB.Model(&models.Notification{}).Where("user_id = ?", service.UserID)
+	if service.UnreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if service.Type != "" {
+		query = query.Where("type = ?", service.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count notifications failed: %v", err)
+		return nil, 0, code.ErrNotificationList
+	}
+
+	var notifications []models.Notification
+	offset := (service.Page - 1) * service.Size
+	if err := query.Order("created_at DESC").Offset(offset).Limit(service.Size).Find(&notifications).Error; err != nil {
+		logger.Logger.Errorf("list notifications failed: %v", err)
+		return nil, 0, code.ErrNotificationList
+	}
+	return notifications, total, nil
+}
+
+// UnreadNotificationCountService 未读通知数量服务结构体
+type UnreadNotificationCountService struct {
+	UserID uuid.UUID `json:"-"` // 用户ID，从JWT中获取
+}
+
+// UnreadCount 获取当前用户的未读通知数量
+func (service *UnreadNotificationCountService) UnreadCount() (int64, error) {
+	count, err := countUnreadNotifications(service.UserID)
+	if err != nil {
+		return 0, code.ErrNotificationList
+	}
+	return count, nil
+}
+
+// MarkNotificationReadService 标记通知已读服务结构体
+type MarkNotificationReadService struct {
+	ID     string    `uri:"id" binding:"required"` // 通知ID，从URL路径获取
+	UserID uuid.UUID `json:"-"`                    // 用户ID，从JWT中获取
+}
+
+// MarkRead 将指定通知标记为已读，只能操作自己的通知
+func (service *MarkNotificationReadService) MarkRead() error {
+	notificationID, err := uuid.Parse(service.ID)
+	if err != nil {
+		return code.ErrParam
+	}
+
+	var notification models.Notification
+	if err := models.DB.Where("id = ? AND user_id = ?", notificationID, service.UserID).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.ErrNotificationNotFound
+		}
+		logger.Logger.Errorf("get notification failed: %v", err)
+		return code.ErrDatabase
+	}
+	if notification.IsRead {
+		return nil
+	}
+
+	now := time.Now()
+	if err := models.DB.Model(&notification).Updates(map[string]interface{}{
+		"is_read": true,
+		"read_at": now,
+	}).Error; err != nil {
+		logger.Logger.Errorf("mark notification read failed: %v", err)
+		return code.ErrNotificationUpdate
+	}
+	publishUnreadCount(service.UserID)
+	return nil
+}
+
+// MarkAllNotificationsReadService 全部标记已读服务结构体
+type MarkAllNotificationsReadService struct {
+	UserID uuid.UUID `json:"-"` // 用户ID，从JWT中获取
+}
+
+// MarkAllRead 将当前用户的所有未读通知标记为已读
+// 返回被标记的通知数量和可能的错误
+func (service *MarkAllNotificationsReadService) MarkAllRead() (int64, error) {
+	result := models.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", service.UserID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		logger.Logger.Errorf("mark all notifications read failed: %v", result.Error)
+		return 0, code.ErrNotificationUpdate
+	}
+	publishUnreadCount(service.UserID)
+	return result.RowsAffected, nil
+}
+
+// SubscribeNotificationsService 订阅通知服务结构体
+// 用于Server-Sent Events实时推送
+type SubscribeNotificationsService struct {
+	UserID uuid.UUID `json:"-"` // 用户ID，从JWT中获取
+}
+
+// Subscribe 订阅当前用户的通知事件，使用完毕后需要调用pubsub.Unsubscribe
+func (service *SubscribeNotificationsService) Subscribe() (*pubsub.Subscriber, error) {
+	subscriber, err := pubsub.Subscribe(NotificationTopic(service.UserID))
+	if err != nil {
+		logger.Logger.Errorf("subscribe notifications failed: %v", err)
+		return nil, code.ErrNotificationStream
+	}
+	return subscriber, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// createTestNotification 为用户创建一条未读通知
+func createTestNotification(t *testing.T, userID uuid.UUID) *models.Notification {
+	t.Helper()
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationTypeMention,
+		Content: "在评论中提到了你",
+	}
+	if err := models.DB.Create(notification).Error; err != nil {
+		t.Fatalf("create notification failed: %v", err)
+	}
+	return notification
+}
+
+func TestMarkNotificationRead(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.Notification{})
+	newTestRedis(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+	notification := createTestNotification(t, alice.ID)
+
+	// 不能标记其他用户的通知
+	service := &MarkNotificationReadService{ID: notification.ID.String(), UserID: bob.ID}
+	if err := service.MarkRead(); err != code.ErrNotificationNotFound {
+		t.Errorf("MarkRead() by other user error = %v, want ErrNotificationNotFound", err)
+	}
+	if err := models.DB.First(notification, "id = ?", notification.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if notification.IsRead {
+		t.Fatal("notification of another user should stay unread")
+	}
+
+	service = &MarkNotificationReadService{ID: "not-a-uuid", UserID: alice.ID}
+	if err := service.MarkRead(); err != code.ErrParam {
+		t.Errorf("MarkRead() with invalid id error = %v, want ErrParam", err)
+	}
+	service = &MarkNotificationReadService{ID: uuid.NewString(), UserID: alice.ID}
+	if err := service.MarkRead(); err != code.ErrNotificationNotFound {
+		t.Errorf("MarkRead() with unknown id error = %v, want ErrNotificationNotFound", err)
+	}
+
+	service = &MarkNotificationReadService{ID: notification.ID.String(), UserID: alice.ID}
+	if err := service.MarkRead(); err != nil {
+		t.Fatalf("MarkRead() error = %v", err)
+	}
+	if err := models.DB.First(notification, "id = ?", notification.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !notification.IsRead || notification.ReadAt == nil {
+		t.Errorf("notification = %+v, want read", notification)
+	}
+	// 重复标记不会报错
+	if err := service.MarkRead(); err != nil {
+		t.Errorf("MarkRead() again error = %v", err)
+	}
+}
+
+func TestMarkAllNotificationsRead(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.Notification{})
+	newTestRedis(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+	for i := 0; i < 3; i++ {
+		createTestNotification(t, alice.ID)
+	}
+	createTestNotification(t, bob.ID)
+
+	unread := func(userID uuid.UUID) int64 {
+		t.Helper()
+		count, err := (&UnreadNotificationCountService{UserID: userID}).UnreadCount()
+		if err != nil {
+			t.Fatalf("UnreadCount() error = %v", err)
+		}
+		return count
+	}
+	if got := unread(alice.ID); got != 3 {
+		t.Fatalf("unread count = %d, want 3", got)
+	}
+
+	marked, err := (&MarkAllNotificationsReadService{UserID: alice.ID}).MarkAllRead()
+	if err != nil {
+		t.Fatalf("MarkAllRead() error = %v", err)
+	}
+	if marked != 3 {
+		t.Errorf("marked = %d, want 3", marked)
+	}
+	if got := unread(alice.ID); got != 0 {
+		t.Errorf("unread count after read all = %d, want 0", got)
+	}
+	// 其他用户的通知不受影响
+	if got := unread(bob.ID); got != 1 {
+		t.Errorf("other user's unread count = %d, want 1", got)
+	}
+
+	// 没有未读通知时标记数量为0
+	marked, err = (&MarkAllNotificationsReadService{UserID: alice.ID}).MarkAllRead()
+	if err != nil || marked != 0 {
+		t.Errorf("MarkAllRead() again = %d, %v, want 0", marked, err)
+	}
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/oauth"
+	"blog-server/internal/redis"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// oauthStateTTL 第三方登录的state有效期
+	oauthStateTTL = 10 * time.Minute
+	// oauthExchangeTimeout 换取令牌和读取账号信息的总超时时间
+	oauthExchangeTimeout = 15 * time.Second
+	// OIDCProviderName 通用OIDC提供方名称
+	OIDCProviderName = "oidc"
+)
+
+var (
+	oauthProvidersOnce sync.Once
+	oauthProviders     []oauth.Provider
+
+	// oauthUserNameInvalidChars 生成用户名时去掉的字符
+	oauthUserNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// OAuthProviderInfo 可用的第三方登录方式
+type OAuthProviderInfo struct {
+	Name        string `json:"name"`         // 提供方名称，用于接口路径
+	DisplayName string `json:"display_name"` // 显示名称
+}
+
+// ListOAuthProvidersService 获取第三方登录方式服务结构体
+type ListOAuthProvidersService struct{}
+
+// List 返回已启用的第三方登录方式
+func (service *ListOAuthProvidersService) List() []OAuthProviderInfo {
+	providers := make([]OAuthProviderInfo, 0, len(loadOAuthProviders()))
+	for _, provider := range loadOAuthProviders() {
+		providers = append(providers, OAuthProviderInfo{Name: provider.Name(), DisplayName: provider.DisplayName()})
+	}
+	return providers
+}
+
+// OAuthAuthorization 第三方登录的授权信息
+type OAuthAuthorization struct {
+	AuthorizeURL string `json:"authorize_url"` // 跳转到提供方的授权地址
+	State        string `json:"state"`         // 本次登录的state，前端保存后在回调时比对
+}
+
+// BeginOAuthLoginService 开始第三方登录服务结构体
+type BeginOAuthLoginService struct {
+	Provider string `uri:"provider" binding:"required"` // 提供方名称
+}
+
+// Begin 生成授权地址
+// state、PKCE的code_verifier和OIDC的nonce保存在Redis中，回调时取出校验，每个state只能使用一次
+func (service *BeginOAuthLoginService) Begin() (*OAuthAuthorization, error) {
+	provider, err := findOAuthProvider(service.Provider)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomOAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomOAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	pending := oauthState{Provider: provider.Name(), Verifier: oauth.GenerateVerifier(), Nonce: nonce}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauthExchangeTimeout)
+	defer cancel()
+	authorizeURL, err := provider.AuthCodeURL(ctx, state, pending.Verifier, pending.Nonce)
+	if err != nil {
+		logger.Logger.Errorf("build %s authorize url failed: %v", provider.Name(), err)
+		return nil, code.ErrOAuthExchangeFailed
+	}
+
+	value, err := json.Marshal(&pending)
+	if err != nil {
+		return nil, code.InternalServerError
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), oauthStateKey(state), value, oauthStateTTL).Err(); err != nil {
+		logger.Logger.Errorf("save oauth state failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &OAuthAuthorization{AuthorizeURL: authorizeURL, State: state}, nil
+}
+
+// FinishOAuthLoginService 完成第三方登录服务结构体
+type FinishOAuthLoginService struct {
+	Provider   string `uri:"provider" json:"-" binding:"required"`   // 提供方名称
+	Code       string `json:"code" form:"code" binding:"required"`   // 提供方回调时返回的授权码
+	State      string `json:"state" form:"state" binding:"required"` // 提供方回调时返回的state
+	DeviceName string `json:"device_name" form:"device_name"`        // 设备名称，可选
+	UserAgent  string `json:"-" form:"-"`                            // 用户代理，从请求头获取
+	IP         string `json:"-" form:"-"`                            // 请求IP
+}
+
+// Finish 校验state、用授权码换取第三方账号信息，找到或创建对应的用户后完成登录
+// 开启两步验证的用户与密码登录一样返回登录挑战令牌
+func (service *FinishOAuthLoginService) Finish() (*LoginResult, error) {
+	pending, err := takeOAuthState(service.State)
+	if err != nil {
+		return nil, err
+	}
+	if pending.Provider != service.Provider {
+		return nil, code.ErrOAuthStateInvalid
+	}
+	provider, err := findOAuthProvider(service.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauthExchangeTimeout)
+	defer cancel()
+	identity, err := provider.Exchange(ctx, service.Code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		logger.Logger.Infof("exchange %s authorization code failed: %v", provider.Name(), err)
+		return nil, code.ErrOAuthExchangeFailed
+	}
+
+	user, err := resolveOAuthUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	// 密码错误次数过多被锁定时，第三方登录同样需要等待
+	if status, err := userThrottleStatus(user); err != nil {
+		logger.Logger.Errorf("get login throttle status failed: %v", err)
+	} else if status.Locked() {
+		return nil, code.ErrUserLocked
+	}
+	if user.Status == "suspended" {
+		return nil, code.ErrUserSuspended
+	}
+	if !user.IsActive {
+		return nil, code.ErrUserInactive
+	}
+	if user.PasswordResetRequired {
+		return nil, code.ErrPasswordResetRequired
+	}
+
+	meta := SessionMeta{
+		DeviceName: service.DeviceName,
+		UserAgent:  service.UserAgent,
+		IP:         service.IP,
+		Method:     models.LoginMethodOAuth,
+	}
+	if user.TwoFactorEnabled {
+		challengeToken, err := createLoginChallenge(user, meta)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			ChallengeToken: challengeToken,
+			Methods:        twoFactorMethods(user),
+		}, nil
+	}
+
+	accessToken, refreshToken, err := completeLogin(user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// ListOAuthIdentitiesService 获取已绑定的第三方账号服务结构体
+type ListOAuthIdentitiesService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// List 返回当前用户绑定的第三方账号
+func (service *ListOAuthIdentitiesService) List() ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := models.DB.Where("user_id = ?", service.UserID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		logger.Logger.Errorf("list user identities failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return identities, nil
+}
+
+// UnlinkOAuthIdentityService 解除绑定第三方账号服务结构体
+type UnlinkOAuthIdentityService struct {
+	Provider string    `uri:"provider" binding:"required"` // 提供方名称
+	UserID   uuid.UUID `json:"-" form:"-"`                 // 用户ID，从JWT中获取
+}
+
+// Unlink 解除绑定，之后该第三方账号登录时会按邮箱重新匹配
+// 通过第三方登录自动创建的账号没有可用的密码，解除绑定前需要先通过忘记密码设置密码
+func (service *UnlinkOAuthIdentityService) Unlink() error {
+	result := models.DB.Unscoped().Where("user_id = ? AND provider = ?", service.UserID, service.Provider).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		logger.Logger.Errorf("delete user identity failed: %v", result.Error)
+		return code.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		return code.ErrOAuthIdentityNotFound
+	}
+	return nil
+}
+
+// oauthState 授权请求的state对应的数据
+type oauthState struct {
+	Provider string `json:"provider"` // 提供方名称
+	Verifier string `json:"verifier"` // PKCE的code_verifier
+	Nonce    string `json:"nonce"`    // OIDC的nonce
+}
+
+// takeOAuthState 取出并删除state对应的数据
+func takeOAuthState(state string) (*oauthState, error) {
+	value, err := redis.GetRedisClient().GetDel(context.Background(), oauthStateKey(state)).Result()
+	if err == redis.Nil {
+		return nil, code.ErrOAuthStateInvalid
+	}
+	if err != nil {
+		logger.Logger.Errorf("get oauth state failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	var pending oauthState
+	if err := json.Unmarshal([]byte(value), &pending); err != nil {
+		return nil, code.ErrOAuthStateInvalid
+	}
+	return &pending, nil
+}
+
+// resolveOAuthUser 找到第三方账号对应的用户
+// 已绑定的直接返回；未绑定时按提供方验证过的邮箱绑定已有用户，没有则在开放注册时自动创建用户
+func resolveOAuthUser(identity *oauth.Identity) (*models.User, error) {
+	postgreDB := models.DB
+	now := time.Now()
+
+	var linked models.UserIdentity
+	err := postgreDB.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&linked).Error
+	if err == nil {
+		var user models.User
+		if err := postgreDB.Where("id = ?", linked.UserID).First(&user).Error; err != nil {
+			return nil, code.ErrUserNotFound
+		}
+		if err := postgreDB.Model(&linked).Updates(map[string]interface{}{
+			"email":         identity.Email,
+			"username":      identity.Username,
+			"last_login_at": now,
+		}).Error; err != nil {
+			logger.Logger.Errorf("update user identity failed: %v", err)
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Logger.Errorf("get user identity failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	// 未验证的邮箱可能属于别人，不能用来绑定或创建账号
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, code.ErrOAuthEmailUnverified
+	}
+
+	var user models.User
+	err = postgreDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("LOWER(email) = LOWER(?)", identity.Email).Order("created_at ASC").First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 第三方登录无法填写邀请码，只有开放注册时才自动创建账号
+			if registrationMode() != RegistrationModeOpen {
+				return code.ErrRegistrationClosed
+			}
+			created, err := newOAuthUser(tx, identity)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(created).Error; err != nil {
+				return err
+			}
+			user = *created
+		} else if err != nil {
+			return err
+		}
+		return tx.Create(&models.UserIdentity{
+			UserID:      user.ID,
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			Username:    identity.Username,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if errors.Is(err, code.ErrRegistrationClosed) {
+		return nil, code.ErrRegistrationClosed
+	}
+	if err != nil {
+		logger.Logger.Errorf("link %s identity failed: %v", identity.Provider, err)
+		return nil, code.ErrUserCreate
+	}
+	return &user, nil
+}
+
+// newOAuthUser 根据第三方账号信息生成新用户
+// 密码为随机值，用户需要通过忘记密码设置密码后才能用密码登录
+func newOAuthUser(tx *gorm.DB, identity *oauth.Identity) (*models.User, error) {
+	userName, err := uniqueOAuthUserName(tx, identity)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomOAuthToken()
+	if err != nil {
+		return nil, err
+	}
+
+	user := generateDefaultUser()
+	user.UserName = userName
+	user.Nickname = userName
+	if identity.Name != "" {
+		user.Nickname = truncateRunes(identity.Name, 50)
+	}
+	user.Email = identity.Email
+	user.Avatar = identity.AvatarURL
+	user.Password = password
+	if err := user.GenerateEncryptedPassword(); err != nil {
+		return nil, err
+	}
+	user.Role = "user"
+	user.Status = "active"
+	user.IsActive = true
+	return user, nil
+}
+
+// uniqueOAuthUserName 根据第三方账号的用户名或邮箱前缀生成未被使用的用户名
+func uniqueOAuthUserName(tx *gorm.DB, identity *oauth.Identity) (string, error) {
+	base := oauthUserNameInvalidChars.ReplaceAllString(identity.Username, "")
+	if base == "" {
+		base = oauthUserNameInvalidChars.ReplaceAllString(strings.SplitN(identity.Email, "@", 2)[0], "")
+	}
+	if base == "" {
+		base = "user"
+	}
+	base = truncateRunes(base, 40)
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("user_name = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix, err := randomOAuthToken()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "_" + suffix[:6]
+	}
+	return "", code.ErrUserExistBefore
+}
+
+// loadOAuthProviders 根据配置创建已启用的第三方登录提供方
+// OIDC提供方会缓存签发者的发现文档，所以提供方只创建一次
+func loadOAuthProviders() []oauth.Provider {
+	oauthProvidersOnce.Do(func() {
+		oauthConfig := config.Conf.Auth.OAuth
+		if oauthConfig.GitHub.Enabled {
+			provider, err := oauth.NewGitHub(oauthProviderConfig(oauth.GitHubProviderName, oauthConfig.GitHub))
+			if err != nil {
+				logger.Logger.Errorf("create github oauth provider failed: %v", err)
+			} else {
+				oauthProviders = append(oauthProviders, provider)
+			}
+		}
+		if oauthConfig.OIDC.Enabled {
+			provider, err := oauth.NewOIDC(OIDCProviderName, oauthProviderConfig(OIDCProviderName, oauthConfig.OIDC))
+			if err != nil {
+				logger.Logger.Errorf("create oidc provider failed: %v", err)
+			} else {
+				oauthProviders = append(oauthProviders, provider)
+			}
+		}
+	})
+	return oauthProviders
+}
+
+// oauthProviderConfig 转换提供方配置，回调地址默认为前端的/oauth/callback/<提供方>页面
+func oauthProviderConfig(name string, providerConfig config.OAuthProviderConfig) oauth.Config {
+	redirectURL := providerConfig.RedirectURL
+	if redirectURL == "" {
+		redirectURL = strings.TrimRight(config.Conf.App.SiteURL, "/") + "/oauth/callback/" + name
+	}
+	return oauth.Config{
+		ClientID:     providerConfig.ClientID,
+		ClientSecret: providerConfig.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       providerConfig.Scopes,
+		DisplayName:  providerConfig.DisplayName,
+		Issuer:       providerConfig.Issuer,
+	}
+}
+
+// findOAuthProvider 根据名称查找已启用的提供方
+func findOAuthProvider(name string) (oauth.Provider, error) {
+	for _, provider := range loadOAuthProviders() {
+		if provider.Name() == name {
+			return provider, nil
+		}
+	}
+	return nil, code.ErrOAuthProviderNotFound
+}
+
+// randomOAuthToken 生成随机的十六进制字符串
+func randomOAuthToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		logger.Logger.Errorf("generate random token failed: %v", err)
+		return "", code.InternalServerError
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func oauthStateKey(state string) string {
+	return config.Conf.Redis.KeyPrefix + ":oauth_state:" + state
+}
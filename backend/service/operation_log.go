@@ -16,6 +16,7 @@ type CreateOperationLogService struct {
 	UserName      string    `json:"user_name"`                      // 用户名
 	OperationType string    `json:"operation_type"`                  // 操作类型
 	OperationDesc string    `json:"operation_desc"`                  // 操作描述
+	TargetID      string    `json:"target_id"`                       // 操作对象ID
 	RequestIP     string    `json:"request_ip"`                      // 请求IP
 	UserAgent     string    `json:"user_agent"`                      // 用户代理
 	RequestData   string    `json:"request_data"`                    // 请求数据
@@ -34,6 +35,7 @@ func (s *CreateOperationLogService) Create() error {
 		UserName:      s.UserName,
 		OperationType: s.OperationType,
 		OperationDesc: s.OperationDesc,
+		TargetID:      s.TargetID,
 		RequestIP:     s.RequestIP,
 		UserAgent:     s.UserAgent,
 		RequestData:   s.RequestData,
@@ -163,4 +165,21 @@ func LogArticleLike(c *gin.Context, userID uuid.UUID, userName, articleID, artic
 func LogArticleStatusUpdate(c *gin.Context, userID uuid.UUID, userName, articleID, articleTitle, oldStatus, newStatus string, success bool, errorMessage string) error {
 	operationDesc := fmt.Sprintf("更新文章状态: %s (%s -> %s)", articleTitle, oldStatus, newStatus)
 	return LogArticleOperation(c, userID, userName, articleID, articleTitle, "article_status_update", operationDesc, success, errorMessage)
-}
\ No newline at end of file
+}
+
+// LogRefreshTokenReuse 记录刷新令牌重复使用日志
+// 已经轮换掉的刷新令牌再次出现，说明令牌可能已被盗用
+func LogRefreshTokenReuse(userID uuid.UUID, userName, sessionID, requestIP, userAgent string) error {
+	logService := &CreateOperationLogService{
+		UserID:        userID,
+		UserName:      userName,
+		OperationType: "refresh_token_reuse",
+		OperationDesc: "疑似令牌被盗：已轮换的刷新令牌被再次使用，已吊销该会话",
+		RequestIP:     requestIP,
+		UserAgent:     userAgent,
+		RequestData:   fmt.Sprintf(`{"session_id":"%s"}`, sessionID),
+		Status:        "failed",
+		ErrorMessage:  "refresh token reused",
+	}
+	return logService.Create()
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/passkey"
+	"blog-server/internal/redis"
+	"blog-server/internal/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// webAuthnSessionTTL WebAuthn仪式的会话数据在Redis中的有效期
+const webAuthnSessionTTL = 5 * time.Minute
+
+// BeginPasskeyRegistrationService 开始注册通行密钥服务结构体
+type BeginPasskeyRegistrationService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// Begin 生成注册选项，会话数据保存在Redis中，同一用户同时只能进行一次注册
+func (service *BeginPasskeyRegistrationService) Begin() (*protocol.CredentialCreation, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, code.ErrPasskeyRegisterFailed
+	}
+	passkeyUser, _, err := loadPasskeyUser(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	options, session, err := rp.BeginRegistration(passkeyUser)
+	if err != nil {
+		logger.Logger.Errorf("begin passkey registration failed: %v", err)
+		return nil, code.ErrPasskeyRegisterFailed
+	}
+	if err := saveWebAuthnSession(webAuthnRegisterKey(user.ID.String()), session); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// FinishPasskeyRegistrationService 完成注册通行密钥服务结构体
+type FinishPasskeyRegistrationService struct {
+	Name       string          `json:"name" form:"name" binding:"max=100"`     // 名称，为空时根据User-Agent生成
+	Credential json.RawMessage `json:"credential" form:"-" binding:"required"` // navigator.credentials.create()返回的凭证
+	UserID     uuid.UUID       `json:"-" form:"-"`                             // 用户ID，从JWT中获取
+	UserAgent  string          `json:"-" form:"-"`                             // 用户代理，从请求头获取
+}
+
+// Finish 校验注册响应并保存通行密钥
+func (service *FinishPasskeyRegistrationService) Finish() (*models.Passkey, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, code.ErrPasskeyRegisterFailed
+	}
+	session, err := takeWebAuthnSession(webAuthnRegisterKey(user.ID.String()))
+	if err != nil {
+		return nil, err
+	}
+	passkeyUser, _, err := loadPasskeyUser(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := rp.FinishRegistration(passkeyUser, *session, service.Credential)
+	if err != nil {
+		logger.Logger.Infof("finish passkey registration failed: %v", err)
+		return nil, code.ErrPasskeyRegisterFailed
+	}
+
+	name := strings.TrimSpace(service.Name)
+	if name == "" {
+		name = utils.DeviceNameFromUserAgent(service.UserAgent)
+	}
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+	record := &models.Passkey{
+		UserID:          user.ID,
+		Name:            truncateRunes(name, 100),
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		Transports:      strings.Join(transports, ","),
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	if err := models.DB.Create(record).Error; err != nil {
+		// 凭证ID唯一，同一个凭证不能重复注册
+		logger.Logger.Errorf("create passkey failed: %v", err)
+		return nil, code.ErrPasskeyRegisterFailed
+	}
+	return record, nil
+}
+
+// ListPasskeysService 通行密钥列表服务结构体
+type ListPasskeysService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// List 获取用户注册的通行密钥，按创建时间倒序
+func (service *ListPasskeysService) List() ([]models.Passkey, error) {
+	var passkeys []models.Passkey
+	if err := models.DB.Where("user_id = ?", service.UserID).Order("created_at DESC").Find(&passkeys).Error; err != nil {
+		logger.Logger.Errorf("list passkeys failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return passkeys, nil
+}
+
+// RenamePasskeyService 重命名通行密钥服务结构体
+type RenamePasskeyService struct {
+	ID     string    `uri:"id" json:"-" binding:"required,uuid"`          // 通行密钥ID
+	Name   string    `json:"name" form:"name" binding:"required,max=100"` // 新名称
+	UserID uuid.UUID `json:"-" form:"-"`                                  // 用户ID，从JWT中获取
+}
+
+// Rename 重命名通行密钥
+func (service *RenamePasskeyService) Rename() (*models.Passkey, error) {
+	record, err := findUserPasskey(service.ID, service.UserID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(service.Name)
+	if name == "" {
+		return nil, code.ErrParam
+	}
+	if err := models.DB.Model(record).Update("name", name).Error; err != nil {
+		logger.Logger.Errorf("rename passkey failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return record, nil
+}
+
+// DeletePasskeyService 删除通行密钥服务结构体
+type DeletePasskeyService struct {
+	ID     string    `uri:"id" binding:"required,uuid"` // 通行密钥ID
+	UserID uuid.UUID `json:"-" form:"-"`                // 用户ID，从JWT中获取
+}
+
+// Delete 删除通行密钥，删除后该凭证不能再用于登录
+func (service *DeletePasskeyService) Delete() error {
+	record, err := findUserPasskey(service.ID, service.UserID)
+	if err != nil {
+		return err
+	}
+	if err := models.DB.Unscoped().Delete(record).Error; err != nil {
+		logger.Logger.Errorf("delete passkey failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// PasskeyLoginOptions 通行密钥登录选项
+type PasskeyLoginOptions struct {
+	SessionID string                        `json:"session_id"` // 本次登录的会话ID，完成登录时提交
+	Options   *protocol.CredentialAssertion `json:"options"`    // 传给navigator.credentials.get()的选项
+}
+
+// BeginPasskeyLoginService 开始通行密钥登录服务结构体
+// 不需要账号，由验证器选择凭证
+type BeginPasskeyLoginService struct{}
+
+// Begin 生成无用户名登录的选项
+func (service *BeginPasskeyLoginService) Begin() (*PasskeyLoginOptions, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, code.ErrPasskeyLoginFailed
+	}
+	options, session, err := rp.BeginDiscoverableLogin()
+	if err != nil {
+		logger.Logger.Errorf("begin passkey login failed: %v", err)
+		return nil, code.ErrPasskeyLoginFailed
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, code.InternalServerError
+	}
+	sessionID := hex.EncodeToString(buf)
+	if err := saveWebAuthnSession(webAuthnLoginKey(sessionID), session); err != nil {
+		return nil, err
+	}
+	return &PasskeyLoginOptions{SessionID: sessionID, Options: options}, nil
+}
+
+// FinishPasskeyLoginService 完成通行密钥登录服务结构体
+type FinishPasskeyLoginService struct {
+	SessionID  string          `json:"session_id" binding:"required"` // 开始登录时返回的会话ID
+	Credential json.RawMessage `json:"credential" binding:"required"` // navigator.credentials.get()返回的凭证
+	DeviceName string          `json:"device_name"`                   // 设备名称，可选
+	UserAgent  string          `json:"-"`                             // 用户代理，从请求头获取
+	IP         string          `json:"-"`                             // 请求IP
+}
+
+// Finish 校验通行密钥并完成登录
+// 通行密钥登录要求用户验证，本身就是多因素认证，不再需要两步验证
+// 返回用户信息、访问令牌、刷新令牌和可能的错误
+func (service *FinishPasskeyLoginService) Finish() (*models.User, string, string, error) {
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, "", "", code.ErrPasskeyLoginFailed
+	}
+	session, err := takeWebAuthnSession(webAuthnLoginKey(service.SessionID))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	var (
+		user   models.User
+		record *models.Passkey
+	)
+	lookup := func(credentialID, userHandle []byte) (*passkey.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		if err := models.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+			return nil, err
+		}
+		passkeyUser, records, err := loadPasskeyUser(&user)
+		if err != nil {
+			return nil, err
+		}
+		for i := range records {
+			if string(records[i].CredentialID) == string(credentialID) {
+				record = &records[i]
+				return passkeyUser, nil
+			}
+		}
+		return nil, errors.New("credential not found")
+	}
+	_, credential, err := rp.FinishDiscoverableLogin(lookup, *session, service.Credential)
+	if err != nil {
+		logger.Logger.Infof("finish passkey login failed: %v", err)
+		return nil, "", "", code.ErrPasskeyLoginFailed
+	}
+
+	if user.Status == "suspended" {
+		return nil, "", "", code.ErrUserSuspended
+	}
+	if !user.IsActive {
+		return nil, "", "", code.ErrUserInactive
+	}
+	if user.PasswordResetRequired {
+		return nil, "", "", code.ErrPasswordResetRequired
+	}
+	if err := updatePasskeyUsage(record, credential); err != nil {
+		return nil, "", "", err
+	}
+
+	accessToken, refreshToken, err := completeLogin(&user, SessionMeta{
+		DeviceName: service.DeviceName,
+		UserAgent:  service.UserAgent,
+		IP:         service.IP,
+		Method:     models.LoginMethodPasskey,
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	return &user, accessToken, refreshToken, nil
+}
+
+// BeginTwoFactorPasskeyService 开始用通行密钥完成两步验证服务结构体
+type BeginTwoFactorPasskeyService struct {
+	ChallengeToken string `json:"challenge_token" form:"challenge_token" binding:"required"` // 登录挑战令牌，必填
+}
+
+// Begin 为登录挑战对应的用户生成认证选项，只允许该用户注册的通行密钥
+func (service *BeginTwoFactorPasskeyService) Begin() (*protocol.CredentialAssertion, error) {
+	challengeHash := hashLoginChallenge(strings.TrimSpace(service.ChallengeToken))
+	_, user, err := loadLoginChallenge(challengeHash)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, code.ErrPasskeyLoginFailed
+	}
+	passkeyUser, _, err := loadPasskeyUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	options, session, err := rp.BeginLogin(passkeyUser)
+	if errors.Is(err, passkey.ErrNoCredentials) {
+		return nil, code.ErrPasskeyNotFound
+	}
+	if err != nil {
+		logger.Logger.Errorf("begin two factor passkey failed: %v", err)
+		return nil, code.ErrPasskeyLoginFailed
+	}
+	if err := saveWebAuthnSession(webAuthnTwoFactorKey(challengeHash), session); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// FinishTwoFactorPasskeyService 用通行密钥完成两步验证服务结构体
+type FinishTwoFactorPasskeyService struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"` // 登录挑战令牌，必填
+	Credential     json.RawMessage `json:"credential" binding:"required"`      // navigator.credentials.get()返回的凭证
+	UserAgent      string          `json:"-"`                                  // 用户代理，从请求头获取
+	IP             string          `json:"-"`                                  // 请求IP
+}
+
+// Finish 校验通行密钥并完成登录
+// 返回用户信息、访问令牌、刷新令牌和可能的错误
+func (service *FinishTwoFactorPasskeyService) Finish() (*models.User, string, string, error) {
+	challengeHash := hashLoginChallenge(strings.TrimSpace(service.ChallengeToken))
+	challenge, user, err := loadLoginChallenge(challengeHash)
+	if err != nil {
+		return nil, "", "", err
+	}
+	rp, err := relyingParty()
+	if err != nil {
+		return nil, "", "", code.ErrPasskeyLoginFailed
+	}
+	session, err := takeWebAuthnSession(webAuthnTwoFactorKey(challengeHash))
+	if err != nil {
+		return nil, "", "", err
+	}
+	passkeyUser, records, err := loadPasskeyUser(user)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	credential, err := rp.FinishLogin(passkeyUser, *session, service.Credential)
+	if err != nil {
+		logger.Logger.Infof("finish two factor passkey failed: %v", err)
+		recordLoginChallengeFailure(challengeHash)
+		return nil, "", "", code.ErrPasskeyLoginFailed
+	}
+	for i := range records {
+		if string(records[i].CredentialID) == string(credential.ID) {
+			if err := updatePasskeyUsage(&records[i], credential); err != nil {
+				return nil, "", "", err
+			}
+			break
+		}
+	}
+
+	return completeLoginChallenge(challengeHash, challenge, user, service.UserAgent, service.IP)
+}
+
+// relyingParty 根据配置创建WebAuthn依赖方
+// 未配置时依赖方ID使用site_url的域名，允许的来源使用site_url
+func relyingParty() (*passkey.RelyingParty, error) {
+	authConfig := config.Conf.Auth
+	rpID := authConfig.WebAuthnRPID
+	if rpID == "" {
+		if siteURL, err := url.Parse(config.Conf.App.SiteURL); err == nil {
+			rpID = siteURL.Hostname()
+		}
+	}
+	rpName := authConfig.WebAuthnRPName
+	if rpName == "" {
+		rpName = totpIssuer()
+	}
+	var origins []string
+	for _, origin := range authConfig.WebAuthnOrigins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 && config.Conf.App.SiteURL != "" {
+		origins = []string{strings.TrimRight(config.Conf.App.SiteURL, "/")}
+	}
+
+	rp, err := passkey.New(passkey.Config{RPID: rpID, RPName: rpName, Origins: origins})
+	if err != nil {
+		logger.Logger.Errorf("create webauthn relying party failed: %v", err)
+		return nil, err
+	}
+	return rp, nil
+}
+
+// loadPasskeyUser 加载用户和用户注册的通行密钥
+// 用户句柄使用用户ID的16个字节，不包含个人信息
+func loadPasskeyUser(user *models.User) (*passkey.User, []models.Passkey, error) {
+	var records []models.Passkey
+	if err := models.DB.Where("user_id = ?", user.ID).Find(&records).Error; err != nil {
+		logger.Logger.Errorf("list passkeys failed: %v", err)
+		return nil, nil, code.ErrDatabase
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, record := range records {
+		var transports []protocol.AuthenticatorTransport
+		for _, transport := range strings.Split(record.Transports, ",") {
+			if transport != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              record.CredentialID,
+			PublicKey:       record.PublicKey,
+			AttestationType: record.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: record.BackupEligible,
+				BackupState:    record.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    record.AAGUID,
+				SignCount: uint32(record.SignCount),
+			},
+		})
+	}
+
+	userID := user.ID
+	return &passkey.User{
+		ID:          userID[:],
+		Name:        user.Email,
+		DisplayName: user.Nickname,
+		Credentials: credentials,
+	}, records, nil
+}
+
+// updatePasskeyUsage 登录成功后更新签名计数器和最近使用时间
+// 只有计数器仍是读取时的值才更新，并发使用同一个凭证时只有一个请求成功
+func updatePasskeyUsage(record *models.Passkey, credential *webauthn.Credential) error {
+	result := models.DB.Model(&models.Passkey{}).
+		Where("id = ? AND sign_count = ?", record.ID, record.SignCount).
+		Updates(map[string]interface{}{
+			"sign_count":   int64(credential.Authenticator.SignCount),
+			"backup_state": credential.Flags.BackupState,
+			"last_used_at": time.Now(),
+		})
+	if result.Error != nil {
+		logger.Logger.Errorf("update passkey failed: %v", result.Error)
+		return code.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		return code.ErrPasskeyLoginFailed
+	}
+	return nil
+}
+
+// findUserPasskey 获取用户的通行密钥
+func findUserPasskey(id string, userID uuid.UUID) (*models.Passkey, error) {
+	var record models.Passkey
+	if err := models.DB.Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrPasskeyNotFound
+		}
+		logger.Logger.Errorf("get passkey failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &record, nil
+}
+
+// saveWebAuthnSession 保存WebAuthn仪式的会话数据
+func saveWebAuthnSession(key string, session *webauthn.SessionData) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return code.InternalServerError
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), key, value, webAuthnSessionTTL).Err(); err != nil {
+		logger.Logger.Errorf("save webauthn session failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// takeWebAuthnSession 取出并删除WebAuthn仪式的会话数据，每个挑战只能使用一次
+func takeWebAuthnSession(key string) (*webauthn.SessionData, error) {
+	value, err := redis.GetRedisClient().GetDel(context.Background(), key).Result()
+	if err == redis.Nil {
+		return nil, code.ErrPasskeySessionExpired
+	}
+	if err != nil {
+		logger.Logger.Errorf("get webauthn session failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(value), &session); err != nil {
+		return nil, code.ErrPasskeySessionExpired
+	}
+	return &session, nil
+}
+
+func webAuthnRegisterKey(userID string) string {
+	return config.Conf.Redis.KeyPrefix + ":webauthn_register:" + userID
+}
+
+func webAuthnLoginKey(sessionID string) string {
+	return config.Conf.Redis.KeyPrefix + ":webauthn_login:" + sessionID
+}
+
+func webAuthnTwoFactorKey(challengeHash string) string {
+	return config.Conf.Redis.KeyPrefix + ":webauthn_two_factor:" + challengeHash
+}
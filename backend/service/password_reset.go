@@ -0,0 +1,248 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/dto"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"blog-server/internal/utils"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// passwordResetTokenTTL 重置密码令牌的有效期
+	passwordResetTokenTTL = 30 * time.Minute
+	// passwordResetLimitWindow 重置密码请求的限流窗口
+	passwordResetLimitWindow = time.Hour
+	// passwordResetEmailLimit 每个邮箱在限流窗口内允许的请求次数
+	passwordResetEmailLimit = 3
+	// passwordResetIPLimit 每个IP在限流窗口内允许的请求次数
+	passwordResetIPLimit = 10
+	// passwordResetPath 前端重置密码页面的路径
+	passwordResetPath = "/reset-password"
+)
+
+// ForgotPasswordService 忘记密码服务结构体
+// 用于处理发送重置密码邮件的请求
+type ForgotPasswordService struct {
+	Email string `json:"email" form:"email" binding:"required"` // 邮箱，必填
+	IP    string `json:"-" form:"-"`                            // 请求IP，用于限流
+}
+
+// ForgotPassword 发送重置密码邮件
+// 按邮箱和IP限流；邮箱未注册时同样返回成功，避免泄露邮箱是否注册
+func (service *ForgotPasswordService) ForgotPassword() error {
+	email := strings.TrimSpace(service.Email)
+	if !utils.ValidateEmail(email) {
+		return code.ErrEmailValidation
+	}
+
+	ctx := context.Background()
+	prefix := config.Conf.Redis.KeyPrefix
+
+	// 按IP和邮箱限流
+	if service.IP != "" {
+		if allowed, err := allowPasswordResetRequest(ctx, prefix+":password_reset_limit:ip:"+service.IP, passwordResetIPLimit); err != nil {
+			return code.ErrDatabase
+		} else if !allowed {
+			return code.ErrPasswordResetTooMany
+		}
+	}
+	if allowed, err := allowPasswordResetRequest(ctx, prefix+":password_reset_limit:email:"+strings.ToLower(email), passwordResetEmailLimit); err != nil {
+		return code.ErrDatabase
+	} else if !allowed {
+		return code.ErrPasswordResetTooMany
+	}
+
+	var user models.User
+	if err := models.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Logger.Infof("password reset requested for unknown email: %s", email)
+			return nil
+		}
+		logger.Logger.Errorf("get user failed: %v", err)
+		return code.ErrDatabase
+	}
+	return sendPasswordResetEmail(ctx, &user)
+}
+
+// forcePasswordReset 要求用户重置密码
+// 吊销用户已签发的令牌并发送重置密码邮件，重置密码前所有登录方式都会被拒绝
+func forcePasswordReset(user *models.User) error {
+	if err := requirePasswordReset(user); err != nil {
+		return err
+	}
+	return sendPasswordResetEmail(context.Background(), user)
+}
+
+// requirePasswordReset 标记用户需要重置密码，吊销用户的所有令牌和个人访问令牌
+// 个人访问令牌可能已经泄露，吊销后重置密码也不会恢复，需要用户重新创建
+func requirePasswordReset(user *models.User) error {
+	if err := models.DB.Model(user).Update("password_reset_required", true).Error; err != nil {
+		logger.Logger.Errorf("force password reset failed: %v", err)
+		return code.ErrDatabase
+	}
+	if err := revokeUserTokens(user); err != nil {
+		return err
+	}
+	if err := revokeUserAccessTokens(user.ID); err != nil {
+		logger.Logger.Errorf("revoke personal access tokens failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// sendPasswordResetEmail 生成重置密码令牌并发送重置密码邮件
+// 同一用户只保留最新的一个重置令牌
+func sendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	redisClient := redis.GetRedisClient()
+	prefix := config.Conf.Redis.KeyPrefix
+
+	token, err := generatePasswordResetToken()
+	if err != nil {
+		logger.Logger.Errorf("generate password reset token failed: %v", err)
+		return code.ErrSendPasswordResetEmail
+	}
+	tokenHash := hashPasswordResetToken(token)
+
+	// 使之前的令牌失效，只保存令牌的哈希
+	userKey := prefix + ":password_reset_user:" + user.ID.String()
+	if oldHash, err := redisClient.Get(ctx, userKey).Result(); err == nil {
+		redisClient.Del(ctx, prefix+":password_reset:"+oldHash)
+	}
+	pipe := redisClient.TxPipeline()
+	pipe.Set(ctx, prefix+":password_reset:"+tokenHash, user.ID.String(), passwordResetTokenTTL)
+	pipe.Set(ctx, userKey, tokenHash, passwordResetTokenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Logger.Errorf("redis set password reset token failed: %v", err)
+		return code.ErrSendPasswordResetEmail
+	}
+
+	message := dto.PasswordResetMessage{
+		Email:         user.Email,
+		UserName:      user.UserName,
+		ResetURL:      passwordResetURL(token),
+		ExpireMinutes: int(passwordResetTokenTTL / time.Minute),
+	}
+	if err := publishEmailMessage(ctx, "password_reset", user.Email, message); err != nil {
+		return code.ErrSendPasswordResetEmail
+	}
+	return nil
+}
+
+// ResetPasswordService 重置密码服务结构体
+// 用于使用邮件中的令牌设置新密码
+type ResetPasswordService struct {
+	Token       string `json:"token" form:"token" binding:"required"`               // 重置密码令牌，必填
+	NewPassword string `json:"new_password" form:"new_password" binding:"required"` // 新密码，必填
+}
+
+// ResetPassword 重置密码
+// 令牌只能使用一次；重置成功后解除账号锁定，并吊销用户所有的访问令牌和刷新令牌
+func (service *ResetPasswordService) ResetPassword() error {
+	ctx := context.Background()
+	redisClient := redis.GetRedisClient()
+	prefix := config.Conf.Redis.KeyPrefix
+	tokenHash := hashPasswordResetToken(strings.TrimSpace(service.Token))
+
+	// 先查询令牌，密码校验失败时令牌仍然可用
+	userID, err := redisClient.Get(ctx, prefix+":password_reset:"+tokenHash).Result()
+	if err == redis.Nil {
+		return code.ErrPasswordResetTokenInvalid
+	}
+	if err != nil {
+		logger.Logger.Errorf("redis get password reset token failed: %v", err)
+		return code.ErrDatabase
+	}
+
+	var user models.User
+	if err := models.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.ErrPasswordResetTokenInvalid
+		}
+		logger.Logger.Errorf("get user failed: %v", err)
+		return code.ErrDatabase
+	}
+	if !utils.ValidatePassword(service.NewPassword, user.UserName) {
+		return code.ErrPasswordValidation
+	}
+
+	// 原子地取出并删除令牌，保证并发请求中只有一个能使用
+	if _, err := redisClient.GetDel(ctx, prefix+":password_reset:"+tokenHash).Result(); err == redis.Nil {
+		return code.ErrPasswordResetTokenInvalid
+	} else if err != nil {
+		logger.Logger.Errorf("redis delete password reset token failed: %v", err)
+		return code.ErrDatabase
+	}
+	redisClient.Del(ctx, prefix+":password_reset_user:"+user.ID.String())
+
+	user.Password = service.NewPassword
+	if err := user.GenerateEncryptedPassword(); err != nil {
+		logger.Logger.Errorf("generate encrypted password failed: %v", err)
+		return err
+	}
+	// 能收到邮件说明是本人，解除管理员要求的重置和因密码错误导致的锁定
+	if err := models.DB.Model(&user).Updates(map[string]interface{}{
+		"password":                user.Password,
+		"password_reset_required": false,
+	}).Error; err != nil {
+		logger.Logger.Errorf("update user password failed: %v", err)
+		return code.ErrDatabase
+	}
+
+	if err := revokeUserTokens(&user); err != nil {
+		return err
+	}
+	if err := clearUserThrottle(&user); err != nil {
+		logger.Logger.Errorf("clear login throttle failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// allowPasswordResetRequest 固定窗口计数限流，返回本次请求是否允许
+func allowPasswordResetRequest(ctx context.Context, key string, limit int64) (bool, error) {
+	redisClient := redis.GetRedisClient()
+	count, err := redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		logger.Logger.Errorf("redis incr %s failed: %v", key, err)
+		return false, err
+	}
+	if count == 1 {
+		if err := redisClient.Expire(ctx, key, passwordResetLimitWindow).Err(); err != nil {
+			logger.Logger.Errorf("redis expire %s failed: %v", key, err)
+		}
+	}
+	return count <= limit, nil
+}
+
+// generatePasswordResetToken 生成随机的重置密码令牌
+func generatePasswordResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashPasswordResetToken Redis中只保存令牌的哈希，避免Redis数据泄露后令牌被直接使用
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// passwordResetURL 生成邮件中的重置密码链接
+func passwordResetURL(token string) string {
+	return siteURL(passwordResetPath + "?token=" + url.QueryEscape(token))
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPasswordResetToken(t *testing.T) {
+	first, err := generatePasswordResetToken()
+	if err != nil {
+		t.Fatalf("generatePasswordResetToken() error = %v", err)
+	}
+	second, err := generatePasswordResetToken()
+	if err != nil {
+		t.Fatalf("generatePasswordResetToken() error = %v", err)
+	}
+	if len(first) != 64 || first == second {
+		t.Errorf("tokens = %q, %q, want two different 64 character tokens", first, second)
+	}
+	if hashPasswordResetToken(first) == first || hashPasswordResetToken(first) != hashPasswordResetToken(first) {
+		t.Errorf("hashPasswordResetToken() is not a stable hash")
+	}
+}
+
+func TestPasswordResetURL(t *testing.T) {
+	test := []struct {
+		name    string
+		siteURL string
+		want    string
+	}{
+		{
+			name:    "site url without trailing slash",
+			siteURL: "https://blog.example.com",
+			want:    "https://blog.example.com/reset-password?token=abc",
+		},
+		{
+			name:    "site url with trailing slash",
+			siteURL: "https://blog.example.com/",
+			want:    "https://blog.example.com/reset-password?token=abc",
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Conf.App.SiteURL = tt.siteURL
+			if got := passwordResetURL("abc"); got != tt.want {
+				t.Errorf("passwordResetURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetPasswordClearsLoginThrottle(t *testing.T) {
+	newTestDB(t, &models.User{}, &models.UserSession{})
+	newTestRedis(t)
+	user := createTestUser(t, "alice")
+	if err := models.DB.Model(user).Update("password_reset_required", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	addTestLoginFailures(t, user, defaultThrottleAccountFreeFailures+1, time.Now())
+
+	token, err := generatePasswordResetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := config.Conf.Redis.KeyPrefix + ":password_reset:" + hashPasswordResetToken(token)
+	if err := redis.GetRedisClient().Set(context.Background(), key, user.ID.String(), time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &ResetPasswordService{Token: token, NewPassword: "N3w-Passw0rd!"}
+	if err := service.ResetPassword(); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if status, err := userThrottleStatus(user); err != nil || status.Locked() || status.FailedLoginCount != 0 {
+		t.Errorf("throttle status after reset = %+v, %v, want cleared", status, err)
+	}
+	if err := models.DB.First(user, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.PasswordResetRequired || !user.ComparePassword("N3w-Passw0rd!") {
+		t.Errorf("user after reset = %+v, want new password without reset required", user)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PublicUserStats 用户的公开统计数据，只统计已发布的文章和公开的照片
+type PublicUserStats struct {
+	ArticleCount int64 `json:"article_count"` // 已发布的文章数
+	TotalViews   int64 `json:"total_views"`   // 已发布文章的总浏览数
+	TotalLikes   int64 `json:"total_likes"`   // 已发布文章的总点赞数
+	PhotoCount   int64 `json:"photo_count"`   // 公开的照片数
+	Followers    int64 `json:"followers"`     // 粉丝数
+	Following    int64 `json:"following"`     // 关注数
+}
+
+// PublicUserProfile 用户的公开资料
+// 只包含允许公开展示的字段，邮箱、手机号、生日等个人信息不会返回
+type PublicUserProfile struct {
+	UserName       string            `json:"user_name"`       // 用户名
+	Nickname       string            `json:"nickname"`        // 昵称
+	Avatar         string            `json:"avatar"`          // 头像
+	AvatarVariants map[string]string `json:"avatar_variants"` // 不同尺寸头像的URL
+	Bio            string            `json:"bio"`             // 个人简介
+	Website        string            `json:"website"`         // 个人网站
+	Location       string            `json:"location"`        // 所在地
+	JoinedAt       time.Time         `json:"joined_at"`       // 注册时间
+	Stats          PublicUserStats   `json:"stats"`           // 统计数据
+}
+
+// newPublicUserProfile 从用户信息生成公开资料
+func newPublicUserProfile(user *models.User, stats PublicUserStats) *PublicUserProfile {
+	return &PublicUserProfile{
+		UserName:       user.UserName,
+		Nickname:       user.Nickname,
+		Avatar:         user.Avatar,
+		AvatarVariants: user.AvatarVariants,
+		Bio:            user.Bio,
+		Website:        user.Website,
+		Location:       user.Location,
+		JoinedAt:       user.CreatedAt,
+		Stats:          stats,
+	}
+}
+
+// findPublicUser 按用户名查询可以公开展示的用户，已删除和被封禁的用户视为不存在
+func findPublicUser(userName string) (*models.User, error) {
+	var user models.User
+	if err := models.DB.Where("user_name = ? AND status <> ?", userName, "suspended").First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrUserNotFound
+		}
+		logger.Logger.Errorf("get public user failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &user, nil
+}
+
+// GetPublicUserProfileService 获取用户公开资料服务结构体
+type GetPublicUserProfileService struct {
+	UserName string `uri:"username" binding:"required"` // 用户名，从URL路径获取
+}
+
+// Get 获取用户的公开资料和统计数据
+func (service *GetPublicUserProfileService) Get() (*PublicUserProfile, error) {
+	user, err := findPublicUser(service.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats PublicUserStats
+	if err := models.DB.Model(&models.Article{}).
+		Select("COUNT(*) AS article_count, COALESCE(SUM(view_count), 0) AS total_views, COALESCE(SUM(like_count), 0) AS total_likes").
+		Where("user_id = ? AND status = ?", user.ID, models.ArticleStatusPublished).
+		Scan(&stats).Error; err != nil {
+		logger.Logger.Errorf("count user articles failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if err := models.DB.Model(&models.DailyPhotograph{}).
+		Where("user_id = ? AND is_public = ?", user.ID, true).
+		Count(&stats.PhotoCount).Error; err != nil {
+		logger.Logger.Errorf("count user photos failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if stats.Followers, stats.Following, err = countFollows(user.ID); err != nil {
+		return nil, err
+	}
+	return newPublicUserProfile(user, stats), nil
+}
+
+// ListPublicUserArticlesService 获取用户已发布文章服务结构体
+type ListPublicUserArticlesService struct {
+	UserName string `uri:"username" form:"-" binding:"required"`         // 用户名，从URL路径获取
+	Page     int    `form:"page" binding:"omitempty,min=1"`              // 页码，默认为1
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"` // 每页数量，默认为10，最大为100
+}
+
+// List 分页获取用户已发布的文章，按发布时间倒序，不返回文章内容
+func (service *ListPublicUserArticlesService) List() ([]models.Article, int64, error) {
+	user, err := findPublicUser(service.UserName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if service.Page == 0 {
+		service.Page = 1
+	}
+	if service.PageSize == 0 {
+		service.PageSize = 10
+	}
+
+	query := models.DB.Model(&models.Article{}).Where("user_id = ? AND status = ?", user.ID, models.ArticleStatusPublished)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count user articles failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var articles []models.Article
+	if err := query.Omit("content").Order(articlePublishedAt + " DESC").
+		Offset((service.Page - 1) * service.PageSize).Limit(service.PageSize).
+		Find(&articles).Error; err != nil {
+		logger.Logger.Errorf("list user articles failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	return articles, total, nil
+}
+
+// ListPublicUserPhotosService 获取用户公开照片服务结构体
+type ListPublicUserPhotosService struct {
+	UserName string `uri:"username" form:"-" binding:"required"`    // 用户名，从URL路径获取
+	Page     int    `form:"page" binding:"omitempty,min=1"`         // 页码，默认为1
+	Size     int    `form:"size" binding:"omitempty,min=1,max=100"` // 每页数量，默认为10，最大为100
+}
+
+// List 分页获取用户公开的日常照片，按创建时间倒序
+func (service *ListPublicUserPhotosService) List() ([]*models.DailyPhotograph, int64, error) {
+	user, err := findPublicUser(service.UserName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if service.Page == 0 {
+		service.Page = 1
+	}
+	if service.Size == 0 {
+		service.Size = 10
+	}
+
+	query := models.DB.Model(&models.DailyPhotograph{}).Where("user_id = ? AND is_public = ?", user.ID, true)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Logger.Errorf("count user photos failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	var photos []*models.DailyPhotograph
+	if err := query.Order("created_at DESC").
+		Offset((service.Page - 1) * service.Size).Limit(service.Size).
+		Find(&photos).Error; err != nil {
+		logger.Logger.Errorf("list user photos failed: %v", err)
+		return nil, 0, code.ErrDatabase
+	}
+	return photos, total, nil
+}
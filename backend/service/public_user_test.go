@@ -0,0 +1,56 @@
+package service
+
+import (
+	"blog-server/internal/models"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewPublicUserProfile(t *testing.T) {
+	joinedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := &models.User{
+		UserName:         "alice",
+		Nickname:         "Alice",
+		Email:            "alice@example.com",
+		Phone:            "13800138000",
+		Birthday:         "1990-01-01",
+		Gender:           "女",
+		Bio:              "hello",
+		Website:          "https://alice.example.com",
+		Location:         "上海",
+		Role:             "admin",
+		Status:           "active",
+		TwoFactorEnabled: true,
+	}
+	user.ID = uuid.New()
+	user.CreatedAt = joinedAt
+
+	profile := newPublicUserProfile(user, PublicUserStats{ArticleCount: 2, TotalViews: 30, TotalLikes: 4, PhotoCount: 5})
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := map[string]bool{
+		"user_name": true, "nickname": true, "avatar": true, "avatar_variants": true,
+		"bio": true, "website": true, "location": true, "joined_at": true, "stats": true,
+	}
+	for key := range fields {
+		if !allowed[key] {
+			t.Errorf("public profile exposes %q", key)
+		}
+	}
+	if fields["nickname"] != "Alice" || fields["bio"] != "hello" {
+		t.Errorf("unexpected profile: %s", data)
+	}
+	if !profile.JoinedAt.Equal(joinedAt) || profile.Stats.TotalViews != 30 {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/rbac"
+	"errors"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// roleNamePattern 角色名称只能包含小写字母、数字、下划线和短横线，以字母开头
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// ListRolesService 获取角色列表服务结构体
+type ListRolesService struct{}
+
+// List 返回所有角色，内置角色在前
+func (service *ListRolesService) List() ([]models.Role, error) {
+	var roles []models.Role
+	if err := models.DB.Order("is_system DESC, created_at ASC").Find(&roles).Error; err != nil {
+		logger.Logger.Errorf("list roles failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return roles, nil
+}
+
+// CreateRoleService 创建角色服务结构体
+type CreateRoleService struct {
+	Name        string   `json:"name" binding:"required"`       // 角色名称，小写字母开头，2-32位
+	Description string   `json:"description" binding:"max=255"` // 角色说明
+	Permissions []string `json:"permissions"`                   // 权限列表
+}
+
+// Create 创建自定义角色
+func (service *CreateRoleService) Create() (*models.Role, error) {
+	if !roleNamePattern.MatchString(service.Name) {
+		return nil, code.ErrParam
+	}
+	permissions, err := rbac.NormalizePermissions(service.Permissions)
+	if err != nil {
+		return nil, code.ErrRolePermissionInvalid
+	}
+
+	var count int64
+	if err := models.DB.Unscoped().Model(&models.Role{}).Where("name = ?", service.Name).Count(&count).Error; err != nil {
+		logger.Logger.Errorf("check role exist failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if count > 0 {
+		return nil, code.ErrRoleExist
+	}
+
+	role := models.Role{
+		Name:        service.Name,
+		Description: service.Description,
+		Permissions: permissions,
+	}
+	if err := models.DB.Create(&role).Error; err != nil {
+		logger.Logger.Errorf("create role failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	rbac.Invalidate()
+	return &role, nil
+}
+
+// UpdateRoleService 更新角色服务结构体
+type UpdateRoleService struct {
+	Name        string   `uri:"name" json:"-" binding:"required"` // 角色名称，从URL路径获取
+	Description string   `json:"description" binding:"max=255"`   // 角色说明
+	Permissions []string `json:"permissions"`                     // 权限列表，为空时不修改
+}
+
+// Update 更新角色的说明和权限，管理员角色的权限不能修改
+func (service *UpdateRoleService) Update() (*models.Role, error) {
+	role, err := findRole(service.Name)
+	if err != nil {
+		return nil, err
+	}
+	columns := []string{"description"}
+	updates := models.Role{Description: service.Description}
+	if service.Permissions != nil {
+		if role.Name == rbac.RoleAdmin {
+			return nil, code.ErrRoleProtected
+		}
+		permissions, err := rbac.NormalizePermissions(service.Permissions)
+		if err != nil {
+			return nil, code.ErrRolePermissionInvalid
+		}
+		columns = append(columns, "permissions")
+		updates.Permissions = permissions
+	}
+	// 使用结构体更新，权限列表才会按json序列化
+	if err := models.DB.Model(role).Select(columns).Updates(&updates).Error; err != nil {
+		logger.Logger.Errorf("update role failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	rbac.Invalidate()
+	return findRole(service.Name)
+}
+
+// DeleteRoleService 删除角色服务结构体
+type DeleteRoleService struct {
+	Name string `uri:"name" binding:"required"` // 角色名称，从URL路径获取
+}
+
+// Delete 删除自定义角色，内置角色和仍有用户使用的角色不能删除
+func (service *DeleteRoleService) Delete() error {
+	role, err := findRole(service.Name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return code.ErrRoleProtected
+	}
+	var count int64
+	if err := models.DB.Model(&models.User{}).Where("role = ?", role.Name).Count(&count).Error; err != nil {
+		logger.Logger.Errorf("count role users failed: %v", err)
+		return code.ErrDatabase
+	}
+	if count > 0 {
+		return code.ErrRoleInUse
+	}
+	if err := models.DB.Unscoped().Delete(role).Error; err != nil {
+		logger.Logger.Errorf("delete role failed: %v", err)
+		return code.ErrDatabase
+	}
+	rbac.Invalidate()
+	return nil
+}
+
+// AssignRoleService 修改用户角色服务结构体
+type AssignRoleService struct {
+	Name     string        `uri:"name" binding:"required"`         // 角色名称，从URL路径获取
+	UserID   string        `uri:"user_id" binding:"required,uuid"` // 用户ID，从URL路径获取
+	Operator AdminOperator `uri:"-"`                               // 执行操作的管理员，用于审计
+}
+
+// Assign 修改用户的角色，并吊销用户已签发的令牌，使新角色立即生效
+// 管理员不能修改自己的角色，每次操作都记录审计日志
+func (service *AssignRoleService) Assign() error {
+	user, err := findUserForAdmin(service.UserID, false)
+	if err == nil {
+		err = service.assign(user)
+	}
+	auditAdminOperation(service.Operator, service.UserID, user, AdminOperationChangeRole, "修改角色", map[string]string{"role": service.Name}, err)
+	return err
+}
+
+func (service *AssignRoleService) assign(user *models.User) error {
+	if user.ID == service.Operator.ID {
+		return code.ErrAdminSelfOperation
+	}
+	role, err := findRole(service.Name)
+	if err != nil {
+		return err
+	}
+	if user.Role == role.Name {
+		return nil
+	}
+	if user.Role == rbac.RoleAdmin {
+		var admins int64
+		if err := models.DB.Model(&models.User{}).Where("role = ?", rbac.RoleAdmin).Count(&admins).Error; err != nil {
+			logger.Logger.Errorf("count admins failed: %v", err)
+			return code.ErrDatabase
+		}
+		if admins <= 1 {
+			return code.ErrRoleLastAdmin
+		}
+	}
+	if err := models.DB.Model(user).Update("role", role.Name).Error; err != nil {
+		logger.Logger.Errorf("update user role failed: %v", err)
+		return code.ErrDatabase
+	}
+	return revokeUserTokens(user)
+}
+
+// findRole 根据名称获取角色
+func findRole(name string) (*models.Role, error) {
+	var role models.Role
+	if err := models.DB.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrRoleNotFound
+		}
+		logger.Logger.Errorf("get role failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return &role, nil
+}
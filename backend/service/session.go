@@ -0,0 +1,116 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/tokenstore"
+	"blog-server/internal/utils"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionMeta 登录设备的信息
+type SessionMeta struct {
+	DeviceName string // 设备名称，为空时根据UserAgent生成
+	UserAgent  string // 用户代理
+	IP         string // 请求IP
+	Method     string // 登录方式，用于登录记录
+}
+
+// createSession 创建登录会话
+func createSession(user *models.User, meta SessionMeta) (*models.UserSession, error) {
+	deviceName := strings.TrimSpace(meta.DeviceName)
+	if deviceName == "" {
+		deviceName = utils.DeviceNameFromUserAgent(meta.UserAgent)
+	}
+	now := time.Now()
+	session := &models.UserSession{
+		UserID:     user.ID,
+		DeviceName: truncateRunes(deviceName, 100),
+		UserAgent:  truncateRunes(meta.UserAgent, 500),
+		IP:         meta.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL()),
+	}
+	if err := models.DB.Create(session).Error; err != nil {
+		logger.Logger.Errorf("create session failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return session, nil
+}
+
+// findActiveSession 获取用户有效的会话
+func findActiveSession(sessionID string, userID string) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := models.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.ErrSessionNotFound
+		}
+		logger.Logger.Errorf("get session failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	if !session.IsActive() {
+		return nil, code.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// revokeSession 吊销会话，会话的刷新令牌和尚未过期的访问令牌都会失效
+func revokeSession(session *models.UserSession) error {
+	now := time.Now()
+	if err := models.DB.Model(session).Update("revoked_at", now).Error; err != nil {
+		logger.Logger.Errorf("revoke session failed: %v", err)
+		return code.ErrDatabase
+	}
+	session.RevokedAt = &now
+	if err := tokenstore.RevokeSession(context.Background(), session.ID.String(), accessTokenTTL()); err != nil {
+		logger.Logger.Errorf("revoke session tokens failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// ListSessionsService 会话列表服务结构体
+type ListSessionsService struct {
+	UserID           uuid.UUID `json:"-"` // 用户ID，从JWT中获取
+	CurrentSessionID string    `json:"-"` // 当前请求所在的会话ID，从JWT中获取
+}
+
+// List 获取用户所有有效的会话，按最近活跃时间倒序
+func (service *ListSessionsService) List() ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	if err := models.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", service.UserID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		logger.Logger.Errorf("list sessions failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == service.CurrentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSessionService 吊销会话服务结构体
+type RevokeSessionService struct {
+	ID     string    `uri:"id" binding:"required"` // 会话ID，从URL路径获取
+	UserID uuid.UUID `json:"-"`                    // 用户ID，从JWT中获取
+}
+
+// Revoke 吊销用户的一个会话，该设备需要重新登录
+func (service *RevokeSessionService) Revoke() error {
+	if _, err := uuid.Parse(service.ID); err != nil {
+		return code.ErrSessionNotFound
+	}
+	session, err := findActiveSession(service.ID, service.UserID.String())
+	if err != nil {
+		return err
+	}
+	return revokeSession(session)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/jwtkeys"
+	"blog-server/internal/logger"
+	"blog-server/internal/redis"
+	"context"
+	"time"
+)
+
+const (
+	// signingKeyReloadInterval 重新加载密钥目录和检查是否需要轮换的间隔，需要短于新密钥提前公开的时间
+	signingKeyReloadInterval = 5 * time.Minute
+	// defaultPublishAhead 新密钥默认提前公开的时间
+	defaultPublishAhead = time.Hour
+)
+
+// signingKeyOptions 根据配置生成密钥环的配置
+// 旧密钥被替换后至少保留到用它签发的刷新令牌过期
+func signingKeyOptions() jwtkeys.Options {
+	conf := config.Conf.Auth.JWTKeys
+	overlap := time.Duration(conf.OverlapHours) * time.Hour
+	if minOverlap := refreshTokenTTL() + accessTokenTTL(); overlap < minOverlap {
+		overlap = minOverlap
+	}
+	publishAhead := time.Duration(conf.PublishAheadMinutes) * time.Minute
+	if publishAhead < 2*signingKeyReloadInterval {
+		publishAhead = defaultPublishAhead
+	}
+	return jwtkeys.Options{
+		Dir:              conf.Dir,
+		Algorithm:        conf.Algorithm,
+		RotationInterval: time.Duration(conf.RotationDays) * 24 * time.Hour,
+		Overlap:          overlap,
+		PublishAhead:     publishAhead,
+		LegacyKeyPath:    config.Conf.App.RsaPrivateKeyPath,
+	}
+}
+
+// InitSigningKeys 加载签发JWT的密钥环，第一次启动时导入旧版本的RSA私钥或生成新的密钥
+func InitSigningKeys() error {
+	return jwtkeys.Init(signingKeyOptions())
+}
+
+// StartSigningKeyRotation 启动后台任务，定期重新加载密钥目录，到期时轮换密钥并删除过期的密钥
+// 所有实例都会重新加载密钥目录，轮换通过Redis锁保证只有一个实例执行
+func StartSigningKeyRotation(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(signingKeyReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			runSigningKeyRotation(ctx)
+		}
+	}()
+}
+
+// runSigningKeyRotation 执行一次密钥轮换检查
+func runSigningKeyRotation(ctx context.Context) {
+	ring := jwtkeys.Default()
+	if ring == nil {
+		return
+	}
+	if err := ring.Reload(); err != nil {
+		logger.Logger.Errorf("reload jwt keys failed: %v", err)
+		return
+	}
+
+	key := config.Conf.Redis.KeyPrefix + ":jwt_key_rotation_lock"
+	locked, err := redis.GetRedisClient().SetNX(ctx, key, 1, signingKeyReloadInterval-time.Minute).Result()
+	if err != nil {
+		logger.Logger.Errorf("acquire jwt key rotation lock failed: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	now := time.Now()
+	if newKey, err := ring.RotateIfDue(now); err != nil {
+		logger.Logger.Errorf("rotate jwt key failed: %v", err)
+	} else if newKey != nil {
+		logger.Logger.Infof("jwt key %s (%s) created, signing from %s", newKey.ID, newKey.Algorithm, newKey.ActivatesAt.Format(time.RFC3339))
+	}
+	removed, err := ring.Prune(now)
+	if err != nil {
+		logger.Logger.Errorf("prune jwt keys failed: %v", err)
+	}
+	for _, id := range removed {
+		logger.Logger.Infof("jwt key %s expired and removed", id)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"testing"
+	"time"
+)
+
+func TestSigningKeyOptions(t *testing.T) {
+	defer func(keys config.JWTKeysConfig, app config.AppConfig) {
+		config.Conf.Auth.JWTKeys = keys
+		config.Conf.App = app
+	}(config.Conf.Auth.JWTKeys, config.Conf.App)
+
+	config.Conf.App.JwtExpirationTime = 5
+	config.Conf.App.RefreshTokenExpiration = 1440
+	config.Conf.App.RsaPrivateKeyPath = "legacy.pem"
+
+	tests := []struct {
+		name             string
+		conf             config.JWTKeysConfig
+		wantRotation     time.Duration
+		wantOverlap      time.Duration
+		wantPublishAhead time.Duration
+	}{
+		{
+			name:             "defaults",
+			conf:             config.JWTKeysConfig{Dir: "keys"},
+			wantOverlap:      24*time.Hour + 5*time.Minute,
+			wantPublishAhead: time.Hour,
+		},
+		{
+			name:             "overlap shorter than refresh token",
+			conf:             config.JWTKeysConfig{Dir: "keys", RotationDays: 30, OverlapHours: 1, PublishAheadMinutes: 5},
+			wantRotation:     30 * 24 * time.Hour,
+			wantOverlap:      24*time.Hour + 5*time.Minute,
+			wantPublishAhead: time.Hour,
+		},
+		{
+			name:             "configured",
+			conf:             config.JWTKeysConfig{Dir: "keys", RotationDays: 7, OverlapHours: 72, PublishAheadMinutes: 120},
+			wantRotation:     7 * 24 * time.Hour,
+			wantOverlap:      72 * time.Hour,
+			wantPublishAhead: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Conf.Auth.JWTKeys = tt.conf
+			opts := signingKeyOptions()
+			if opts.RotationInterval != tt.wantRotation || opts.Overlap != tt.wantOverlap || opts.PublishAhead != tt.wantPublishAhead {
+				t.Errorf("signingKeyOptions() = %+v", opts)
+			}
+			if opts.Dir != "keys" || opts.LegacyKeyPath != "legacy.pem" {
+				t.Errorf("unexpected paths: %+v", opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var testEnvOnce sync.Once
+
+// initTestEnv 初始化测试需要的日志和配置
+func initTestEnv(t *testing.T) {
+	t.Helper()
+	testEnvOnce.Do(func() {
+		if err := logger.InitLogger("../logs"); err != nil {
+			panic(err)
+		}
+		if err := config.Init("../config/config_test.yaml"); err != nil {
+			panic(err)
+		}
+		// SQLite没有uuid_generate_v4，注册一个同名函数生成主键
+		sql.Register("sqlite3_uuid", &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				return conn.RegisterFunc("uuid_generate_v4", uuid.NewString, false)
+			},
+		})
+	})
+}
+
+// newTestDB 创建迁移了指定模型的SQLite数据库，并设置为全局DB实例，测试结束后恢复
+func newTestDB(t *testing.T, dst ...interface{}) *gorm.DB {
+	t.Helper()
+	initTestEnv(t)
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_foreign_keys=off"
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite3_uuid", DSN: dsn}, &gorm.Config{
+		Logger:         gormlogger.Default.LogMode(gormlogger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	// PostgreSQL的默认值函数在SQLite中需要加括号
+	for _, model := range dst {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse model failed: %v", err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if strings.HasSuffix(field.DefaultValue, "()") {
+				field.DefaultValue = "(" + field.DefaultValue + ")"
+			}
+		}
+	}
+	if err := db.AutoMigrate(dst...); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	previous := models.DB
+	models.DB = db
+	t.Cleanup(func() {
+		models.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// testRedis 进程内的Redis服务端，只实现了服务中用到的命令
+type testRedis struct {
+	addr   string
+	mutex  sync.Mutex
+	data   map[string]testRedisValue
+	offset time.Duration // 快进的时间，用于测试过期
+}
+
+type testRedisValue struct {
+	value     string
+	zset      map[string]float64 // 有序集合的成员和分数
+	expiresAt time.Time
+}
+
+var (
+	sharedTestRedis *testRedis
+	testRedisOnce   sync.Once
+)
+
+// newTestRedis 启动测试用的Redis服务端并初始化Redis客户端，每个测试开始时清空数据
+func newTestRedis(t *testing.T) *testRedis {
+	t.Helper()
+	initTestEnv(t)
+	testRedisOnce.Do(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			panic(err)
+		}
+		sharedTestRedis = &testRedis{addr: listener.Addr().String(), data: make(map[string]testRedisValue)}
+		go sharedTestRedis.serve(listener)
+		if err := redis.Init(config.RedisConfig{Addr: sharedTestRedis.addr, PoolSize: 4}); err != nil {
+			panic(err)
+		}
+	})
+	sharedTestRedis.mutex.Lock()
+	sharedTestRedis.data = make(map[string]testRedisValue)
+	sharedTestRedis.offset = 0
+	sharedTestRedis.mutex.Unlock()
+	return sharedTestRedis
+}
+
+// FastForward 使时间前进，过期的键随之失效
+func (r *testRedis) FastForward(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.offset += d
+}
+
+func (r *testRedis) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *testRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "MULTI":
+			inMulti = true
+			queued = nil
+			writer.WriteString("+OK\r\n")
+		case name == "EXEC":
+			fmt.Fprintf(writer, "*%d\r\n", len(queued))
+			for _, command := range queued {
+				writer.WriteString(r.exec(command))
+			}
+			inMulti = false
+			queued = nil
+		case inMulti:
+			queued = append(queued, args)
+			writer.WriteString("+QUEUED\r\n")
+		default:
+			writer.WriteString(r.exec(args))
+		}
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand 读取一条RESP格式的命令
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid command")
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimRight(header, "\r\n")[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (r *testRedis) exec(args []string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now().Add(r.offset)
+	get := func(key string) (testRedisValue, bool) {
+		value, ok := r.data[key]
+		if ok && !value.expiresAt.IsZero() && !now.Before(value.expiresAt) {
+			delete(r.data, key)
+			return testRedisValue{}, false
+		}
+		return value, ok
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "CLIENT", "SELECT":
+		return "+OK\r\n"
+	case "GET", "GETDEL":
+		value, ok := get(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		if strings.EqualFold(args[0], "GETDEL") {
+			delete(r.data, args[1])
+		}
+		return bulkString(value.value)
+	case "SET":
+		value := testRedisValue{value: args[2]}
+		_, exists := get(args[1])
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				if exists {
+					return "$-1\r\n"
+				}
+			case "XX":
+				if !exists {
+					return "$-1\r\n"
+				}
+			case "EX", "PX":
+				amount, _ := strconv.ParseInt(args[i+1], 10, 64)
+				unit := time.Second
+				if strings.EqualFold(args[i], "PX") {
+					unit = time.Millisecond
+				}
+				value.expiresAt = now.Add(time.Duration(amount) * unit)
+				i++
+			}
+		}
+		r.data[args[1]] = value
+		return "+OK\r\n"
+	case "DEL", "EXISTS":
+		count := 0
+		for _, key := range args[1:] {
+			if _, ok := get(key); ok {
+				count++
+				if strings.EqualFold(args[0], "DEL") {
+					delete(r.data, key)
+				}
+			}
+		}
+		return ":" + strconv.Itoa(count) + "\r\n"
+	case "INCR":
+		value, ok := get(args[1])
+		number := int64(0)
+		if ok {
+			parsed, err := strconv.ParseInt(value.value, 10, 64)
+			if err != nil {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+			number = parsed
+		}
+		number++
+		value.value = strconv.FormatInt(number, 10)
+		r.data[args[1]] = value
+		return ":" + value.value + "\r\n"
+	case "EXPIRE":
+		value, ok := get(args[1])
+		if !ok {
+			return ":0\r\n"
+		}
+		seconds, _ := strconv.ParseInt(args[2], 10, 64)
+		value.expiresAt = now.Add(time.Duration(seconds) * time.Second)
+		r.data[args[1]] = value
+		return ":1\r\n"
+	case "ZADD":
+		value, ok := get(args[1])
+		if !ok || value.zset == nil {
+			value = testRedisValue{zset: make(map[string]float64), expiresAt: value.expiresAt}
+		}
+		added := 0
+		for i := 2; i+1 < len(args); i += 2 {
+			score, _ := strconv.ParseFloat(args[i], 64)
+			if _, exists := value.zset[args[i+1]]; !exists {
+				added++
+			}
+			value.zset[args[i+1]] = score
+		}
+		r.data[args[1]] = value
+		return ":" + strconv.Itoa(added) + "\r\n"
+	case "ZCARD":
+		value, _ := get(args[1])
+		return ":" + strconv.Itoa(len(value.zset)) + "\r\n"
+	case "ZREMRANGEBYSCORE":
+		value, _ := get(args[1])
+		removed := 0
+		for member, score := range value.zset {
+			if scoreInRange(score, args[2], args[3]) {
+				delete(value.zset, member)
+				removed++
+			}
+		}
+		return ":" + strconv.Itoa(removed) + "\r\n"
+	case "ZREVRANGE":
+		value, _ := get(args[1])
+		members := make([]string, 0, len(value.zset))
+		for member := range value.zset {
+			members = append(members, member)
+		}
+		sort.Slice(members, func(i, j int) bool { return value.zset[members[i]] > value.zset[members[j]] })
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		if stop < 0 || stop >= len(members) {
+			stop = len(members) - 1
+		}
+		withScores := len(args) > 4 && strings.EqualFold(args[4], "WITHSCORES")
+		var reply []string
+		for i := start; i <= stop; i++ {
+			reply = append(reply, bulkString(members[i]))
+			if withScores {
+				reply = append(reply, bulkString(strconv.FormatFloat(value.zset[members[i]], 'f', -1, 64)))
+			}
+		}
+		return "*" + strconv.Itoa(len(reply)) + "\r\n" + strings.Join(reply, "")
+	case "SCAN":
+		// 一次返回所有匹配的键
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for key := range r.data {
+			if matched, _ := path.Match(pattern, key); matched {
+				if _, ok := get(key); ok {
+					keys = append(keys, bulkString(key))
+				}
+			}
+		}
+		return "*2\r\n" + bulkString("0") + "*" + strconv.Itoa(len(keys)) + "\r\n" + strings.Join(keys, "")
+	case "PUBLISH":
+		return ":0\r\n"
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+// scoreInRange 分数是否在ZRANGEBYSCORE格式的区间内，支持-inf、+inf和(开头的开区间
+func scoreInRange(score float64, min, max string) bool {
+	bound := func(value string) (float64, bool) {
+		exclusive := strings.HasPrefix(value, "(")
+		value = strings.TrimPrefix(value, "(")
+		switch value {
+		case "-inf":
+			return math.Inf(-1), exclusive
+		case "+inf", "inf":
+			return math.Inf(1), exclusive
+		}
+		number, _ := strconv.ParseFloat(value, 64)
+		return number, exclusive
+	}
+	low, lowExclusive := bound(min)
+	high, highExclusive := bound(max)
+	if score < low || (lowExclusive && score == low) {
+		return false
+	}
+	return score < high || (!highExclusive && score == high)
+}
+
+func bulkString(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"blog-server/internal/tokenstore"
+	"blog-server/internal/utils"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// accessTokenTTL 访问令牌的有效期
+func accessTokenTTL() time.Duration {
+	return time.Duration(config.Conf.App.JwtExpirationTime) * time.Minute
+}
+
+// refreshTokenTTL 刷新令牌的有效期
+func refreshTokenTTL() time.Duration {
+	return time.Duration(config.Conf.App.RefreshTokenExpiration) * time.Minute
+}
+
+// newTokenClaims 生成令牌的Claims
+func newTokenClaims(user *models.User, tokenType string, ttl time.Duration, version int64, sessionID string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		// 发行者
+		"iss": "moity",
+		// 用户ID
+		"sub": user.ID.String(),
+		// Token类型
+		"type": tokenType,
+		// 用户角色
+		"role": user.Role,
+		// 过期时间
+		"exp": now.Add(ttl).Unix(),
+		// 生效时间
+		"nbf": now.Unix(),
+		// 签发时间
+		"iat": now.Unix(),
+		// 用户名
+		"username": user.UserName,
+		// 令牌ID，用于单独吊销
+		tokenstore.ClaimJTI: tokenstore.NewJTI(),
+		// 令牌版本号，用于吊销用户的所有令牌
+		tokenstore.ClaimVersion: version,
+		// 会话ID，用于吊销一台设备上的令牌
+		tokenstore.ClaimSession: sessionID,
+	}
+}
+
+// issueAccessToken 为会话签发访问令牌
+func issueAccessToken(user *models.User, sessionID string, version int64) (string, error) {
+	accessToken, err := utils.GenerateJWT(newTokenClaims(user, "access", accessTokenTTL(), version, sessionID))
+	if err != nil {
+		return "", code.ErrGenerateJWT
+	}
+	return accessToken, nil
+}
+
+// issueRefreshToken 为会话签发刷新令牌，返回令牌和令牌ID
+func issueRefreshToken(user *models.User, sessionID string, version int64) (string, string, error) {
+	claims := newTokenClaims(user, "refresh", refreshTokenTTL(), version, sessionID)
+	refreshToken, err := utils.GenerateJWT(claims)
+	if err != nil {
+		return "", "", code.ErrGenerateJWT
+	}
+	return refreshToken, claims[tokenstore.ClaimJTI].(string), nil
+}
+
+// issueTokenPair 为用户在新设备上创建会话，并签发访问令牌和刷新令牌，返回令牌和创建的会话
+func issueTokenPair(user *models.User, meta SessionMeta) (string, string, *models.UserSession, error) {
+	version, err := tokenstore.Version(context.Background(), user.ID.String())
+	if err != nil {
+		logger.Logger.Errorf("get token version failed: %v", err)
+		return "", "", nil, code.ErrDatabase
+	}
+
+	session, err := createSession(user, meta)
+	if err != nil {
+		return "", "", nil, err
+	}
+	sessionID := session.ID.String()
+
+	accessToken, err := issueAccessToken(user, sessionID, version)
+	if err != nil {
+		return "", "", nil, err
+	}
+	refreshToken, refreshJTI, err := issueRefreshToken(user, sessionID, version)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if err := models.DB.Model(session).Update("refresh_jti", refreshJTI).Error; err != nil {
+		logger.Logger.Errorf("update session refresh token failed: %v", err)
+		return "", "", nil, code.ErrDatabase
+	}
+	return accessToken, refreshToken, session, nil
+}
+
+// refreshRotationGrace 刷新令牌轮换结果的保留时间
+// 客户端并发刷新或重试时，宽限期内提交同一个刷新令牌得到相同的新令牌，而不会被当作重复使用
+const refreshRotationGrace = 10 * time.Second
+
+// refreshRotationPending 轮换进行中的占位值
+const refreshRotationPending = "pending"
+
+// refreshRotation 一次刷新令牌轮换的结果
+type refreshRotation struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// claimRefreshRotation 占用刷新令牌的轮换权
+// 占用成功时返回true；已被占用时等待并返回宽限期内的轮换结果
+// 占用方轮换失败释放了轮换权时重新占用，轮换结果已过宽限期时同样重新占用，由调用方比对会话当前的令牌ID
+func claimRefreshRotation(jti string) (*refreshRotation, bool, error) {
+	ctx := context.Background()
+	redisClient := redis.GetRedisClient()
+	key := refreshRotationKey(jti)
+
+	// 等待并发的轮换请求完成
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		claimed, err := redisClient.SetNX(ctx, key, refreshRotationPending, refreshRotationGrace).Result()
+		if err != nil {
+			logger.Logger.Errorf("claim refresh rotation failed: %v", err)
+			return nil, false, err
+		}
+		if claimed {
+			return nil, true, nil
+		}
+
+		value, err := redisClient.Get(ctx, key).Result()
+		if err != nil && err != redis.Nil {
+			logger.Logger.Errorf("get refresh rotation failed: %v", err)
+			return nil, false, err
+		}
+		if err == nil && value != refreshRotationPending {
+			var rotation refreshRotation
+			if err := json.Unmarshal([]byte(value), &rotation); err != nil {
+				return nil, false, err
+			}
+			return &rotation, false, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, false, code.ErrRefreshTokenInvalid
+		}
+		// 轮换权刚被释放时立即重新占用
+		if err == redis.Nil {
+			continue
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// saveRefreshRotation 保存轮换结果，供宽限期内的重复请求使用
+func saveRefreshRotation(jti string, rotation *refreshRotation) {
+	value, err := json.Marshal(rotation)
+	if err != nil {
+		return
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), refreshRotationKey(jti), value, refreshRotationGrace).Err(); err != nil {
+		logger.Logger.Errorf("save refresh rotation failed: %v", err)
+	}
+}
+
+// releaseRefreshRotation 轮换失败时释放轮换权
+func releaseRefreshRotation(jti string) {
+	if err := redis.GetRedisClient().Del(context.Background(), refreshRotationKey(jti)).Err(); err != nil {
+		logger.Logger.Errorf("release refresh rotation failed: %v", err)
+	}
+}
+
+func refreshRotationKey(jti string) string {
+	return config.Conf.Redis.KeyPrefix + ":refresh_rotation:" + jti
+}
+
+// revokeUserTokens 吊销用户的所有访问令牌和刷新令牌
+// 递增令牌版本号使已签发的令牌失效，并吊销用户的所有会话
+func revokeUserTokens(user *models.User) error {
+	if err := tokenstore.BumpVersion(context.Background(), user.ID.String()); err != nil {
+		logger.Logger.Errorf("bump token version failed: %v", err)
+		return code.ErrDatabase
+	}
+	if err := models.DB.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.Logger.Errorf("revoke user sessions failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/jwtkeys"
+	"blog-server/internal/models"
+	"blog-server/internal/tokenstore"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNewTokenClaims(t *testing.T) {
+	user := &models.User{UserName: "test", Role: "user"}
+	user.ID = uuid.New()
+
+	first := newTokenClaims(user, "access", time.Minute, 3, "session")
+	second := newTokenClaims(user, "access", time.Minute, 3, "session")
+
+	if first[tokenstore.ClaimJTI] == "" || first[tokenstore.ClaimJTI] == second[tokenstore.ClaimJTI] {
+		t.Errorf("jti = %v, %v, want two different ids", first[tokenstore.ClaimJTI], second[tokenstore.ClaimJTI])
+	}
+	if first[tokenstore.ClaimVersion] != int64(3) {
+		t.Errorf("ver = %v, want 3", first[tokenstore.ClaimVersion])
+	}
+	if first["sub"] != user.ID.String() || first["type"] != "access" || first[tokenstore.ClaimSession] != "session" {
+		t.Errorf("claims = %v", first)
+	}
+}
+
+// setupRefreshTest 准备刷新令牌测试需要的数据库、Redis和签名密钥，返回用户、刷新令牌和会话
+func setupRefreshTest(t *testing.T) (*testRedis, *models.User, string, *models.UserSession) {
+	t.Helper()
+	newTestDB(t, &models.User{}, &models.UserSession{}, &models.OperationLog{})
+	fakeRedis := newTestRedis(t)
+	app := config.Conf.App
+	t.Cleanup(func() { config.Conf.App = app })
+	config.Conf.App.JwtExpirationTime = 5
+	config.Conf.App.RefreshTokenExpiration = 60
+
+	ring, err := jwtkeys.Open(jwtkeys.Options{Dir: t.TempDir(), Overlap: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := jwtkeys.Default()
+	jwtkeys.SetDefault(ring)
+	t.Cleanup(func() { jwtkeys.SetDefault(previous) })
+
+	user := createTestUser(t, "alice")
+	_, refreshToken, session, err := issueTokenPair(user, SessionMeta{UserAgent: "curl/8.0", IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("issueTokenPair() error = %v", err)
+	}
+	return fakeRedis, user, refreshToken, session
+}
+
+// assertSessionRevoked 检查会话已被吊销，并记录了刷新令牌重复使用的操作日志
+func assertSessionRevoked(t *testing.T, session *models.UserSession) {
+	t.Helper()
+	var current models.UserSession
+	if err := models.DB.First(&current, "id = ?", session.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if current.RevokedAt == nil {
+		t.Error("session should be revoked")
+	}
+	// 操作日志在后台写入
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var count int64
+		models.DB.Model(&models.OperationLog{}).Where("operation_type = ?", "refresh_token_reuse").Count(&count)
+		if count > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Error("refresh token reuse should be logged")
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRefreshTokenRepeatWithinGrace(t *testing.T) {
+	_, _, refreshToken, _ := setupRefreshTest(t)
+
+	service := &RefreshTokenService{Token: refreshToken}
+	_, accessToken, newRefreshToken, err := service.RefreshToken()
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if newRefreshToken == refreshToken {
+		t.Fatal("refresh token should be rotated")
+	}
+
+	// 宽限期内重复提交得到相同的令牌
+	_, repeatedAccess, repeatedRefresh, err := service.RefreshToken()
+	if err != nil {
+		t.Fatalf("repeated RefreshToken() error = %v", err)
+	}
+	if repeatedAccess != accessToken || repeatedRefresh != newRefreshToken {
+		t.Error("repeated request within grace should get the same token pair")
+	}
+
+	// 新的刷新令牌可以继续轮换
+	if _, _, _, err := (&RefreshTokenService{Token: newRefreshToken}).RefreshToken(); err != nil {
+		t.Errorf("RefreshToken() with rotated token error = %v", err)
+	}
+}
+
+func TestRefreshTokenReuseAfterGrace(t *testing.T) {
+	fakeRedis, _, refreshToken, session := setupRefreshTest(t)
+
+	service := &RefreshTokenService{Token: refreshToken}
+	_, _, newRefreshToken, err := service.RefreshToken()
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+
+	fakeRedis.FastForward(refreshRotationGrace + time.Second)
+	if _, _, _, err := service.RefreshToken(); err != code.ErrRefreshTokenReused {
+		t.Fatalf("RefreshToken() after grace error = %v, want ErrRefreshTokenReused", err)
+	}
+	assertSessionRevoked(t, session)
+
+	// 整个令牌家族失效，轮换得到的新令牌也不能使用
+	if _, _, _, err := (&RefreshTokenService{Token: newRefreshToken}).RefreshToken(); err == nil {
+		t.Error("rotated token of a revoked session should be rejected")
+	}
+}
+
+func TestRefreshTokenJTIMismatch(t *testing.T) {
+	_, _, refreshToken, session := setupRefreshTest(t)
+
+	// 会话当前的刷新令牌不是提交的令牌，视为重复使用
+	if err := models.DB.Model(session).Update("refresh_jti", tokenstore.NewJTI()).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := (&RefreshTokenService{Token: refreshToken}).RefreshToken(); err != code.ErrRefreshTokenReused {
+		t.Fatalf("RefreshToken() error = %v, want ErrRefreshTokenReused", err)
+	}
+	assertSessionRevoked(t, session)
+}
+
+func TestRefreshTokenClaimReleasedWhileWaiting(t *testing.T) {
+	_, _, refreshToken, session := setupRefreshTest(t)
+
+	// 第一个请求占用了轮换权
+	rotation, claimed, err := claimRefreshRotation(session.RefreshJTI)
+	if err != nil || !claimed || rotation != nil {
+		t.Fatalf("claimRefreshRotation() = %v, %v, %v, want claimed", rotation, claimed, err)
+	}
+
+	type result struct {
+		refreshToken string
+		err          error
+	}
+	done := make(chan result, 1)
+	go func() {
+		_, _, newRefreshToken, err := (&RefreshTokenService{Token: refreshToken}).RefreshToken()
+		done <- result{newRefreshToken, err}
+	}()
+
+	// 第二个请求等待期间，第一个请求轮换失败并释放轮换权
+	time.Sleep(200 * time.Millisecond)
+	releaseRefreshRotation(session.RefreshJTI)
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("RefreshToken() error = %v, want nil", got.err)
+	}
+	if got.refreshToken == "" || got.refreshToken == refreshToken {
+		t.Error("waiting request should rotate the refresh token")
+	}
+	var current models.UserSession
+	if err := models.DB.First(&current, "id = ?", session.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if current.RevokedAt != nil {
+		t.Error("session should not be revoked")
+	}
+}
+
+func TestRevokeUserTokensError(t *testing.T) {
+	// 没有会话表，吊销会话失败
+	newTestDB(t, &models.User{})
+	newTestRedis(t)
+	user := createTestUser(t, "alice")
+
+	if err := revokeUserTokens(user); err != code.ErrDatabase {
+		t.Errorf("revokeUserTokens() error = %v, want ErrDatabase", err)
+	}
+	if err := (&LogoutAllService{UserID: user.ID.String()}).LogoutAll(); err != code.ErrDatabase {
+		t.Errorf("LogoutAll() error = %v, want ErrDatabase", err)
+	}
+}
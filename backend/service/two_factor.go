@@ -0,0 +1,554 @@
+package service
+
+import (
+	"blog-server/internal/code"
+	"blog-server/internal/config"
+	"blog-server/internal/logger"
+	"blog-server/internal/models"
+	"blog-server/internal/redis"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	// twoFactorEnrollTTL 开启两步验证时，未确认的密钥的有效期
+	twoFactorEnrollTTL = 10 * time.Minute
+	// loginChallengeTTL 登录挑战令牌的有效期
+	loginChallengeTTL = 5 * time.Minute
+	// loginChallengeMaxAttempts 每个登录挑战允许输错验证码的次数
+	loginChallengeMaxAttempts = 5
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// totpQRCodeSize 二维码图片的边长（像素）
+	totpQRCodeSize = 256
+)
+
+// 两步验证方式
+const (
+	TwoFactorMethodTOTP         = "totp"
+	TwoFactorMethodRecoveryCode = "recovery_code"
+	TwoFactorMethodPasskey      = "passkey"
+)
+
+// totpValidateOpts TOTP校验参数，与RFC 6238和常见验证器应用的默认值一致，允许前后各一个时间步长的误差
+var totpValidateOpts = totp.ValidateOpts{
+	Period:    30,
+	Skew:      1,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TwoFactorEnrollment 开启两步验证时返回的密钥信息
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`      // Base32编码的密钥，用于手动输入
+	OTPAuthURL string `json:"otpauth_url"` // otpauth://格式的URI
+	QRCode     string `json:"qr_code"`     // otpauth URI的二维码，PNG格式的Data URL
+}
+
+// EnrollTwoFactorService 开启两步验证服务结构体
+// 生成新的TOTP密钥，需要用验证码确认后才会生效
+type EnrollTwoFactorService struct {
+	UserID uuid.UUID `json:"-" form:"-"` // 用户ID，从JWT中获取
+}
+
+// Enroll 生成TOTP密钥，返回otpauth URI和二维码
+func (service *EnrollTwoFactorService) Enroll() (*TwoFactorEnrollment, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return nil, code.ErrTwoFactorAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer(),
+		AccountName: user.Email,
+		Period:      uint(totpValidateOpts.Period),
+		Digits:      totpValidateOpts.Digits,
+		Algorithm:   totpValidateOpts.Algorithm,
+	})
+	if err != nil {
+		logger.Logger.Errorf("generate totp key failed: %v", err)
+		return nil, code.InternalServerError
+	}
+
+	image, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		logger.Logger.Errorf("generate totp qr code failed: %v", err)
+		return nil, code.InternalServerError
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image); err != nil {
+		logger.Logger.Errorf("encode totp qr code failed: %v", err)
+		return nil, code.InternalServerError
+	}
+
+	// 确认前密钥只保存在Redis中，重复调用会覆盖之前未确认的密钥
+	if err := redis.GetRedisClient().Set(context.Background(), twoFactorEnrollKey(user.ID.String()), key.Secret(), twoFactorEnrollTTL).Err(); err != nil {
+		logger.Logger.Errorf("save totp enrollment failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ConfirmTwoFactorService 确认开启两步验证服务结构体
+type ConfirmTwoFactorService struct {
+	Code   string    `json:"code" form:"code" binding:"required"` // 验证器应用生成的验证码，必填
+	UserID uuid.UUID `json:"-" form:"-"`                          // 用户ID，从JWT中获取
+}
+
+// Confirm 使用第一个验证码确认密钥，开启两步验证并生成恢复码
+// 返回的恢复码只展示这一次
+func (service *ConfirmTwoFactorService) Confirm() ([]string, error) {
+	ctx := context.Background()
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return nil, code.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := redis.GetRedisClient().Get(ctx, twoFactorEnrollKey(user.ID.String())).Result()
+	if err == redis.Nil {
+		return nil, code.ErrTwoFactorEnrollExpired
+	}
+	if err != nil {
+		logger.Logger.Errorf("get totp enrollment failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	user.TwoFactorSecret = secret
+	ok, err := validateTOTP(&user, service.Code)
+	if err != nil {
+		return nil, code.ErrDatabase
+	}
+	if !ok {
+		return nil, code.ErrTwoFactorCodeInvalid
+	}
+
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		logger.Logger.Errorf("generate recovery codes failed: %v", err)
+		return nil, code.InternalServerError
+	}
+	now := time.Now()
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"two_factor_enabled":    true,
+			"two_factor_secret":     secret,
+			"two_factor_enabled_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, user.ID, recoveryCodes)
+	})
+	if err != nil {
+		logger.Logger.Errorf("enable two factor failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+
+	redis.GetRedisClient().Del(ctx, twoFactorEnrollKey(user.ID.String()))
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactorService 关闭两步验证服务结构体
+type DisableTwoFactorService struct {
+	Password string    `json:"password" form:"password" binding:"required"` // 当前密码，必填
+	Code     string    `json:"code" form:"code" binding:"required"`         // 验证码或恢复码，必填
+	UserID   uuid.UUID `json:"-" form:"-"`                                  // 用户ID，从JWT中获取
+}
+
+// Disable 验证密码和验证码后关闭两步验证，删除密钥和恢复码
+func (service *DisableTwoFactorService) Disable() error {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return code.ErrUserNotFound
+	}
+	if !user.TwoFactorEnabled {
+		return code.ErrTwoFactorNotEnabled
+	}
+	if !user.ComparePassword(service.Password) {
+		return code.ErrPasswordIncorrect
+	}
+	if err := verifySecondFactor(&user, service.Code); err != nil {
+		return err
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"two_factor_enabled":    false,
+			"two_factor_secret":     "",
+			"two_factor_enabled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		logger.Logger.Errorf("disable two factor failed: %v", err)
+		return code.ErrDatabase
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodesService 重新生成恢复码服务结构体
+type RegenerateRecoveryCodesService struct {
+	Code   string    `json:"code" form:"code" binding:"required"` // 验证器应用生成的验证码，必填
+	UserID uuid.UUID `json:"-" form:"-"`                          // 用户ID，从JWT中获取
+}
+
+// Regenerate 重新生成恢复码，之前的恢复码全部失效
+// 返回的恢复码只展示这一次
+func (service *RegenerateRecoveryCodesService) Regenerate() ([]string, error) {
+	var user models.User
+	if err := models.DB.Where("id = ?", service.UserID).First(&user).Error; err != nil {
+		return nil, code.ErrUserNotFound
+	}
+	if !user.TwoFactorEnabled {
+		return nil, code.ErrTwoFactorNotEnabled
+	}
+	ok, err := validateTOTP(&user, service.Code)
+	if err != nil {
+		return nil, code.ErrDatabase
+	}
+	if !ok {
+		return nil, code.ErrTwoFactorCodeInvalid
+	}
+
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		logger.Logger.Errorf("generate recovery codes failed: %v", err)
+		return nil, code.InternalServerError
+	}
+	if err := models.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, user.ID, recoveryCodes)
+	}); err != nil {
+		logger.Logger.Errorf("regenerate recovery codes failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	return recoveryCodes, nil
+}
+
+// TwoFactorLoginService 两步验证登录服务结构体
+// 使用登录接口返回的挑战令牌和验证码换取访问令牌和刷新令牌
+type TwoFactorLoginService struct {
+	ChallengeToken string `json:"challenge_token" form:"challenge_token" binding:"required"` // 登录挑战令牌，必填
+	Code           string `json:"code" form:"code" binding:"required"`                       // 验证码或恢复码，必填
+	UserAgent      string `json:"-" form:"-"`                                                // 用户代理，从请求头获取
+	IP             string `json:"-" form:"-"`                                                // 请求IP
+}
+
+// Login 校验验证码并完成登录
+// 返回用户信息、访问令牌、刷新令牌和可能的错误
+func (service *TwoFactorLoginService) Login() (*models.User, string, string, error) {
+	challengeHash := hashLoginChallenge(strings.TrimSpace(service.ChallengeToken))
+	challenge, user, err := loadLoginChallenge(challengeHash)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if err := verifySecondFactor(user, service.Code); err != nil {
+		if err == code.ErrTwoFactorCodeInvalid {
+			recordLoginChallengeFailure(challengeHash)
+			meta := challenge.SessionMeta
+			meta.UserAgent, meta.IP = service.UserAgent, service.IP
+			recordLoginFailure(user, meta, "two factor code incorrect")
+		}
+		return nil, "", "", err
+	}
+
+	return completeLoginChallenge(challengeHash, challenge, user, service.UserAgent, service.IP)
+}
+
+// loginChallenge 密码验证通过后保存在Redis中的登录挑战
+type loginChallenge struct {
+	UserID      string      `json:"user_id"`
+	SessionMeta SessionMeta `json:"session_meta"`
+}
+
+// createLoginChallenge 为通过密码验证的用户创建登录挑战，返回挑战令牌
+// Redis中只保存令牌的哈希
+func createLoginChallenge(user *models.User, meta SessionMeta) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		logger.Logger.Errorf("generate login challenge failed: %v", err)
+		return "", code.InternalServerError
+	}
+	token := hex.EncodeToString(buf)
+
+	value, err := json.Marshal(&loginChallenge{UserID: user.ID.String(), SessionMeta: meta})
+	if err != nil {
+		return "", code.InternalServerError
+	}
+	if err := redis.GetRedisClient().Set(context.Background(), loginChallengeKey(hashLoginChallenge(token)), value, loginChallengeTTL).Err(); err != nil {
+		logger.Logger.Errorf("save login challenge failed: %v", err)
+		return "", code.ErrDatabase
+	}
+	return token, nil
+}
+
+// loadLoginChallenge 获取登录挑战和对应的用户
+// 挑战令牌签发后用户状态可能发生变化，需要重新检查
+func loadLoginChallenge(challengeHash string) (*loginChallenge, *models.User, error) {
+	challenge, err := getLoginChallenge(challengeHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user models.User
+	if err := models.DB.Where("id = ?", challenge.UserID).First(&user).Error; err != nil {
+		return nil, nil, code.ErrTwoFactorChallengeInvalid
+	}
+	if user.Status == "suspended" {
+		return nil, nil, code.ErrUserSuspended
+	}
+	if !user.IsActive {
+		return nil, nil, code.ErrUserInactive
+	}
+	if user.PasswordResetRequired {
+		return nil, nil, code.ErrPasswordResetRequired
+	}
+	if !user.TwoFactorEnabled {
+		return nil, nil, code.ErrTwoFactorChallengeInvalid
+	}
+	return challenge, &user, nil
+}
+
+// completeLoginChallenge 第二步验证通过后使用挑战令牌完成登录，挑战令牌只能使用一次
+// 会话信息使用完成密码验证时的设备，IP和用户代理以本次请求为准
+func completeLoginChallenge(challengeHash string, challenge *loginChallenge, user *models.User, userAgent, ip string) (*models.User, string, string, error) {
+	if !consumeLoginChallenge(challengeHash) {
+		return nil, "", "", code.ErrTwoFactorChallengeInvalid
+	}
+
+	meta := challenge.SessionMeta
+	if userAgent != "" {
+		meta.UserAgent = userAgent
+	}
+	if ip != "" {
+		meta.IP = ip
+	}
+	accessToken, refreshToken, err := completeLogin(user, meta)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return user, accessToken, refreshToken, nil
+}
+
+// getLoginChallenge 获取登录挑战
+func getLoginChallenge(challengeHash string) (*loginChallenge, error) {
+	value, err := redis.GetRedisClient().Get(context.Background(), loginChallengeKey(challengeHash)).Result()
+	if err == redis.Nil {
+		return nil, code.ErrTwoFactorChallengeInvalid
+	}
+	if err != nil {
+		logger.Logger.Errorf("get login challenge failed: %v", err)
+		return nil, code.ErrDatabase
+	}
+	var challenge loginChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		return nil, code.ErrTwoFactorChallengeInvalid
+	}
+	return &challenge, nil
+}
+
+// consumeLoginChallenge 删除登录挑战，并发请求中只有一个能成功
+func consumeLoginChallenge(challengeHash string) bool {
+	ctx := context.Background()
+	redisClient := redis.GetRedisClient()
+	deleted, err := redisClient.Del(ctx, loginChallengeKey(challengeHash)).Result()
+	if err != nil {
+		logger.Logger.Errorf("delete login challenge failed: %v", err)
+		return false
+	}
+	redisClient.Del(ctx, loginChallengeAttemptsKey(challengeHash))
+	return deleted > 0
+}
+
+// recordLoginChallengeFailure 记录验证码错误，次数用完后挑战失效，需要重新输入密码
+func recordLoginChallengeFailure(challengeHash string) {
+	ctx := context.Background()
+	redisClient := redis.GetRedisClient()
+	key := loginChallengeAttemptsKey(challengeHash)
+	attempts, err := redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		logger.Logger.Errorf("record login challenge failure failed: %v", err)
+		return
+	}
+	if attempts == 1 {
+		redisClient.Expire(ctx, key, loginChallengeTTL)
+	}
+	if attempts >= loginChallengeMaxAttempts {
+		redisClient.Del(ctx, loginChallengeKey(challengeHash), key)
+	}
+}
+
+// twoFactorMethods 用户可用的两步验证方式
+// 注册了通行密钥的用户也可以用通行密钥代替验证码
+func twoFactorMethods(user *models.User) []string {
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	methods := []string{TwoFactorMethodTOTP, TwoFactorMethodRecoveryCode}
+	var count int64
+	if err := models.DB.Model(&models.Passkey{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		logger.Logger.Errorf("count passkeys failed: %v", err)
+	} else if count > 0 {
+		methods = append(methods, TwoFactorMethodPasskey)
+	}
+	return methods
+}
+
+// verifySecondFactor 校验验证码或恢复码
+// 6位数字按TOTP验证码校验，其它按恢复码校验
+func verifySecondFactor(user *models.User, input string) error {
+	input = strings.TrimSpace(input)
+	if isTOTPCode(input) {
+		ok, err := validateTOTP(user, input)
+		if err != nil {
+			return code.ErrDatabase
+		}
+		if !ok {
+			return code.ErrTwoFactorCodeInvalid
+		}
+		return nil
+	}
+	return useRecoveryCode(user, input)
+}
+
+// isTOTPCode 判断输入是否为TOTP验证码的格式
+func isTOTPCode(input string) bool {
+	if len(input) != totpValidateOpts.Digits.Length() {
+		return false
+	}
+	for _, r := range input {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTOTP 校验TOTP验证码，同一个验证码在有效期内只能使用一次，防止被截获后重放
+func validateTOTP(user *models.User, passcode string) (bool, error) {
+	if user.TwoFactorSecret == "" {
+		return false, nil
+	}
+	ok, err := totp.ValidateCustom(passcode, user.TwoFactorSecret, time.Now(), totpValidateOpts)
+	if err != nil || !ok {
+		return false, nil
+	}
+
+	// 验证码在前后各一个时间步长内有效，记录的时间覆盖整个有效期
+	ttl := time.Duration(totpValidateOpts.Period*(2*totpValidateOpts.Skew+1)) * time.Second
+	fresh, err := redis.GetRedisClient().SetNX(context.Background(), totpUsedKey(user.ID.String(), passcode), 1, ttl).Result()
+	if err != nil {
+		logger.Logger.Errorf("record used totp code failed: %v", err)
+		return false, err
+	}
+	return fresh, nil
+}
+
+// useRecoveryCode 使用恢复码，每个恢复码只能使用一次
+func useRecoveryCode(user *models.User, input string) error {
+	result := models.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(input)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		logger.Logger.Errorf("use recovery code failed: %v", result.Error)
+		return code.ErrDatabase
+	}
+	if result.RowsAffected == 0 {
+		return code.ErrTwoFactorCodeInvalid
+	}
+	return nil
+}
+
+// replaceRecoveryCodes 删除用户原有的恢复码并保存新的恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, recoveryCodes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	records := make([]models.RecoveryCode, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(recoveryCode),
+		})
+	}
+	return tx.Create(&records).Error
+}
+
+// generateRecoveryCodes 生成一组随机恢复码，格式为xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		recoveryCodes = append(recoveryCodes, raw[:5]+"-"+raw[5:])
+	}
+	return recoveryCodes, nil
+}
+
+// hashRecoveryCode 恢复码的哈希，忽略大小写、空格和连字符
+func hashRecoveryCode(recoveryCode string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(recoveryCode))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashLoginChallenge 登录挑战令牌的哈希
+func hashLoginChallenge(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// totpIssuer 验证器应用中显示的签发者名称，不能为空
+func totpIssuer() string {
+	if config.Conf.Auth.TOTPIssuer != "" {
+		return config.Conf.Auth.TOTPIssuer
+	}
+	if config.Conf.App.Name != "" {
+		return config.Conf.App.Name
+	}
+	return "blog-server"
+}
+
+func twoFactorEnrollKey(userID string) string {
+	return config.Conf.Redis.KeyPrefix + ":two_factor_enroll:" + userID
+}
+
+func loginChallengeKey(challengeHash string) string {
+	return config.Conf.Redis.KeyPrefix + ":login_challenge:" + challengeHash
+}
+
+func loginChallengeAttemptsKey(challengeHash string) string {
+	return config.Conf.Redis.KeyPrefix + ":login_challenge_attempts:" + challengeHash
+}
+
+func totpUsedKey(userID, passcode string) string {
+	return config.Conf.Redis.KeyPrefix + ":totp_used:" + userID + ":" + passcode
+}
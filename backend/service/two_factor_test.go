@@ -0,0 +1,79 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes() error = %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("len(recoveryCodes) = %d, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+	pattern := regexp.MustCompile(`^[0-9a-f]{5}-[0-9a-f]{5}$`)
+	seen := make(map[string]bool)
+	for _, recoveryCode := range recoveryCodes {
+		if !pattern.MatchString(recoveryCode) {
+			t.Errorf("recovery code %q does not match xxxxx-xxxxx", recoveryCode)
+		}
+		if seen[recoveryCode] {
+			t.Errorf("duplicate recovery code %q", recoveryCode)
+		}
+		seen[recoveryCode] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("abcde-12345")
+	for _, input := range []string{"ABCDE-12345", "abcde12345", "abcde 12345"} {
+		if got := hashRecoveryCode(input); got != want {
+			t.Errorf("hashRecoveryCode(%q) = %v, want %v", input, got, want)
+		}
+	}
+	if hashRecoveryCode("abcde-12346") == want {
+		t.Errorf("different recovery codes have the same hash")
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	test := []struct {
+		input string
+		want  bool
+	}{
+		{input: "123456", want: true},
+		{input: "12345", want: false},
+		{input: "1234567", want: false},
+		{input: "12a456", want: false},
+		{input: "abcde-12345", want: false},
+	}
+	for _, tt := range test {
+		if got := isTOTPCode(tt.input); got != tt.want {
+			t.Errorf("isTOTPCode(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPValidateOpts(t *testing.T) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "test", AccountName: "test@test.com"})
+	if err != nil {
+		t.Fatalf("totp.Generate() error = %v", err)
+	}
+	now := time.Now()
+	passcode, err := totp.GenerateCodeCustom(key.Secret(), now.Add(-30*time.Second), totpValidateOpts)
+	if err != nil {
+		t.Fatalf("totp.GenerateCodeCustom() error = %v", err)
+	}
+	// 前一个时间步长的验证码仍然有效
+	if ok, _ := totp.ValidateCustom(passcode, key.Secret(), now, totpValidateOpts); !ok {
+		t.Errorf("passcode from the previous step is rejected")
+	}
+	if ok, _ := totp.ValidateCustom(passcode, key.Secret(), now.Add(2*time.Minute), totpValidateOpts); ok {
+		t.Errorf("expired passcode is accepted")
+	}
+}
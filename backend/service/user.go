@@ -6,16 +6,15 @@ import (
 	"blog-server/internal/dto"
 	"blog-server/internal/logger"
 	"blog-server/internal/models"
-	"blog-server/internal/mq"
 	"blog-server/internal/redis"
-	"blog-server/internal/rsa"
 	"blog-server/internal/utils"
 	"context"
-	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // RegisterUserService 用户注册服务结构体
@@ -25,15 +24,24 @@ type RegisterUserService struct {
 	Password         string `json:"password" form:"password" binding:"required"`         // 密码，必填
 	Email            string `json:"email" form:"email" binding:"required"`                // 邮箱，必填
 	VerificationCode string `json:"verification_code" form:"verification_code" binding:"required"` // 邮箱验证码，必填
+	InviteCode       string `json:"invite_code" form:"invite_code"`                               // 邀请码，仅邀请注册时必填
 }
 
 // Register 用户注册
-// 验证验证码、检查用户名是否已存在、创建用户并保存到数据库
+// 检查注册方式、验证验证码、检查用户名是否已存在、使用邀请码、创建用户并保存到数据库
 // 返回可能的错误
 func (service *RegisterUserService) Register() error {
 	postgreDB := models.DB
 	var count int64
 	
+	// 检查当前注册方式，不允许注册时不消耗验证码
+	if err := checkRegistrationAllowed(service.InviteCode); err != nil {
+		return err
+	}
+	if err := checkInviteCodeUsable(service.InviteCode); err != nil {
+		return err
+	}
+	
 	// 验证验证码长度
 	if len(service.VerificationCode) != 6 {
 		return code.ErrVerificationCodeLength
@@ -87,8 +95,23 @@ func (service *RegisterUserService) Register() error {
 	user.Status = "active"
 	user.IsActive = true
 	
-	// 创建用户
-	if err := postgreDB.Create(&user).Error; err != nil {
+	// 创建用户，使用邀请码时在同一事务中增加邀请码的使用次数，并使用邀请码的角色
+	err := postgreDB.Transaction(func(tx *gorm.DB) error {
+		if normalizeInviteCode(service.InviteCode) != "" {
+			invite, err := redeemInviteCode(tx, service.InviteCode)
+			if err != nil {
+				return err
+			}
+			user.Role = invite.Role
+			user.InvitedBy = &invite.CreatedBy
+			user.InviteCodeID = &invite.ID
+		}
+		return tx.Create(&user).Error
+	})
+	if errors.Is(err, code.ErrInviteCodeInvalid) {
+		return code.ErrInviteCodeInvalid
+	}
+	if err != nil {
 		logger.Logger.Errorf("create user failed: %v", err)
 		return code.ErrUserCreate
 	}
@@ -98,139 +121,137 @@ func (service *RegisterUserService) Register() error {
 // LoginUserService 用户登录服务结构体
 // 用于处理用户登录的请求和业务逻辑
 type LoginUserService struct {
-	Account  string `json:"account" form:"account" binding:"required"`   // 账号（用户名/邮箱/手机号），必填
-	Password string `json:"password" form:"password" binding:"required"` // 密码，必填
+	Account      string `json:"account" form:"account" binding:"required"`   // 账号（用户名/邮箱/手机号），必填
+	Password     string `json:"password" form:"password" binding:"required"` // 密码，必填
+	DeviceName   string `json:"device_name" form:"device_name"`              // 设备名称，可选，为空时根据User-Agent生成
+	UserAgent    string `json:"-" form:"-"`                                  // 用户代理，从请求头获取
+	IP           string `json:"-" form:"-"`                                  // 请求IP
+	PowChallenge string `json:"pow_challenge" form:"pow_challenge"`          // 工作量证明挑战，失败次数过多时必填
+	PowNonce     string `json:"pow_nonce" form:"pow_nonce"`                  // 工作量证明的答案
+}
+
+// LoginResult 登录结果
+// 开启两步验证的用户密码验证通过后只返回ChallengeToken，需要再用验证码换取访问令牌和刷新令牌
+type LoginResult struct {
+	User           *models.User // 用户信息
+	AccessToken    string       // 访问令牌
+	RefreshToken   string       // 刷新令牌
+	ChallengeToken string       // 两步验证的登录挑战令牌
+	Methods        []string     // 可用的两步验证方式
 }
 
 // Login 用户登录
-// 验证账号密码、检查用户状态、生成JWT令牌、更新登录记录
-// 返回用户信息、访问令牌、刷新令牌和可能的错误
-func (service *LoginUserService) Login() (*models.User, string, string, error) {
+// 验证账号密码、检查用户状态；未开启两步验证时生成JWT令牌、更新登录记录，
+// 开启两步验证时返回短期有效的登录挑战令牌
+// 返回登录结果和可能的错误
+func (service *LoginUserService) Login() (*LoginResult, error) {
 	postgreDB := models.DB
 	var user models.User
-	
-	// 根据账号（用户名/邮箱/手机号）查询用户
-	if postgreDB.Where("user_name = ? OR email = ? OR phone = ?", service.Account, service.Account, service.Account).First(&user).Error != nil {
-		return nil, "", "", code.ErrUserNotFound
+
+	meta := SessionMeta{
+		DeviceName: service.DeviceName,
+		UserAgent:  service.UserAgent,
+		IP:         service.IP,
+		Method:     models.LoginMethodPassword,
 	}
-	
-	// TODO: 可以用Redis存储Lock信息，防止用户频繁登录
-	// 检查用户是否被锁定
-	if user.IsLocked && user.LockUntil.After(time.Now()) {
-		user.LastFailedLogin = time.Now()
-		user.LastFailedReason = "user locked"
-		if postgreDB.Save(&user).Error != nil {
-			return nil, "", "", code.ErrDatabase
-		}
-		return nil, "", "", code.ErrUserLocked
-	} else if user.IsLocked && user.LockUntil.Before(time.Now()) {
-		// 锁定时间已过，解锁用户
-		user.IsLocked = false
-		user.LockUntil = time.Now()
+
+	// 根据账号（用户名/邮箱/手机号）查询用户，账号不存在时也需要限流，防止撞库
+	found := postgreDB.Where("user_name = ? OR email = ? OR phone = ?", service.Account, service.Account, service.Account).First(&user).Error == nil
+	var throttle *loginThrottle
+	if found {
+		throttle = newLoginThrottle(service.Account, &user, service.IP)
+	} else {
+		throttle = newLoginThrottle(service.Account, nil, service.IP)
 	}
-	
+
+	// 按账号和IP检查失败次数，需要等待或完成工作量证明时直接返回
+	if err := throttle.Check(service.PowChallenge, service.PowNonce); err != nil {
+		return nil, err
+	}
+	if !found {
+		throttle.RecordFailure()
+		return nil, code.ErrUserNotFound
+	}
+
 	// 验证密码
 	if !user.ComparePassword(service.Password) {
-		user.FailedLoginCount++
-		user.LastFailedLogin = time.Now()
-		user.LastFailedReason = "password incorrect"
-		
-		// TODO: 失败次数过多，锁定用户
-		// 失败次数超过5次，锁定用户
-		if user.FailedLoginCount > 5 {
-			user.IsLocked = true
-			user.LockUntil = time.Now().Add((time.Duration(user.FailedLoginCount - 5)) * time.Minute)
-		}
-		
-		if postgreDB.Save(&user).Error != nil {
-			return nil, "", "", code.ErrDatabase
-		}
-		return nil, "", "", code.ErrPasswordIncorrect
+		throttle.RecordFailure()
+		recordLoginFailure(&user, meta, "password incorrect")
+		return nil, code.ErrPasswordIncorrect
 	}
-	
+	throttle.Reset()
+
+	// 密码哈希使用旧的算法或参数时，用这次验证通过的密码重新计算
+	if user.PasswordNeedsRehash() {
+		rehashPassword(&user, service.Password)
+	}
+
 	// 验证用户是否被封号
 	switch user.Status {
 	case "suspended":
-		return nil, "", "", code.ErrUserSuspended
+		return nil, code.ErrUserSuspended
 	case "inactive":
 		// 如果用户状态为inactive，则激活用户
 		user.Status = "active"
 	}
-	
+
 	// 验证用户是否激活
 	if !user.IsActive {
-		return nil, "", "", code.ErrUserInactive
+		return nil, code.ErrUserInactive
 	}
-	
-	// 处理用户的失败登录记录
-	if user.FailedLoginCount > 0 {
-		user.FailedLoginCount = 0
-		user.LastFailedLogin = time.Now()
+
+	// 管理员要求重置密码或用户确认不是本人登录时，需要先通过邮件重置密码
+	if user.PasswordResetRequired {
+		return nil, code.ErrPasswordResetRequired
 	}
 
-	var accessToken, refreshToken string
-	redisClient := redis.GetRedisClient()
-	
-	// 生成Access Token
-	accessClaims := jwt.MapClaims{
-		// 发行者
-		"iss": "moity",
-		// 用户ID
-		"sub": user.ID.String(),
-		// Token类型
-		"type": "access",
-		// 用户角色
-		"role": user.Role,
-		// 过期时间
-		"exp": time.Now().Add(time.Duration(config.Conf.App.JwtExpirationTime) * time.Minute).Unix(),
-		// 生效时间
-		"nbf": time.Now().Unix(),
-		// 签发时间
-		"iat": time.Now().Unix(),
-		// 用户名
-		"username": user.UserName,
-	}
-	accessJwtToken, err := utils.GenerateJWT(accessClaims, rsa.PrivateKey)
+	// 开启两步验证时，密码验证通过后还需要验证码
+	if user.TwoFactorEnabled {
+		if err := postgreDB.Save(&user).Error; err != nil {
+			logger.Logger.Errorf("update user failed: %v", err)
+			return nil, code.ErrDatabase
+		}
+		challengeToken, err := createLoginChallenge(&user, meta)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			ChallengeToken: challengeToken,
+			Methods:        twoFactorMethods(&user),
+		}, nil
+	}
+
+	accessToken, refreshToken, err := completeLogin(&user, meta)
 	if err != nil {
-		return nil, "", "", code.ErrGenerateJWT
+		return nil, err
 	}
-	accessToken = accessJwtToken
-	
-	// 生成Refresh Token
-	refreshClaims := jwt.MapClaims{
-		// 发行者
-		"iss": "moity",
-		// 用户ID
-		"sub": user.ID.String(),
-		// Token类型
-		"type": "refresh",
-		// 用户角色
-		"role": user.Role,
-		// 过期时间 (天)
-		"exp": time.Now().Add(time.Duration(config.Conf.App.RefreshTokenExpiration) * time.Minute).Unix(),
-		// 生效时间
-		"nbf": time.Now().Unix(),
-		// 签发时间
-		"iat": time.Now().Unix(),
-		// 用户名
-		"username": user.UserName,
-	}
-	refreshJwtToken, err := utils.GenerateJWT(refreshClaims, rsa.PrivateKey)
+	return &LoginResult{
+		User:         &user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// rehashPassword 使用当前配置的算法重新计算密码哈希，失败时保留原来的哈希，不影响登录
+func rehashPassword(user *models.User, password string) {
+	encrypted, err := utils.GenerateEncryptedPassword(password)
 	if err != nil {
-		return nil, "", "", code.ErrGenerateJWT
+		logger.Logger.Errorf("rehash password failed: %v", err)
+		return
 	}
-	refreshToken = refreshJwtToken
-	
-	// 将Access Token存入Redis
-	if err := redisClient.Set(context.Background(), config.Conf.Redis.KeyPrefix+":access_token:"+user.Email, accessToken, time.Duration(config.Conf.App.JwtExpirationTime)*time.Minute).Err(); err != nil {
-		logger.Logger.Errorf("redis set access token failed: %v", err)
-		return nil, "", "", err
+	if err := models.DB.Model(user).UpdateColumn("password", encrypted).Error; err != nil {
+		logger.Logger.Errorf("update password hash failed: %v", err)
+		return
 	}
-	
-	// 将Refresh Token存入Redis
-	if err := redisClient.Set(context.Background(), config.Conf.Redis.KeyPrefix+":refresh_token:"+user.Email, refreshToken, time.Duration(config.Conf.App.RefreshTokenExpiration)*time.Minute).Err(); err != nil {
-		logger.Logger.Errorf("redis set refresh token failed: %v", err)
-		return nil, "", "", err
+	user.Password = encrypted
+}
+
+// completeLogin 完成登录：签发令牌、更新用户的登录记录，并记录登录历史
+func completeLogin(user *models.User, meta SessionMeta) (string, string, error) {
+	// 签发令牌
+	accessToken, refreshToken, session, err := issueTokenPair(user, meta)
+	if err != nil {
+		return "", "", err
 	}
 
 	// 更新用户的登录记录
@@ -238,36 +259,34 @@ func (service *LoginUserService) Login() (*models.User, string, string, error) {
 	user.LoginCount++
 	
 	// 更新用户的信息
-	if err := postgreDB.Save(&user).Error; err != nil {
+	if err := models.DB.Save(user).Error; err != nil {
 		logger.Logger.Errorf("update user failed: %v", err)
-		return nil, "", "", code.ErrDatabase
+		return "", "", code.ErrDatabase
 	}
-	return &user, accessToken, refreshToken, nil
+
+	// 记录登录历史，新设备或新位置登录时发送提醒邮件
+	recordLoginSuccess(user, meta, session)
+	return accessToken, refreshToken, nil
 }
 
 // generateDefaultUser 生成默认用户
 // 返回一个带有默认值的用户对象
 func generateDefaultUser() *models.User {
 	return &models.User{
-		UserName:         "guest",
-		Password:         "guest",
-		Nickname:         "guest",
-		Email:            "guest@guest.com",
-		Phone:            "12345678910",
-		Avatar:           "",
-		Role:             "guest",
-		Status:           "inactive",
-		LastLogin:        time.Now(),
-		LastLogout:       time.Now(),
-		LoginCount:       0,
-		FailedLoginCount: 0,
-		LastFailedLogin:  time.Now(),
-		LastFailedReason: "",
-		IsLocked:         false,
-		LockUntil:        time.Now(),
-		DeletedBy:        "",
-		DeletedReason:    "",
-		IsActive:         false,
+		UserName:      "guest",
+		Password:      "guest",
+		Nickname:      "guest",
+		Email:         "guest@guest.com",
+		Phone:         "12345678910",
+		Avatar:        "",
+		Role:          "guest",
+		Status:        "inactive",
+		LastLogin:     time.Now(),
+		LastLogout:    time.Now(),
+		LoginCount:    0,
+		DeletedBy:     "",
+		DeletedReason:  "",
+		IsActive:      false,
 	}
 }
 
@@ -304,32 +323,19 @@ func (service *EmailVerificationCodeService) SendEmailVerificationCode() error {
 		}
 	}
 	
-	// TODO: 用消息队列发送邮件
 	// 发送邮件
-	if producer, err := mq.NewKafkaAsyncProducer(); err != nil {
-		logger.Logger.Errorf("new kafka producer failed: %v", err)
+	return sendVerificationCodeEmail(service.Email, verificationCode)
+}
+
+// sendVerificationCodeEmail 通过消息队列发送验证码邮件
+func sendVerificationCodeEmail(email, verificationCode string) error {
+	message := dto.EmailVerificationMessage{
+		Email:            email,
+		VerificationCode: verificationCode,
+	}
+	if err := publishEmailMessage(context.Background(), "email_verification", email, message); err != nil {
 		return code.ErrSendEmailVerificationCode
-	} else {
-		message := dto.EmailVerificationMessage{
-			Email:            service.Email,
-			VerificationCode: verificationCode,
-		}
-		// 将message转换为JSON字符串
-		messageJSON, err := json.Marshal(message)
-		if err != nil {
-			logger.Logger.Errorf("marshal message failed: %v", err)
-			return code.ErrSendEmailVerificationCode
-		}
-		// 设置email为key，使同一个邮箱都到同一个分区中
-		if err := producer.SendMessage(context.Background(), "email_verification", service.Email, messageJSON); err != nil {
-			logger.Logger.Errorf("send message to mq failed: %v", err)
-			return code.ErrSendEmailVerificationCode
-		}
 	}
-	// if err := email.SentVerifyCode(service.Email, verificationCode); err != nil {
-	// 	logger.Logger.Errorf("send email verification code failed: %v", err)
-	// 	return code.ErrSendEmailVerificationCode
-	// }
 	return nil
 }
 
@@ -432,13 +438,14 @@ func (service *GetUserProfileService) GetUserProfile() (*models.User, error) {
 type UpdateUserProfileService struct {
 	ID       uuid.UUID `json:"id" form:"id"`                           // 用户ID，必填
 	Nickname string    `json:"nickname" form:"nickname"`               // 昵称
-	Email    string    `json:"email" form:"email"`                     // 邮箱
+	Email    string    `json:"email" form:"email"`                     // 邮箱，只能通过修改邮箱接口验证后修改，与当前邮箱不同时返回错误
 	Phone    string    `json:"phone" form:"phone"`                     // 手机号
 	Bio      string    `json:"bio" form:"bio"`                         // 个人简介
 	Website  string    `json:"website" form:"website"`                 // 个人网站
 	Location string    `json:"location" form:"location"`               // 所在地
 	Birthday string    `json:"birthday" form:"birthday"`               // 生日
 	Gender   string    `json:"gender" form:"gender"`                   // 性别
+	MuteMentions *bool `json:"mute_mentions" form:"mute_mentions"`     // 是否屏蔽@提及通知
 }
 
 // UpdateUserProfile 更新用户资料
@@ -458,8 +465,9 @@ func (service *UpdateUserProfileService) UpdateUserProfile() error {
 	if service.Nickname != "" {
 		user.Nickname = service.Nickname
 	}
-	if service.Email != "" {
-		user.Email = service.Email
+	// 修改邮箱需要先验证新邮箱
+	if service.Email != "" && !strings.EqualFold(service.Email, user.Email) {
+		return code.ErrEmailChangeVerification
 	}
 	if service.Phone != "" {
 		user.Phone = service.Phone
@@ -479,41 +487,13 @@ func (service *UpdateUserProfileService) UpdateUserProfile() error {
 	if service.Gender != "" {
 		user.Gender = service.Gender
 	}
-	
-	// 保存用户信息
-	if err := postgreDB.Save(&user).Error; err != nil {
-		logger.Logger.Errorf("update user profile failed: %v", err)
-		return code.ErrDatabase
-	}
-	return nil
-}
-
-// UploadAvatarService 上传头像服务结构体
-// 用于处理上传用户头像的请求和业务逻辑
-type UploadAvatarService struct {
-	ID     uuid.UUID `json:"id" form:"id"`     // 用户ID，必填
-	Avatar string    `json:"avatar" form:"avatar" binding:"required"` // 头像URL，必填
-}
-
-// UploadAvatar 上传头像
-// 根据用户ID更新用户头像
-// 返回可能的错误
-func (service *UploadAvatarService) UploadAvatar() error {
-	postgreDB := models.DB
-	var user models.User
-	
-	// 根据ID查询用户
-	if err := postgreDB.First(&user, service.ID).Error; err != nil {
-		logger.Logger.Errorf("get user profile failed: %v", err)
-		return code.ErrUserNotFound
+	if service.MuteMentions != nil {
+		user.MuteMentions = *service.MuteMentions
 	}
 	
-	// 更新用户头像
-	user.Avatar = service.Avatar
-	
 	// 保存用户信息
 	if err := postgreDB.Save(&user).Error; err != nil {
-		logger.Logger.Errorf("update user avatar failed: %v", err)
+		logger.Logger.Errorf("update user profile failed: %v", err)
 		return code.ErrDatabase
 	}
 	return nil
@@ -559,7 +539,9 @@ func (service *ChangePasswordService) ChangePassword() error {
 		logger.Logger.Errorf("update user password failed: %v", err)
 		return code.ErrDatabase
 	}
-	return nil
+
+	// 修改密码后吊销之前签发的所有令牌
+	return revokeUserTokens(&user)
 }
 
 // GetOperationLogsService 获取操作日志服务结构体
@@ -4,6 +4,7 @@ import (
 	"blog-server/internal/config"
 	"blog-server/internal/logger"
 	"blog-server/internal/models"
+	"encoding/base64"
 	"github.com/google/uuid"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -104,12 +105,12 @@ func TestLogin(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			_, accessToken, refreshToken, err := tt.service.Login()
+			result, err := tt.service.Login()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
-			} else {
-				t.Logf("Login AccessToken: %v", accessToken)
-				t.Logf("Login RefreshToken: %v", refreshToken)
+			} else if result != nil {
+				t.Logf("Login AccessToken: %v", result.AccessToken)
+				t.Logf("Login RefreshToken: %v", result.RefreshToken)
 			}
 		})
 	}
@@ -189,6 +190,8 @@ func TestUpdateUserProfile(t *testing.T) {
 
 func TestUploadAvatar(t *testing.T) {
 	initTestEnvWithSQLite()
+	stubAvatarStorage(t)
+	avatarBase64 := "data:image/png;base64," + base64.StdEncoding.EncodeToString(encodeTestPNG(t, 300, 200))
 	test := []struct {
 		name    string
 		service *UploadAvatarService
@@ -206,15 +209,25 @@ func TestUploadAvatar(t *testing.T) {
 		{
 			name: "upload avatar success",
 			service: &UploadAvatarService{
-				ID: uuid.New(), // 假设存在用户ID
+				ID:           uuid.New(), // 假设存在用户ID
+				AvatarBase64: avatarBase64,
 			},
 			avatarURL: "/uploads/avatars/test.jpg",
 			wantErr: false,
 		},
+		{
+			name: "upload avatar fail - not an image",
+			service: &UploadAvatarService{
+				ID:           uuid.New(),
+				AvatarBase64: "data:image/png;base64,bm90IGFuIGltYWdl",
+			},
+			avatarURL: "/uploads/avatars/test.jpg",
+			wantErr: true,
+		},
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.service.UploadAvatar()
+			_, err := tt.service.UploadAvatar()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UploadAvatar() error = %v, wantErr %v", err, tt.wantErr)
 			} else {
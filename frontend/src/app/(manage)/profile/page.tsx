@@ -407,22 +407,8 @@ export default function ProfilePage() {
                 <div>
                   <p className="text-sm font-medium text-muted-foreground">账户状态</p>
                   <div className="flex items-center gap-2 mt-1">
-                    {profile?.is_locked ? (
-                      <>
-                        <Lock className="h-3 w-3 sm:h-4 sm:w-4 text-destructive" />
-                        <span className="text-sm sm:text-base text-destructive">已锁定</span>
-                        {profile?.lock_until && (
-                          <span className="text-xs sm:text-sm text-muted-foreground break-words">
-                            (至 {new Date(profile.lock_until).toLocaleString('zh-CN')})
-                          </span>
-                        )}
-                      </>
-                    ) : (
-                      <>
-                        <div className="h-2 w-2 bg-green-500 rounded-full"></div>
-                        <span className="text-sm sm:text-base">正常</span>
-                      </>
-                    )}
+                    <div className="h-2 w-2 bg-green-500 rounded-full"></div>
+                    <span className="text-sm sm:text-base">正常</span>
                   </div>
                 </div>
                 <div>
@@ -294,11 +294,10 @@ export default function ProfileEditForm({ profile, onSubmit, isLoading = false }
                     name="email"
                     type="email"
                     value={formData.email || ''}
-                    onChange={handleInputChange}
-                    placeholder="请输入邮箱地址"
-                    required
+                    readOnly
                     className={errors.email ? 'border-red-500' : ''}
                   />
+                  <p className="text-xs text-muted-foreground">修改邮箱需要验证新邮箱</p>
                   {errors.email && (
                     <Alert className="py-2 px-3">
                       <AlertCircle className="h-4 w-4" />
@@ -14,6 +14,7 @@ interface ValidateTokenResponse {
 interface RefreshTokenResponse {
   success: boolean;
   access_token: string;
+  refresh_token: string;
 }
 
 // 验证access token是否有效
@@ -44,6 +45,8 @@ export const useRefreshToken = (showToast = true) => {
       if (data?.data?.success) {
         // 更新localStorage中的token
         localStorage.setItem('access_token', data.data.access_token);
+        // refresh token每次使用后都会轮换，旧的refresh token不能再使用
+        localStorage.setItem('refresh_token', data.data.refresh_token);
         // 触发自定义事件，通知其他组件token已更新
         window.dispatchEvent(new Event('tokenUpdating'));
       }else{
@@ -2,6 +2,7 @@
 
 import api from "@/lib/api";
 import { post, get, put } from "@/utils/request";
+import { solvePowChallenge, type PowChallenge } from "@/lib/pow";
 import type { UserLoginRequest, UserLoginResponse, UserProfile, UpdateUserProfileRequest, ChangePasswordRequest, OperationLog, UserProfileResponse } from "@/interface/user";
 import type { BaseResponse } from "@/interface/base";
 import { useMutation, useQuery } from "@tanstack/react-query";
@@ -25,15 +26,29 @@ const {
 export function useUserLogin() {
     const router = useRouter()
     const {isPending, data, error, mutate} = useMutation({
-        mutationFn: (postData: UserLoginRequest) => {
-            // 创建 FormData 对象
-            const formData = new FormData();
-            formData.append('account', postData.account);
-            formData.append('password', postData.password);
-            // fetch 会自动设置正确的 Content-Type 和边界
-            return post<BaseResponse>(userLogin, {
-                body: formData
-            })
+        mutationFn: async (postData: UserLoginRequest) => {
+            const login = (pow?: { challenge: string; nonce: string }) => {
+                // 创建 FormData 对象
+                const formData = new FormData();
+                formData.append('account', postData.account);
+                formData.append('password', postData.password);
+                if (pow) {
+                    formData.append('pow_challenge', pow.challenge);
+                    formData.append('pow_nonce', pow.nonce);
+                }
+                // fetch 会自动设置正确的 Content-Type 和边界
+                return post<BaseResponse>(userLogin, {
+                    body: formData
+                })
+            }
+            const data = await login();
+            // 失败次数过多时需要先完成工作量证明，再重新登录
+            const challenge: PowChallenge | undefined = data.data?.challenge;
+            if (challenge && (data.code === 20264 || data.code === 20265)) {
+                const nonce = await solvePowChallenge(challenge);
+                return login({ challenge: challenge.challenge, nonce });
+            }
+            return data;
         },
         onSuccess(data) {
             if (data.code !== 0){
@@ -167,7 +182,7 @@ export function useUserCheckEmail() {
 export function useUserRegister() {
     const router = useRouter()
     const {isPending, data, error, mutate} = useMutation({
-        mutationFn: (postData: {user_name: string, password: string, email: string, verification_code: string}) => {
+        mutationFn: (postData: {user_name: string, password: string, email: string, verification_code: string, invite_code?: string}) => {
             return post<BaseResponse>(userRegister, {
                 body: postData
             })
@@ -16,8 +16,6 @@ export interface User extends BaseModel {
     birthday: string;
     gender: string;
     phone: string;
-    is_locked: boolean;
-    lock_until: string;
 }
 
 // eslint-disable-next-line @typescript-eslint/no-empty-object-type
@@ -0,0 +1,28 @@
+// 登录失败次数过多时服务端返回的工作量证明挑战
+export interface PowChallenge {
+  challenge: string
+  difficulty: number
+  expires_in: number
+}
+
+function leadingZeroBits(hash: Uint8Array) {
+  let zeros = 0
+  for (const byte of hash) {
+    if (byte !== 0) {
+      return zeros + Math.clz32(byte) - 24
+    }
+    zeros += 8
+  }
+  return zeros
+}
+
+// 找到nonce，使sha256(challenge + nonce)的前difficulty位为0
+export async function solvePowChallenge({ challenge, difficulty }: PowChallenge) {
+  const encoder = new TextEncoder()
+  for (let nonce = 0; ; nonce++) {
+    const digest = await crypto.subtle.digest("SHA-256", encoder.encode(challenge + nonce))
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return String(nonce)
+    }
+  }
+}
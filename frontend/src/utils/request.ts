@@ -113,6 +113,7 @@ const makeRequest = async <T = BaseResponse>(
     
     if (data?.data?.success) {
       localStorage.setItem('access_token', data.data.access_token);
+      localStorage.setItem('refresh_token', data.data.refresh_token);
       window.dispatchEvent(new Event('tokenUpdating'));
       return data;
     } else {